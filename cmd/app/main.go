@@ -1,16 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
 	"log"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/thiagohmm/integracaocron/configuration"
 	"github.com/thiagohmm/integracaocron/domain/repositories"
 	"github.com/thiagohmm/integracaocron/domain/usecases"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
 	"github.com/thiagohmm/integracaocron/infraestructure/database"
+	amqpbroker "github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
 	rabbitmq "github.com/thiagohmm/integracaocron/internal/delivery"
 )
 
@@ -23,8 +31,17 @@ func main() {
 		log.Fatalf("Erro ao carregar configuração: %v", err)
 	}
 
-	// Connect to database
-	db, err := database.ConectarBanco(cfg)
+	// Get number of workers from environment or use default; it also sizes the DB pool
+	workers := getWorkersCount(cfg)
+	cfg.Workers = workers
+
+	// Connect to database; SIGTERM during startup stops the attempts
+	startupCtx, stopStartup := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	db, err := database.ConectarBancoContext(startupCtx, cfg)
+	stopStartup()
+	if errors.Is(err, database.ErrConexaoFatal) {
+		log.Fatalf("Erro fatal ao conectar ao banco de dados, verifique DB_USER, DB_PASSWD e DB_CONNECTSTRING: %v", err)
+	}
 	if err != nil {
 		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
 	}
@@ -35,6 +52,9 @@ func main() {
 	}()
 
 	// Initialize repositories
+	repositories.SetQueryTimeout(time.Duration(cfg.DBQueryTimeout) * time.Second)
+	database.SetRetryAttempts(cfg.DBRetryAttempts)
+	database.SetCircuitBreakerSettings(cfg.CircuitBreakerFailures, time.Duration(cfg.CircuitBreakerOpenSeconds)*time.Second)
 	promotionRepo := repositories.NewPromotionRepository(db)
 	parameterRepo := repositories.NewParameterRepository(db)
 	integrationRepo := repositories.NewIntegrationRepository(db)
@@ -44,18 +64,60 @@ func main() {
 
 	// Get RabbitMQ configuration
 	rabbitmqURL := getRabbitMQURL(cfg)
-	if rabbitmqURL == "" {
+	if rabbitmqURL == "" && cfg.Broker != brokerMemory {
 		log.Fatal("URL do RabbitMQ não configurada")
 	}
+	amqpbroker.SetPublishChannels(cfg.RabbitMQPublishChannels)
+	defer amqpbroker.CloseConnections()
+
+	// Shared LogIntegrRMS publisher, falling back to LOG_INTEGR_RMS when RabbitMQ is down
+	logPublisher := usecases.NewLogPublisher(rabbitmqURL, productIntegrationRepo)
+	logPublisher.SetExchange(cfg.LogExchange)
+	logPublisher.SetQueue(cfg.LogQueue)
+	logPublisher.SetFormat(cfg.LogFormat)
+	productIntegrationRepo.SetLogPublisher(logPublisher)
+	promotionNormalizationRepo.SetLogPublisher(logPublisher)
 
 	// Initialize use cases
 	integrationJobUC := usecases.NewIntegrationJobUseCase(parameterRepo, integrationRepo, networkRepo, db)
 	promotionUC := usecases.NewPromotionUseCase(promotionRepo, rabbitmqURL, integrationJobUC)
-	productIntegrationUC := usecases.NewProductIntegrationUseCase(productIntegrationRepo, db)
-	promotionNormalizationUC := usecases.NewPromotionNormalizationUseCase(promotionNormalizationRepo, db)
+	promotionUC.SetLogPublisher(logPublisher)
+	productIntegrationUC := usecases.NewProductIntegrationUseCase(productIntegrationRepo, parameterRepo, db)
+	productIntegrationUC.SetShadowReportPath(cfg.ProductShadowReportPath)
+	productIntegrationUC.SetCatalogCacheTTL(time.Duration(cfg.ProductCatalogCacheTTL) * time.Second)
+	marketingStructureUC := usecases.NewMarketingStructureIntegrationUseCase(productIntegrationRepo, db)
+	productExportUC := usecases.NewProductExportUseCase(productIntegrationRepo, parameterRepo, rabbitmqURL)
+	productExportUC.SetExportDir(cfg.ProductExportDir)
+	productExportUC.SetExchange(cfg.ProductExportExchange)
+	productExportUC.SetDestination(cfg.ProductExportDestination)
+	productExportUC.SetPageSize(cfg.ProductExportPageSize)
+	productExportUC.SetCatalogCacheTTL(time.Duration(cfg.ProductCatalogCacheTTL) * time.Second)
+	promotionNormalizationUC := usecases.NewPromotionNormalizationUseCase(promotionNormalizationRepo, parameterRepo, db)
+	promotionNormalizationUC.SetPageSize(cfg.PromotionNormalizationPageSize)
+	if cfg.PromotionNormalizationRules != "" {
+		pipeline, err := usecases.ParsePromotionNormalizationRules(cfg.PromotionNormalizationRules)
+		if err != nil {
+			log.Fatalf("Erro nas regras de normalização de promoções: %v", err)
+		}
+		promotionNormalizationUC.SetPipeline(pipeline)
+	}
 
-	// Get number of workers from environment or use default
-	workers := getWorkersCount()
+	// Transactional outbox: logs are committed with the change and published by the relay
+	var outboxRelay *usecases.LogOutboxRelay
+	if cfg.LogOutboxEnabled {
+		outbox := usecases.NewLogOutbox(db, repositories.NewLogOutboxRepository(db))
+		promotionUC.SetLogOutbox(outbox)
+		productIntegrationUC.SetLogOutbox(outbox)
+		marketingStructureUC.SetLogOutbox(outbox)
+		promotionNormalizationUC.SetLogOutbox(outbox)
+
+		outboxRelay = usecases.NewLogOutboxRelay(outbox, logPublisher)
+		outboxRelay.SetInterval(time.Duration(cfg.LogOutboxInterval) * time.Second)
+		outboxRelay.SetBatchSize(cfg.LogOutboxBatchSize)
+		outboxRelay.SetMaxAttempts(cfg.LogOutboxMaxAttempts)
+		outboxRelay.SetRetention(time.Duration(cfg.LogOutboxRetentionHours) * time.Hour)
+		outboxRelay.Start()
+	}
 
 	// Initialize RabbitMQ listener
 	listener := &rabbitmq.Listener{
@@ -63,21 +125,71 @@ func main() {
 		IntegrationUc:            integrationJobUC,
 		ProductIntegrationUC:     productIntegrationUC,
 		PromotionNormalizationUC: promotionNormalizationUC,
+		MarketingStructureUC:     marketingStructureUC,
+		ProductExportUC:          productExportUC,
 		Workers:                  workers,
+		MessageTimeout:           time.Duration(cfg.MessageTimeout) * time.Second,
+		MaxRequeues:              cfg.MessageMaxRequeues,
+		RetryDelay:               time.Duration(cfg.MessageRetryDelay) * time.Second,
+	}
+
+	if cfg.HealthAddr != "" {
+		health := &rabbitmq.HealthServer{Addr: cfg.HealthAddr, DB: db}
+		health.Start()
 	}
 
+	// Application context, cancelled on shutdown to interrupt in-flight messages
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Setup graceful shutdown
-	setupGracefulShutdown()
+	setupGracefulShutdown(cancel, outboxRelay)
+
+	// BROKER=memory runs without RabbitMQ: messages are read from stdin, one per line
+	if cfg.Broker == brokerMemory {
+		memoryBroker := broker.NewMemoryBroker()
+		logPublisher.SetBroker(memoryBroker)
+		productExportUC.SetBroker(memoryBroker)
+		listener.Consumer = memoryBroker
+		listener.Publisher = memoryBroker
+		go feedMemoryBroker(ctx, memoryBroker, os.Stdin)
+	}
 
 	// Start listening to RabbitMQ
 	log.Printf("Iniciando listener RabbitMQ com %d workers", workers)
 	log.Printf("Conectando ao RabbitMQ: %s", maskRabbitMQURL(rabbitmqURL))
 
-	if err := listener.ListenToQueue(rabbitmqURL); err != nil {
+	if err := listener.ListenToQueueContext(ctx, rabbitmqURL); err != nil && !errors.Is(err, context.Canceled) {
 		log.Fatalf("Erro ao iniciar listener RabbitMQ: %v", err)
 	}
 }
 
+// brokerMemory is the BROKER value that replaces RabbitMQ with an in-memory broker
+const brokerMemory = "memory"
+
+// feedMemoryBroker publishes each line read from r to the listener queue
+func feedMemoryBroker(ctx context.Context, memoryBroker *broker.MemoryBroker, r io.Reader) {
+	queue := "integracaoCron"
+	memoryBroker.DeclareQueue(queue)
+	log.Printf("Broker em memória: cada linha da entrada padrão é publicada na fila %s", queue)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := memoryBroker.Publish(ctx, "", queue, broker.Message{ContentType: "text/plain", Body: []byte(line)}); err != nil {
+			log.Printf("Erro ao publicar mensagem no broker em memória: %v", err)
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Erro lendo a entrada padrão: %v", err)
+	}
+}
+
 // loadConfiguration loads the application configuration
 func loadConfiguration() (*configuration.Conf, error) {
 	// Try to load from .env file in current directory
@@ -104,10 +216,13 @@ func getRabbitMQURL(cfg *configuration.Conf) string {
 	return rabbitmqURL
 }
 
-// getWorkersCount gets the number of workers from environment or uses default
-func getWorkersCount() int {
+// getWorkersCount gets the number of workers from environment, then the config file, or uses default
+func getWorkersCount(cfg *configuration.Conf) int {
 	workersStr := os.Getenv("WORKERS")
 	if workersStr == "" {
+		if cfg.Workers > 0 {
+			return cfg.Workers
+		}
 		return 20 // Default number of workers
 	}
 
@@ -134,7 +249,7 @@ func maskRabbitMQURL(url string) string {
 }
 
 // setupGracefulShutdown sets up graceful shutdown handling
-func setupGracefulShutdown() {
+func setupGracefulShutdown(cancel context.CancelFunc, outboxRelay *usecases.LogOutboxRelay) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
@@ -142,8 +257,14 @@ func setupGracefulShutdown() {
 		sig := <-c
 		log.Printf("Recebido sinal %v, iniciando shutdown graceful...", sig)
 
+		// Interrupt in-flight database operations; their messages go back to the queue
+		cancel()
+
 		// Here you could add cleanup logic if needed
 		// For example, closing connections, finishing current work, etc.
+		if outboxRelay != nil {
+			outboxRelay.Stop()
+		}
 
 		log.Println("Aplicação finalizada.")
 		os.Exit(0)
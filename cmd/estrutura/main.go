@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/thiagohmm/integracaocron/configuration"
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+	"github.com/thiagohmm/integracaocron/domain/usecases"
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run executes the selected mode and returns the exit code, so the deferred db.Close runs
+// before the process exits
+func run() int {
+	var mode = flag.String("mode", "dump", "Operation: dump (print the tree), validate (list hierarchy problems), resolve (level 1-4 path of -id)")
+	var id = flag.Int("id", 0, "ID_ESTRUTURA_MERCADOLOGICA used by -mode resolve")
+	var asJSON = flag.Bool("json", false, "Print validate/resolve output as JSON")
+	flag.Parse()
+
+	cfg, err := configuration.LoadConfig(".")
+	if err != nil {
+		log.Printf("Erro ao carregar configuração: %v", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	db, err := database.ConectarBancoContext(ctx, cfg)
+	if err != nil {
+		log.Printf("Erro ao conectar ao banco de dados: %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	structures, err := repositories.NewProductIntegrationRepository(db).ListMarketingStructures(ctx)
+	if err != nil {
+		log.Printf("Erro ao carregar estrutura mercadológica: %v", err)
+		return 1
+	}
+	tree := usecases.NewMarketingStructureTree(structures)
+	log.Printf("Estrutura mercadológica carregada: %d registros", tree.Len())
+
+	switch *mode {
+	case "dump":
+		if err := tree.Dump(os.Stdout); err != nil {
+			log.Printf("Erro ao imprimir árvore: %v", err)
+			return 1
+		}
+
+	case "validate":
+		issues := tree.Validate()
+		if *asJSON {
+			if err := printJSON(issues); err != nil {
+				log.Printf("Erro ao serializar saída: %v", err)
+				return 1
+			}
+		} else {
+			for _, issue := range issues {
+				fmt.Printf("%-12s [%d] %s: %s\n", issue.Tipo, issue.IdEstruturaMercadologica, issue.Descricao, issue.Mensagem)
+			}
+			fmt.Printf("%d problema(s) encontrado(s)\n", len(issues))
+		}
+		if len(issues) > 0 {
+			return 1
+		}
+
+	case "resolve":
+		if *id <= 0 {
+			log.Print("Informe -id com o ID da estrutura mercadológica de nível 4")
+			return 1
+		}
+		path, err := tree.Resolve(*id)
+		if err != nil {
+			log.Printf("Erro ao resolver estrutura %d: %v", *id, err)
+			return 1
+		}
+		if *asJSON {
+			if err := printJSON(path); err != nil {
+				log.Printf("Erro ao serializar saída: %v", err)
+				return 1
+			}
+			return 0
+		}
+		for level, ms := range []entities.MarketingStructure{path.Nivel1, path.Nivel2, path.Nivel3, path.Nivel4} {
+			fmt.Printf("N%d [%d] %s\n", level+1, *ms.IdEstruturaMercadologica, ms.DescricaoEstrutura)
+		}
+
+	default:
+		log.Printf("Modo desconhecido: %s (use dump, validate ou resolve)", *mode)
+		return 1
+	}
+	return 0
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
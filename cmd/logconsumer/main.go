@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/configuration"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+	"github.com/thiagohmm/integracaocron/domain/usecases"
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
+	rabbitmq "github.com/thiagohmm/integracaocron/internal/delivery"
+)
+
+func main() {
+	cfg, err := configuration.LoadConfig(".")
+	if err != nil {
+		log.Fatalf("Erro ao carregar configuração: %v", err)
+	}
+
+	var queue = flag.String("queue", cfg.LogQueue, "Queue with the LogIntegrRMS messages (default \"log\")")
+	var batchSize = flag.Int("batch", cfg.LogConsumerBatchSize, "Messages inserted per batch (default 100)")
+	var interval = flag.Int("interval", cfg.LogConsumerFlushInterval, "Seconds before a partial batch is inserted (default 2)")
+	flag.Parse()
+
+	rabbitmqURL := os.Getenv("ENV_RABBITMQ")
+	if rabbitmqURL == "" {
+		rabbitmqURL = cfg.ENV_RABBITMQ
+	}
+	if rabbitmqURL == "" {
+		log.Fatal("URL do RabbitMQ não configurada")
+	}
+
+	db, err := database.ConectarBanco(cfg)
+	if err != nil {
+		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+	defer db.Close()
+
+	consumer := &rabbitmq.LogConsumer{
+		UC:            usecases.NewLogConsumerUseCase(repositories.NewProductIntegrationRepository(db), db),
+		Queue:         *queue,
+		BatchSize:     *batchSize,
+		FlushInterval: time.Duration(*interval) * time.Second,
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-c
+		log.Printf("Recebido sinal %v, gravando lote pendente...", sig)
+		consumer.Stop()
+	}()
+
+	consumer.Run(rabbitmqURL)
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 
-	"github.com/streadway/amqp"
 	"github.com/thiagohmm/integracaocron/configuration"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+	"github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
 )
 
 func main() {
@@ -22,30 +24,13 @@ func main() {
 	}
 
 	// Connect to RabbitMQ
-	conn, err := amqp.Dial(cfg.ENV_RABBITMQ)
-	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
-	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
-	}
-	defer ch.Close()
+	var publisher broker.Publisher = rabbitmq.NewAMQPBroker(cfg.ENV_RABBITMQ)
+	defer rabbitmq.CloseConnections()
 
 	queueName := "integracaoCron"
 
 	// Declare queue to ensure it exists
-	_, err = ch.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
+	if err := publisher.DeclareQueue(queueName); err != nil {
 		log.Fatalf("Failed to declare queue: %v", err)
 	}
 
@@ -94,16 +79,10 @@ func main() {
 	}
 
 	// Send message
-	err = ch.Publish(
-		"",        // exchange
-		queueName, // routing key
-		false,     // mandatory
-		false,     // immediate
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(messageBody),
-		},
-	)
+	err = publisher.Publish(context.Background(), "", queueName, broker.Message{
+		ContentType: "text/plain",
+		Body:        []byte(messageBody),
+	})
 	if err != nil {
 		log.Fatalf("Failed to publish message: %v", err)
 	}
@@ -3,56 +3,87 @@ package configuration
 import (
 	"fmt"
 	"log"
-	"regexp"
-	"strconv"
 
 	"github.com/spf13/viper"
 )
 
 type Conf struct {
-	DBDriver           string `mapstructure:"DB_DIALECT"`
-	DBUser             string `mapstructure:"DB_USER"`
-	DBPassword         string `mapstructure:"DB_PASSWD"`
-	DBSchema           string `mapstructure:"DB_SCHEMA"`
-	DBConnect          string `mapstructure:"DB_CONNECTSTRING"`
-	ServiceName        string
-	Port               int
-	Host               string
-	ENV_RABBITMQ       string `mapstructure:"ENV_RABBITMQ"`
-	ENV_REDIS_ADDR     string `mapstructure:"ENV_REDIS_ADDRESS"`
-	ENV_REDIS_PASSWORD string `mapstructure:"ENV_REDIS_PASSWORD"`
-	ENV_REDIS_EXPIRE   int    `mapstructure:"ENV_REDIS_EXPIRE"`
-}
-
-type Dados struct {
-	ServiceName string
-	Port        int
-	Host        string
-}
-
-func extrairDados(descricao string) (Dados, error) {
-	var dados Dados
-
-	// Expressões regulares para extrair os valores
-	serviceNamePattern := regexp.MustCompile(`service_name=([\w.]+)`)
-	portPattern := regexp.MustCompile(`port=(\d+)`)
-	hostPattern := regexp.MustCompile(`host=([\d.]+)`)
-
-	// Encontrar os valores usando as expressões regulares
-	serviceNameMatch := serviceNamePattern.FindStringSubmatch(descricao)
-	portMatch := portPattern.FindStringSubmatch(descricao)
-	hostMatch := hostPattern.FindStringSubmatch(descricao)
-
-	// Verificar se todos os valores foram encontrados
-	if len(serviceNameMatch) < 2 || len(portMatch) < 2 || len(hostMatch) < 2 {
-		return dados, fmt.Errorf("não foi possível extrair todos os dados")
-	}
-
-	dados.ServiceName = serviceNameMatch[1]
-	dados.Port, _ = strconv.Atoi(portMatch[1])
-	dados.Host = hostMatch[1]
-
-	return dados, nil
+	DBDriver   string `mapstructure:"DB_DIALECT"`
+	DBUser     string `mapstructure:"DB_USER"`
+	DBPassword string `mapstructure:"DB_PASSWD"`
+	DBSchema   string `mapstructure:"DB_SCHEMA"`
+	DBConnect  string `mapstructure:"DB_CONNECTSTRING"`
+	TNSAdmin   string `mapstructure:"TNS_ADMIN"`
+
+	// Extracted from DB_CONNECTSTRING
+	ServiceName  string
+	Port         int
+	Host         string
+	SID          string
+	InstanceName string
+	// ConnectDescriptor is the full TNS descriptor when DB_CONNECTSTRING is one or a TNS alias
+	ConnectDescriptor string
+
+	// Connection pool, sized from Workers when the limits are zero
+	Workers           int `mapstructure:"WORKERS"`
+	DBMaxOpenConns    int `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime int `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime int `mapstructure:"DB_CONN_MAX_IDLE_TIME"`
+	DBConnectTimeout  int `mapstructure:"DB_CONNECT_TIMEOUT"`
+	DBStartupMaxWait  int `mapstructure:"DB_STARTUP_MAX_WAIT"`
+
+	// Timeouts, in seconds, of each repository operation and of each queue message (0 = no limit)
+	DBQueryTimeout int `mapstructure:"DB_QUERY_TIMEOUT"`
+	MessageTimeout int `mapstructure:"MESSAGE_TIMEOUT"`
+
+	// Retries of transient Oracle errors and requeues of messages that failed with them
+	DBRetryAttempts    int `mapstructure:"DB_RETRY_ATTEMPTS"`
+	MessageMaxRequeues int `mapstructure:"MESSAGE_MAX_REQUEUES"`
+	MessageRetryDelay  int `mapstructure:"MESSAGE_RETRY_DELAY"`
+
+	// Circuit breakers of the stored procedures and the health endpoint (empty = disabled)
+	CircuitBreakerFailures    int    `mapstructure:"CIRCUIT_BREAKER_FAILURES"`
+	CircuitBreakerOpenSeconds int    `mapstructure:"CIRCUIT_BREAKER_OPEN_SECONDS"`
+	HealthAddr                string `mapstructure:"HEALTH_ADDR"`
+
+	// TLS and Oracle wallet
+	DBSSL            string `mapstructure:"DB_SSL"`
+	DBSSLVerify      bool   `mapstructure:"DB_SSL_VERIFY"`
+	DBSSLCA          string `mapstructure:"DB_SSL_CA"`
+	DBWallet         string `mapstructure:"DB_WALLET"`
+	DBWalletPassword string `mapstructure:"DB_WALLET_PASSWORD"`
+
+	ENV_RABBITMQ            string `mapstructure:"ENV_RABBITMQ"`
+	Broker                  string `mapstructure:"BROKER"`                    // "rabbitmq" (default) or "memory"
+	RabbitMQPublishChannels int    `mapstructure:"RABBITMQ_PUBLISH_CHANNELS"` // pooled publishing channels
+	ENV_REDIS_ADDR          string `mapstructure:"ENV_REDIS_ADDRESS"`
+	ENV_REDIS_PASSWORD      string `mapstructure:"ENV_REDIS_PASSWORD"`
+	ENV_REDIS_EXPIRE        int    `mapstructure:"ENV_REDIS_EXPIRE"`
+
+	PromotionNormalizationRules    string `mapstructure:"PROMOTION_NORMALIZATION_RULES"`
+	PromotionNormalizationPageSize int    `mapstructure:"PROMOTION_NORMALIZATION_PAGE_SIZE"`
+
+	ProductShadowReportPath string `mapstructure:"PRODUCT_SHADOW_REPORT_PATH"`
+	ProductCatalogCacheTTL  int    `mapstructure:"PRODUCT_CATALOG_CACHE_TTL"`
+
+	ProductExportDir         string `mapstructure:"PRODUCT_EXPORT_DIR"`
+	ProductExportExchange    string `mapstructure:"PRODUCT_EXPORT_EXCHANGE"`
+	ProductExportDestination string `mapstructure:"PRODUCT_EXPORT_DESTINATION"`
+	ProductExportPageSize    int    `mapstructure:"PRODUCT_EXPORT_PAGE_SIZE"`
+
+	LogExchange string `mapstructure:"LOG_EXCHANGE"`
+	LogQueue    string `mapstructure:"LOG_QUEUE"`
+	LogFormat   string `mapstructure:"LOG_MESSAGE_FORMAT"`
+
+	LogOutboxEnabled        bool `mapstructure:"LOG_OUTBOX_ENABLED"`
+	LogOutboxInterval       int  `mapstructure:"LOG_OUTBOX_INTERVAL"`
+	LogOutboxBatchSize      int  `mapstructure:"LOG_OUTBOX_BATCH_SIZE"`
+	LogOutboxMaxAttempts    int  `mapstructure:"LOG_OUTBOX_MAX_ATTEMPTS"`
+	LogOutboxRetentionHours int  `mapstructure:"LOG_OUTBOX_RETENTION_HOURS"`
+
+	LogConsumerBatchSize     int `mapstructure:"LOG_CONSUMER_BATCH_SIZE"`
+	LogConsumerFlushInterval int `mapstructure:"LOG_CONSUMER_FLUSH_INTERVAL"`
 }
 
 // Carrega as configurações do arquivo .env e das variáveis de ambiente
@@ -72,11 +103,59 @@ func LoadConfig(path string) (*Conf, error) {
 		cfg.DBPassword = viper.GetString("DB_PASSWD")
 		cfg.DBSchema = viper.GetString("DB_SCHEMA")
 		cfg.DBConnect = viper.GetString("DB_CONNECTSTRING")
+		cfg.TNSAdmin = viper.GetString("TNS_ADMIN")
+
+		cfg.Workers = viper.GetInt("WORKERS")
+		cfg.DBMaxOpenConns = viper.GetInt("DB_MAX_OPEN_CONNS")
+		cfg.DBMaxIdleConns = viper.GetInt("DB_MAX_IDLE_CONNS")
+		cfg.DBConnMaxLifetime = viper.GetInt("DB_CONN_MAX_LIFETIME")
+		cfg.DBConnMaxIdleTime = viper.GetInt("DB_CONN_MAX_IDLE_TIME")
+		cfg.DBConnectTimeout = viper.GetInt("DB_CONNECT_TIMEOUT")
+		cfg.DBStartupMaxWait = viper.GetInt("DB_STARTUP_MAX_WAIT")
+		cfg.DBQueryTimeout = viper.GetInt("DB_QUERY_TIMEOUT")
+		cfg.MessageTimeout = viper.GetInt("MESSAGE_TIMEOUT")
+		cfg.DBRetryAttempts = viper.GetInt("DB_RETRY_ATTEMPTS")
+		cfg.MessageMaxRequeues = viper.GetInt("MESSAGE_MAX_REQUEUES")
+		cfg.MessageRetryDelay = viper.GetInt("MESSAGE_RETRY_DELAY")
+		cfg.CircuitBreakerFailures = viper.GetInt("CIRCUIT_BREAKER_FAILURES")
+		cfg.CircuitBreakerOpenSeconds = viper.GetInt("CIRCUIT_BREAKER_OPEN_SECONDS")
+		cfg.HealthAddr = viper.GetString("HEALTH_ADDR")
+		cfg.DBSSL = viper.GetString("DB_SSL")
+		cfg.DBSSLVerify = viper.GetBool("DB_SSL_VERIFY")
+		cfg.DBSSLCA = viper.GetString("DB_SSL_CA")
+		cfg.DBWallet = viper.GetString("DB_WALLET")
+		cfg.DBWalletPassword = viper.GetString("DB_WALLET_PASSWORD")
 		cfg.ENV_RABBITMQ = viper.GetString("ENV_RABBITMQ")
+		cfg.Broker = viper.GetString("BROKER")
+		cfg.RabbitMQPublishChannels = viper.GetInt("RABBITMQ_PUBLISH_CHANNELS")
 
 		cfg.ENV_REDIS_ADDR = viper.GetString("ENV_REDIS_ADDRESS")
 		cfg.ENV_REDIS_PASSWORD = viper.GetString("ENV_REDIS_PASSWORD")
 		cfg.ENV_REDIS_EXPIRE = viper.GetInt("ENV_REDIS_EXPIRE")
+
+		cfg.PromotionNormalizationRules = viper.GetString("PROMOTION_NORMALIZATION_RULES")
+		cfg.PromotionNormalizationPageSize = viper.GetInt("PROMOTION_NORMALIZATION_PAGE_SIZE")
+
+		cfg.ProductShadowReportPath = viper.GetString("PRODUCT_SHADOW_REPORT_PATH")
+		cfg.ProductCatalogCacheTTL = viper.GetInt("PRODUCT_CATALOG_CACHE_TTL")
+
+		cfg.ProductExportDir = viper.GetString("PRODUCT_EXPORT_DIR")
+		cfg.ProductExportExchange = viper.GetString("PRODUCT_EXPORT_EXCHANGE")
+		cfg.ProductExportDestination = viper.GetString("PRODUCT_EXPORT_DESTINATION")
+		cfg.ProductExportPageSize = viper.GetInt("PRODUCT_EXPORT_PAGE_SIZE")
+
+		cfg.LogExchange = viper.GetString("LOG_EXCHANGE")
+		cfg.LogQueue = viper.GetString("LOG_QUEUE")
+		cfg.LogFormat = viper.GetString("LOG_MESSAGE_FORMAT")
+
+		cfg.LogOutboxEnabled = viper.GetBool("LOG_OUTBOX_ENABLED")
+		cfg.LogOutboxInterval = viper.GetInt("LOG_OUTBOX_INTERVAL")
+		cfg.LogOutboxBatchSize = viper.GetInt("LOG_OUTBOX_BATCH_SIZE")
+		cfg.LogOutboxMaxAttempts = viper.GetInt("LOG_OUTBOX_MAX_ATTEMPTS")
+		cfg.LogOutboxRetentionHours = viper.GetInt("LOG_OUTBOX_RETENTION_HOURS")
+
+		cfg.LogConsumerBatchSize = viper.GetInt("LOG_CONSUMER_BATCH_SIZE")
+		cfg.LogConsumerFlushInterval = viper.GetInt("LOG_CONSUMER_FLUSH_INTERVAL")
 	} else {
 		err = viper.Unmarshal(&cfg)
 		if err != nil {
@@ -86,7 +165,7 @@ func LoadConfig(path string) (*Conf, error) {
 	}
 
 	// Extrair os dados da string de conexão
-	dados, err := extrairDados(cfg.DBConnect)
+	dados, err := extrairDados(cfg.DBConnect, tnsDirs(&cfg)...)
 	if err != nil {
 		return &cfg, err
 	}
@@ -95,6 +174,9 @@ func LoadConfig(path string) (*Conf, error) {
 	cfg.ServiceName = dados.ServiceName
 	cfg.Port = dados.Port
 	cfg.Host = dados.Host
+	cfg.SID = dados.SID
+	cfg.InstanceName = dados.InstanceName
+	cfg.ConnectDescriptor = dados.Descriptor
 
 	return &cfg, nil
 }
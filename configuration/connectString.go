@@ -0,0 +1,296 @@
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultOraclePort is used when the connect string has no port
+const defaultOraclePort = 1521
+
+// Dados are the connection parameters extracted from DB_CONNECTSTRING
+type Dados struct {
+	ServiceName  string
+	Port         int
+	Host         string
+	SID          string
+	InstanceName string
+	// Descriptor is set for TNS descriptors and aliases, which go-ora parses itself
+	Descriptor string
+}
+
+var (
+	legacyHostPattern        = regexp.MustCompile(`(?i)\bhost=([^\s]+)`)
+	legacyPortPattern        = regexp.MustCompile(`(?i)\bport=(\d+)`)
+	legacyServiceNamePattern = regexp.MustCompile(`(?i)\bservice_name=([^\s]+)`)
+	legacySIDPattern         = regexp.MustCompile(`(?i)\bsid=([^\s]+)`)
+
+	descriptorHostPattern        = regexp.MustCompile(`(?i)\(\s*HOST\s*=\s*([^)\s]+)\s*\)`)
+	descriptorPortPattern        = regexp.MustCompile(`(?i)\(\s*PORT\s*=\s*(\d+)\s*\)`)
+	descriptorServiceNamePattern = regexp.MustCompile(`(?i)\(\s*SERVICE_NAME\s*=\s*([^)\s]+)\s*\)`)
+	descriptorSIDPattern         = regexp.MustCompile(`(?i)\(\s*SID\s*=\s*([^)\s]+)\s*\)`)
+
+	tnsAliasPattern = regexp.MustCompile(`^[A-Za-z][\w.-]*$`)
+)
+
+// extrairDados reads DB_CONNECTSTRING in any of the supported forms:
+//   - host=db.example.com port=1521 service_name=ORCL (or sid=ORCL)
+//   - EZConnect: [//]host[:port]/service[:server][/instance], IPv6 hosts in brackets
+//   - a full TNS descriptor, e.g. (DESCRIPTION=(ADDRESS_LIST=...)(CONNECT_DATA=...))
+//   - a TNS alias looked up in tnsnames.ora of tnsDirs
+func extrairDados(descricao string, tnsDirs ...string) (Dados, error) {
+	descricao = strings.TrimSpace(descricao)
+	switch {
+	case descricao == "":
+		return Dados{}, fmt.Errorf("DB_CONNECTSTRING não definida")
+	case strings.HasPrefix(descricao, "("):
+		return parseDescriptor(descricao)
+	case legacyHostPattern.MatchString(descricao):
+		return parseLegacy(descricao)
+	case strings.ContainsAny(descricao, ":/"):
+		return parseEZConnect(descricao)
+	case tnsAliasPattern.MatchString(descricao):
+		descriptor, err := lookupTNSAlias(descricao, tnsDirs)
+		if err != nil {
+			return Dados{}, err
+		}
+		return parseDescriptor(descriptor)
+	default:
+		return Dados{}, fmt.Errorf("formato de DB_CONNECTSTRING não reconhecido: %q", descricao)
+	}
+}
+
+// parseLegacy reads the "host=... port=... service_name=..." form
+func parseLegacy(descricao string) (Dados, error) {
+	dados := Dados{
+		Host: legacyHostPattern.FindStringSubmatch(descricao)[1],
+		Port: defaultOraclePort,
+	}
+	if match := legacyPortPattern.FindStringSubmatch(descricao); match != nil {
+		dados.Port, _ = strconv.Atoi(match[1])
+	}
+	if match := legacyServiceNamePattern.FindStringSubmatch(descricao); match != nil {
+		dados.ServiceName = match[1]
+	}
+	if match := legacySIDPattern.FindStringSubmatch(descricao); match != nil {
+		dados.SID = match[1]
+	}
+
+	if dados.ServiceName == "" && dados.SID == "" {
+		return dados, fmt.Errorf("DB_CONNECTSTRING sem service_name ou sid")
+	}
+	return dados, nil
+}
+
+// parseEZConnect reads [//]host[:port]/service[:server][/instance][?options]
+func parseEZConnect(descricao string) (Dados, error) {
+	dados := Dados{Port: defaultOraclePort}
+
+	rest := strings.TrimPrefix(descricao, "//")
+	if i := strings.Index(rest, "?"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	var hostPort string
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return dados, fmt.Errorf("endereço IPv6 sem ']' em DB_CONNECTSTRING: %q", descricao)
+		}
+		dados.Host = rest[1:end]
+		hostPort, rest = rest[end+1:], ""
+		if i := strings.Index(hostPort, "/"); i >= 0 {
+			hostPort, rest = hostPort[:i], hostPort[i+1:]
+		}
+		hostPort = strings.TrimPrefix(hostPort, ":")
+	} else {
+		if i := strings.Index(rest, "/"); i >= 0 {
+			hostPort, rest = rest[:i], rest[i+1:]
+		} else {
+			hostPort, rest = rest, ""
+		}
+		dados.Host = hostPort
+		if i := strings.LastIndex(hostPort, ":"); i >= 0 {
+			dados.Host, hostPort = hostPort[:i], hostPort[i+1:]
+		} else {
+			hostPort = ""
+		}
+	}
+
+	if hostPort != "" {
+		port, err := strconv.Atoi(hostPort)
+		if err != nil || port <= 0 {
+			return dados, fmt.Errorf("porta inválida em DB_CONNECTSTRING: %q", hostPort)
+		}
+		dados.Port = port
+	}
+	if dados.Host == "" {
+		return dados, fmt.Errorf("DB_CONNECTSTRING sem host: %q", descricao)
+	}
+
+	// service[:dedicated|shared|pooled][/instance]
+	if i := strings.Index(rest, "/"); i >= 0 {
+		rest, dados.InstanceName = rest[:i], rest[i+1:]
+	}
+	if i := strings.Index(rest, ":"); i >= 0 {
+		rest = rest[:i]
+	}
+	dados.ServiceName = rest
+	if dados.ServiceName == "" {
+		return dados, fmt.Errorf("DB_CONNECTSTRING sem service name: %q", descricao)
+	}
+
+	return dados, nil
+}
+
+// parseDescriptor checks a TNS descriptor and extracts its first address for logging; the
+// descriptor itself, with its failover ADDRESS_LIST, is handed to go-ora
+func parseDescriptor(descriptor string) (Dados, error) {
+	descriptor = strings.Join(strings.Fields(descriptor), " ")
+	if err := checkParentheses(descriptor); err != nil {
+		return Dados{}, fmt.Errorf("descritor TNS inválido: %w", err)
+	}
+
+	dados := Dados{Descriptor: descriptor, Port: defaultOraclePort}
+	match := descriptorHostPattern.FindStringSubmatch(descriptor)
+	if match == nil {
+		return Dados{}, fmt.Errorf("descritor TNS sem HOST")
+	}
+	dados.Host = match[1]
+	if match := descriptorPortPattern.FindStringSubmatch(descriptor); match != nil {
+		dados.Port, _ = strconv.Atoi(match[1])
+	}
+	if match := descriptorServiceNamePattern.FindStringSubmatch(descriptor); match != nil {
+		dados.ServiceName = match[1]
+	}
+	if match := descriptorSIDPattern.FindStringSubmatch(descriptor); match != nil {
+		dados.SID = match[1]
+	}
+
+	if dados.ServiceName == "" && dados.SID == "" {
+		return Dados{}, fmt.Errorf("descritor TNS sem SERVICE_NAME ou SID")
+	}
+	return dados, nil
+}
+
+// checkParentheses reports unbalanced parentheses
+func checkParentheses(value string) error {
+	depth := 0
+	for _, char := range value {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("')' sem '(' correspondente")
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("%d '(' sem ')' correspondente", depth)
+	}
+	return nil
+}
+
+// tnsDirs lists where tnsnames.ora is searched: TNS_ADMIN and the wallet directory
+func tnsDirs(cfg *Conf) []string {
+	var dirs []string
+	for _, dir := range []string{cfg.TNSAdmin, os.Getenv("TNS_ADMIN"), cfg.DBWallet} {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// lookupTNSAlias returns the descriptor of alias from the first tnsnames.ora that defines it
+func lookupTNSAlias(alias string, dirs []string) (string, error) {
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("DB_CONNECTSTRING %q parece um alias TNS, mas TNS_ADMIN não está definido", alias)
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, "tnsnames.ora")
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("erro ao ler %s: %w", path, err)
+		}
+
+		entries, err := parseTNSNames(string(content))
+		if err != nil {
+			return "", fmt.Errorf("erro em %s: %w", path, err)
+		}
+		if descriptor, ok := entries[strings.ToUpper(alias)]; ok {
+			return descriptor, nil
+		}
+	}
+
+	return "", fmt.Errorf("alias TNS %q não encontrado em tnsnames.ora (%s)", alias, strings.Join(dirs, ", "))
+}
+
+// parseTNSNames maps the upper-cased aliases of a tnsnames.ora to their descriptors.
+// An entry may declare several comma separated aliases and span several lines.
+func parseTNSNames(content string) (map[string]string, error) {
+	var cleaned strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		cleaned.WriteString(line)
+		cleaned.WriteString("\n")
+	}
+	text := cleaned.String()
+
+	entries := make(map[string]string)
+	for pos := 0; pos < len(text); {
+		eq := strings.Index(text[pos:], "=")
+		if eq < 0 {
+			if strings.TrimSpace(text[pos:]) != "" {
+				return nil, fmt.Errorf("entrada sem '=': %q", strings.TrimSpace(text[pos:]))
+			}
+			break
+		}
+		names := text[pos : pos+eq]
+		pos += eq + 1
+
+		start := strings.Index(text[pos:], "(")
+		if start < 0 {
+			return nil, fmt.Errorf("entrada %q sem descritor", strings.TrimSpace(names))
+		}
+		pos += start
+
+		depth, end := 0, -1
+		for i := pos; i < len(text) && end < 0; i++ {
+			switch text[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					end = i + 1
+				}
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("descritor de %q sem ')' final", strings.TrimSpace(names))
+		}
+
+		descriptor := strings.Join(strings.Fields(text[pos:end]), " ")
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				entries[strings.ToUpper(name)] = descriptor
+			}
+		}
+		pos = end
+	}
+
+	return entries, nil
+}
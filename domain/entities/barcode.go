@@ -0,0 +1,38 @@
+package entities
+
+// Barcode symbologies recognized by the barcode classifier
+const (
+	BARCODE_EAN8            = "EAN8"
+	BARCODE_EAN13           = "EAN13"
+	BARCODE_UPCA            = "UPCA"
+	BARCODE_GTIN14          = "GTIN14"
+	BARCODE_VARIABLE_WEIGHT = "PESO_VARIAVEL" // EAN-13 with prefix 2 printed by in-store scales
+	BARCODE_INTERNAL        = "INTERNO"       // in-store code (restricted prefix 2 or short PLU)
+	BARCODE_INVALID         = "INVALIDO"
+)
+
+// BarcodeInfo is the result of validating and classifying a barcode
+type BarcodeInfo struct {
+	Code   string `json:"code"`
+	Type   string `json:"type"`
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// TipoCodigoBarras maps the classification to the TIPO_CODIGO_BARRAS value of EMBALAGEM_PRODUTO
+func (b BarcodeInfo) TipoCodigoBarras() string {
+	switch b.Type {
+	case BARCODE_EAN8, BARCODE_EAN13, BARCODE_UPCA, BARCODE_GTIN14:
+		return CB_BARRA_EAN
+	default:
+		return CB_INTERNO
+	}
+}
+
+// BarcodeIssue reports an invalid barcode of an imported item
+type BarcodeIssue struct {
+	CodigoRMS string `json:"codigoRms"`
+	Codigo    string `json:"codigo"`
+	Origem    string `json:"origem"` // codBarras or embalagem
+	Motivo    string `json:"motivo"`
+}
@@ -1,6 +1,9 @@
 package entities
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // IParameter represents a system parameter
 type IParameter struct {
@@ -99,3 +102,42 @@ type DealerNetwork struct {
 type ProductSelect struct {
 	Cod string `json:"cod" db:"COD"`
 }
+
+const (
+	// DEFAULT_DB_QUERY_TIMEOUT_SECONDS bounds each repository operation when DB_QUERY_TIMEOUT is not set
+	DEFAULT_DB_QUERY_TIMEOUT_SECONDS = 30
+
+	// DEFAULT_MESSAGE_MAX_REQUEUES caps how many times a message failing with a transient error
+	// is republished when MESSAGE_MAX_REQUEUES is not set
+	DEFAULT_MESSAGE_MAX_REQUEUES = 3
+
+	// DEFAULT_MESSAGE_RETRY_DELAY_SECONDS is the wait before the first requeue when
+	// MESSAGE_RETRY_DELAY is not set; it doubles on each retry up to
+	// MAX_MESSAGE_RETRY_DELAY_SECONDS
+	DEFAULT_MESSAGE_RETRY_DELAY_SECONDS = 5
+	MAX_MESSAGE_RETRY_DELAY_SECONDS     = 300
+
+	// DEFAULT_CIRCUIT_BREAKER_FAILURES consecutive transient failures open a procedure's circuit
+	// breaker, which stays open DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS before a trial call
+	DEFAULT_CIRCUIT_BREAKER_FAILURES     = 5
+	DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS = 30
+
+	// DEFAULT_RABBITMQ_PUBLISH_CHANNELS caps the pooled publishing channels of a RabbitMQ
+	// connection when RABBITMQ_PUBLISH_CHANNELS is not set
+	DEFAULT_RABBITMQ_PUBLISH_CHANNELS = 8
+)
+
+// Stored procedures called through a circuit breaker, one breaker per procedure
+const (
+	PROC_PROMOCAO_INTEGRA_HERMES = "pkg_integra_promocao.prc_integra_hermes"
+	PROC_PRODUTO_INTEGRA_HERMES  = "pkg_integra_produto.prc_integra_hermes"
+	PROC_MOVER_STAGING_ESTRUTURA = "sp_MoverStagingEstruturaMercadologica"
+	PROC_MOVER_STAGING_PRODUTO   = "sp_MoverStagingProduto"
+	PROC_MOVER_STAGING_EMBALAGEM = "sp_MoverStagingEmbalagem"
+	PROC_MOVER_STAGING_COMBO     = "sp_MoverStagingCombo"
+	PROC_MOVER_STAGING_PROMOCAO  = "sp_MoverStagingPromocao"
+)
+
+// ErrCircuitOpen is returned, wrapped, for calls rejected by an open circuit breaker: the
+// procedure was not called and the work should be retried later
+var ErrCircuitOpen = errors.New("circuit breaker aberto")
@@ -1,86 +1,97 @@
 package entities
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
 
 type PromotionRepository interface {
-	Dopkg_promotion(pIprId int) (*PromotionResult, error)
-	GetIntegrRMSPromocaoIN() ([]Promotion, error)
-	DeletePorObjeto(ipmID int) error
+	Dopkg_promotion(ctx context.Context, pIprId int) (*PromotionResult, error)
+	GetIntegrRMSPromocaoIN(ctx context.Context) ([]Promotion, error)
+	DeletePorObjeto(ctx context.Context, ipmID int) error
+	// WithTx returns a repository that runs every statement inside tx
+	WithTx(tx *sql.Tx) PromotionRepository
+}
+
+// LogPublisher delivers integration log events
+type LogPublisher interface {
+	Publish(event LogEvent) error
 }
 
 // ParameterRepository handles system parameters
 type ParameterRepository interface {
-	ListByCodeParameter(codigo string) (*IParameter, error)
-	Update(param *IParameter) error
-	Delete(idParametro int) error
-	ListById(idParametro int) (*IParameter, error)
-	ListGridPerFilter(filter *IFilterParameter) ([]IParameter, error)
-	Create(param *IParameter) (*IParameter, error)
+	ListByCodeParameter(ctx context.Context, codigo string) (*IParameter, error)
+	Update(ctx context.Context, param *IParameter) error
+	Delete(ctx context.Context, idParametro int) error
+	ListById(ctx context.Context, idParametro int) (*IParameter, error)
+	ListGridPerFilter(ctx context.Context, filter *IFilterParameter) ([]IParameter, error)
+	Create(ctx context.Context, param *IParameter) (*IParameter, error)
 }
 
 // IntegrationRepository handles integration cleanup operations
 type IntegrationRepository interface {
 	// Transaction removal methods
-	RemoveIntegrationCombo(dataCorte time.Time, expurgo ...string) error
-	ClearIntegrationPackagingByCutOffDate(dataCorte time.Time, expurgo ...string) error
-	RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte time.Time, expurgo ...string) error
-	RemoverTransacaoIntegracaoProduto(dataCorte time.Time, expurgo ...string) error
-	RemoverTransacaoIntegracaoPromocao(dataCorte time.Time, expurgo ...string) error
+	RemoveIntegrationCombo(ctx context.Context, dataCorte time.Time, expurgo ...string) error
+	ClearIntegrationPackagingByCutOffDate(ctx context.Context, dataCorte time.Time, expurgo ...string) error
+	RemoverTransacaoIntegracaoEstruturaMercadologica(ctx context.Context, dataCorte time.Time, expurgo ...string) error
+	RemoverTransacaoIntegracaoProduto(ctx context.Context, dataCorte time.Time, expurgo ...string) error
+	RemoverTransacaoIntegracaoPromocao(ctx context.Context, dataCorte time.Time, expurgo ...string) error
 
 	// Data movement methods
-	MoveIntegrationMarketingStructure(dataCorte time.Time) error
-	MoveIntegrationProductStaging(dataCorte time.Time) error
-	MoveIntegrationPackagingStaging(dataCorte time.Time) error
-	MoveIntegrationComboStaging(dataCorte time.Time) error
-	MoveIntegrationPromotionStaging(dataCorte time.Time) error
+	MoveIntegrationMarketingStructure(ctx context.Context, dataCorte time.Time) error
+	MoveIntegrationProductStaging(ctx context.Context, dataCorte time.Time) error
+	MoveIntegrationPackagingStaging(ctx context.Context, dataCorte time.Time) error
+	MoveIntegrationComboStaging(ctx context.Context, dataCorte time.Time) error
+	MoveIntegrationPromotionStaging(ctx context.Context, dataCorte time.Time) error
 
 	// Expiry methods
-	GetIntegrationUpdateComboByDate(dataCorte time.Time) ([]IntegrationCombo, error)
-	DeleteIntegrationCombo(idIntegracaoCombo int) error
-	UpdateExpiredSlaSolicitation() error
+	GetIntegrationUpdateComboByDate(ctx context.Context, dataCorte time.Time) ([]IntegrationCombo, error)
+	DeleteIntegrationCombo(ctx context.Context, idIntegracaoCombo int) error
+	UpdateExpiredSlaSolicitation(ctx context.Context) error
 }
 
 // NetworkRepository handles network operations
 type NetworkRepository interface {
-	GetNetwork() ([]Network, error)
-	ListByAllByIdDealerNew(idRevendedor int) ([]DealerNetwork, error)
-	ReplicateProductNetwork(idRede int) error
-	GetNetworkReplicadosByDealer(idRevendedor int) ([]interface{}, error)
-	GetProductsByReplicateNetworkServiceNew(idRevendedor int) ([]ProductSelect, error)
-	GetProductsByReplicateNetworkReplicate(idProduto int) ([]ProductSelect, error)
-	GetNetworkByDealer(idDealer int) (*Network, error)
-	UpdateNetwork(network *Network) error
-	GetNetworkReplicados() ([]ProductReplicate, error)
-	ReplicateProductNetworkSP(idNetwork int) error
-	RequestReplicateProducts(idNetwork int, userLogin string) (*Success, error)
-	MoveIntegrationMarketingStructure(dataCorte time.Time) error
+	GetNetwork(ctx context.Context) ([]Network, error)
+	ListByAllByIdDealerNew(ctx context.Context, idRevendedor int) ([]DealerNetwork, error)
+	ReplicateProductNetwork(ctx context.Context, idRede int) error
+	GetNetworkReplicadosByDealer(ctx context.Context, idRevendedor int) ([]interface{}, error)
+	GetProductsByReplicateNetworkServiceNew(ctx context.Context, idRevendedor int) ([]ProductSelect, error)
+	GetProductsByReplicateNetworkReplicate(ctx context.Context, idProduto int) ([]ProductSelect, error)
+	GetNetworkByDealer(ctx context.Context, idDealer int) (*Network, error)
+	UpdateNetwork(ctx context.Context, network *Network) error
+	GetNetworkReplicados(ctx context.Context) ([]ProductReplicate, error)
+	ReplicateProductNetworkSP(ctx context.Context, idNetwork int) error
+	RequestReplicateProducts(ctx context.Context, idNetwork int, userLogin string) (*Success, error)
+	MoveIntegrationMarketingStructure(ctx context.Context, dataCorte time.Time) error
 }
 
 // IntegrationMarketingStructureRepository handles marketing structure integration operations
 type IntegrationMarketingStructureRepository interface {
-	GetIntegrationUpdateByDate(date time.Time) ([]IntegrationMarketingStructure, error)
-	GetIntegrations(ip *IntegrationMarketingStructure) ([]IntegrationMarketingStructure, error)
-	GetIMSByDate(date time.Time) ([]IntegrationMarketingStructure, error)
-	RemoveById(id int) error
-	RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte time.Time, fazExpurgo string) error
+	GetIntegrationUpdateByDate(ctx context.Context, date time.Time) ([]IntegrationMarketingStructure, error)
+	GetIntegrations(ctx context.Context, ip *IntegrationMarketingStructure) ([]IntegrationMarketingStructure, error)
+	GetIMSByDate(ctx context.Context, date time.Time) ([]IntegrationMarketingStructure, error)
+	RemoveById(ctx context.Context, id int) error
+	RemoverTransacaoIntegracaoEstruturaMercadologica(ctx context.Context, dataCorte time.Time, fazExpurgo string) error
 }
 
 // IntegrationPackagingRepository handles packaging integration operations
 type IntegrationPackagingRepository interface {
-	GetIntegrationUpdateByDate(date time.Time) ([]IntegrationPackaging, error)
-	GetIntegrationsByCodIbm(codigoIbm string, transactionId string) ([]IntegrationPackaging, error)
-	GetIntegrations(ip *IntegrationPackaging) ([]IntegrationPackaging, error)
-	GetTransactionByRemove(date time.Time) ([]IntegrationPackaging, error)
-	RemoveById(id int) error
-	MoveIntegrationPackagingStaging(dataCorte time.Time) error
-	ClearIntegrationPackagingByDealer(idDealer int) error
-	ClearIntegrationPackagingByCutOffDate(cutOffDate time.Time, doPurge string) error
+	GetIntegrationUpdateByDate(ctx context.Context, date time.Time) ([]IntegrationPackaging, error)
+	GetIntegrationsByCodIbm(ctx context.Context, codigoIbm string, transactionId string) ([]IntegrationPackaging, error)
+	GetIntegrations(ctx context.Context, ip *IntegrationPackaging) ([]IntegrationPackaging, error)
+	GetTransactionByRemove(ctx context.Context, date time.Time) ([]IntegrationPackaging, error)
+	RemoveById(ctx context.Context, id int) error
+	MoveIntegrationPackagingStaging(ctx context.Context, dataCorte time.Time) error
+	ClearIntegrationPackagingByDealer(ctx context.Context, idDealer int) error
+	ClearIntegrationPackagingByCutOffDate(ctx context.Context, cutOffDate time.Time, doPurge string) error
 }
 
 // IntegrationComboRepository handles combo integration operations
 type IntegrationComboRepository interface {
-	GetIntegrationUpdateComboByDate(date time.Time) ([]IntegrationCombo, error)
-	GetIntegrationComboByDate(date time.Time) ([]IntegrationCombo, error)
-	RemoveIntegrationCombo(date time.Time, doPurge string) error
-	MoveIntegrationComboStaging(dataCorte time.Time) error
+	GetIntegrationUpdateComboByDate(ctx context.Context, date time.Time) ([]IntegrationCombo, error)
+	GetIntegrationComboByDate(ctx context.Context, date time.Time) ([]IntegrationCombo, error)
+	RemoveIntegrationCombo(ctx context.Context, date time.Time, doPurge string) error
+	MoveIntegrationComboStaging(ctx context.Context, dataCorte time.Time) error
 }
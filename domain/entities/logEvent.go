@@ -0,0 +1,291 @@
+package entities
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LOG_EVENT_VERSION is the version of the LogEvent wire format
+const LOG_EVENT_VERSION = 1
+
+// Formats in which log events are published
+const (
+	LOG_MESSAGE_FORMAT_LEGACY = "legacy" // tabela/fields/values QueueMessage
+	LOG_MESSAGE_FORMAT_V1     = "v1"     // versioned LogEvent
+)
+
+// LogEventStatus is the outcome of the processing recorded by a LogEvent
+type LogEventStatus string
+
+// Statuses of a LogEvent
+const (
+	LOG_EVENT_STATUS_SUCCESS LogEventStatus = "SUCESSO"
+	LOG_EVENT_STATUS_ERROR   LogEventStatus = "ERRO"
+)
+
+// LegacyCode returns the STATUSPROCESSAMENTO value of the status: 0 success, 1 error
+func (s LogEventStatus) LegacyCode() int {
+	if s == LOG_EVENT_STATUS_SUCCESS {
+		return 0
+	}
+	return 1
+}
+
+// LogEventStatusOf returns the status for a success flag
+func LogEventStatusOf(success bool) LogEventStatus {
+	if success {
+		return LOG_EVENT_STATUS_SUCCESS
+	}
+	return LOG_EVENT_STATUS_ERROR
+}
+
+// LogEvent is an integration log entry, persisted to LOG_INTEGR_RMS by the log consumer.
+// Erro maps to DESCRICAOERRO, which also carries the message of successful runs, and
+// IdExecucao groups the events of one run.
+type LogEvent struct {
+	Versao            int            `json:"versao"`
+	Transacao         string         `json:"transacao"`
+	Tabela            string         `json:"tabela"`
+	DataRecebimento   time.Time      `json:"dataRecebimento"`
+	DataProcessamento time.Time      `json:"dataProcessamento"`
+	Status            LogEventStatus `json:"status"`
+	Payload           string         `json:"payload"`
+	Erro              string         `json:"erro,omitempty"`
+	IdExecucao        string         `json:"idExecucao,omitempty"`
+}
+
+// logEventFields are the LOG_INTEGR_RMS columns of the legacy fields/values format, in order
+var logEventFields = []string{"TRANSACAO", "TABELA", "DATARECEBIMENTO", "DATAPROCESSAMENTO", "STATUSPROCESSAMENTO", "JSON", "DESCRICAOERRO"}
+
+// logEventRequiredFields must be present in every legacy message
+var logEventRequiredFields = []string{"TRANSACAO", "TABELA", "STATUSPROCESSAMENTO"}
+
+// NewLogEvent creates a current-version event processed now. A zero receivedAt is also
+// replaced by the current time.
+func NewLogEvent(transacao, tabela string, receivedAt time.Time, status LogEventStatus, payload, erro, idExecucao string) LogEvent {
+	now := time.Now()
+	if receivedAt.IsZero() {
+		receivedAt = now
+	}
+	return LogEvent{
+		Versao:            LOG_EVENT_VERSION,
+		Transacao:         transacao,
+		Tabela:            tabela,
+		DataRecebimento:   receivedAt,
+		DataProcessamento: now,
+		Status:            status,
+		Payload:           payload,
+		Erro:              erro,
+		IdExecucao:        idExecucao,
+	}
+}
+
+// NewIdExecucao generates the run id that groups the log events of one run
+func NewIdExecucao() string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().Format("20060102T150405.000000000")
+	}
+	return time.Now().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// Validate checks the fields required to persist the event
+func (e LogEvent) Validate() error {
+	if e.Versao < 1 || e.Versao > LOG_EVENT_VERSION {
+		return fmt.Errorf("versão de log %d não suportada", e.Versao)
+	}
+	if e.Transacao == "" {
+		return fmt.Errorf("transacao obrigatória")
+	}
+	if e.Tabela == "" {
+		return fmt.Errorf("tabela obrigatória")
+	}
+	if e.Status != LOG_EVENT_STATUS_SUCCESS && e.Status != LOG_EVENT_STATUS_ERROR {
+		return fmt.Errorf("status %q inválido", e.Status)
+	}
+	return nil
+}
+
+// ToQueueMessage adapts the event to the legacy tabela/fields/values format. The run id has
+// no legacy column and is dropped.
+func (e LogEvent) ToQueueMessage() QueueMessage {
+	return QueueMessage{
+		Tabela: LOG_MESSAGE_TABLE,
+		Fields: append([]string(nil), logEventFields...),
+		Values: []interface{}{
+			e.Transacao,
+			e.Tabela,
+			e.DataRecebimento,
+			e.DataProcessamento,
+			e.Status.LegacyCode(),
+			e.Payload,
+			e.Erro,
+		},
+	}
+}
+
+// ToLogIntegrRMS converts the event to a LOG_INTEGR_RMS row
+func (e LogEvent) ToLogIntegrRMS() LogIntegrRMS {
+	dataRecebimento := e.DataRecebimento
+	dataProcessamento := e.DataProcessamento
+	return LogIntegrRMS{
+		Transacao:           e.Transacao,
+		Tabela:              e.Tabela,
+		DataRecebimento:     &dataRecebimento,
+		DataProcessamento:   &dataProcessamento,
+		StatusProcessamento: e.Status.LegacyCode(),
+		JSON:                e.Payload,
+		DescricaoErro:       e.Erro,
+	}
+}
+
+// EncodeLogEvent serializes the event in the given format; empty means legacy
+func EncodeLogEvent(e LogEvent, format string) ([]byte, error) {
+	if format == LOG_MESSAGE_FORMAT_V1 {
+		return json.Marshal(e)
+	}
+	return json.Marshal(e.ToQueueMessage())
+}
+
+// DecodeLogEvent parses a log message in either the versioned or the legacy format
+func DecodeLogEvent(body []byte) (LogEvent, error) {
+	var header struct {
+		Versao int `json:"versao"`
+	}
+	if err := json.Unmarshal(body, &header); err != nil {
+		return LogEvent{}, fmt.Errorf("mensagem de log não é um JSON válido: %w", err)
+	}
+
+	if header.Versao > 0 {
+		var event LogEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return LogEvent{}, fmt.Errorf("mensagem de log inválida: %w", err)
+		}
+		if err := event.Validate(); err != nil {
+			return LogEvent{}, err
+		}
+		return event, nil
+	}
+
+	var message QueueMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return LogEvent{}, fmt.Errorf("mensagem de log inválida: %w", err)
+	}
+	return LogEventFromQueueMessage(message)
+}
+
+// LogEventFromQueueMessage validates a legacy tabela/fields/values message and converts it.
+// "SYSDATE", empty and unparseable dates become the current time.
+func LogEventFromQueueMessage(message QueueMessage) (LogEvent, error) {
+	if !strings.EqualFold(message.Tabela, LOG_MESSAGE_TABLE) {
+		return LogEvent{}, fmt.Errorf("tabela %q inválida, esperado %s", message.Tabela, LOG_MESSAGE_TABLE)
+	}
+	if len(message.Fields) == 0 {
+		return LogEvent{}, fmt.Errorf("mensagem de log sem campos")
+	}
+	if len(message.Fields) != len(message.Values) {
+		return LogEvent{}, fmt.Errorf("mensagem de log com %d campos e %d valores", len(message.Fields), len(message.Values))
+	}
+
+	now := time.Now()
+	event := LogEvent{
+		Versao:            LOG_EVENT_VERSION,
+		DataRecebimento:   now,
+		DataProcessamento: now,
+	}
+
+	seen := make(map[string]bool, len(message.Fields))
+	for i, field := range message.Fields {
+		if seen[field] {
+			return LogEvent{}, fmt.Errorf("campo %q repetido", field)
+		}
+		seen[field] = true
+
+		value := message.Values[i]
+		switch field {
+		case "TRANSACAO":
+			event.Transacao = logText(value)
+		case "TABELA":
+			event.Tabela = logText(value)
+		case "DATARECEBIMENTO":
+			event.DataRecebimento = logTime(value, now)
+		case "DATAPROCESSAMENTO":
+			event.DataProcessamento = logTime(value, now)
+		case "STATUSPROCESSAMENTO":
+			status, err := logInt(value)
+			if err != nil {
+				return LogEvent{}, fmt.Errorf("STATUSPROCESSAMENTO inválido: %w", err)
+			}
+			event.Status = LogEventStatusOf(status == 0)
+		case "JSON":
+			event.Payload = logText(value)
+		case "DESCRICAOERRO":
+			event.Erro = logText(value)
+		default:
+			return LogEvent{}, fmt.Errorf("campo %q desconhecido", field)
+		}
+	}
+
+	for _, field := range logEventRequiredFields {
+		if !seen[field] {
+			return LogEvent{}, fmt.Errorf("campo obrigatório %s ausente", field)
+		}
+	}
+
+	return event, nil
+}
+
+// logText renders a legacy log value as text
+func logText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// logInt converts the numeric representations used in legacy log messages
+func logInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		return strconv.Atoi(v)
+	default:
+		return 0, fmt.Errorf("tipo %T não suportado", value)
+	}
+}
+
+// logTime converts the date representations used in legacy log messages, returning fallback
+// for "SYSDATE", empty and unparseable values
+func logTime(value interface{}, fallback time.Time) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "02/01/2006 15:04:05"} {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t
+			}
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// LogOutboxEntry is a LogIntegrRMS message waiting in LOG_INTEGR_OUTBOX to be published
+type LogOutboxEntry struct {
+	IdOutbox    int       `json:"id_outbox" db:"ID_OUTBOX"`
+	Mensagem    string    `json:"mensagem" db:"MENSAGEM"`
+	Tentativas  int       `json:"tentativas" db:"TENTATIVAS"`
+	DataCriacao time.Time `json:"data_criacao" db:"DATA_CRIACAO"`
+}
+
+// Constants for the LogIntegrRMS outbox
+const (
+	LOG_OUTBOX_STATUS_PENDING = "P" // waiting to be published
+	LOG_OUTBOX_STATUS_SENT    = "E" // published, purged after the retention period
+	LOG_OUTBOX_STATUS_FAILED  = "F" // gave up after the maximum number of attempts
+
+	DEFAULT_LOG_OUTBOX_INTERVAL_SECONDS = 5
+	DEFAULT_LOG_OUTBOX_BATCH_SIZE       = 100
+	DEFAULT_LOG_OUTBOX_MAX_ATTEMPTS     = 10
+	DEFAULT_LOG_OUTBOX_RETENTION_HOURS  = 72
+)
@@ -0,0 +1,66 @@
+package entities
+
+import "time"
+
+// ProductExportOptions represents the options of a product export run
+type ProductExportOptions struct {
+	// Full ignores the watermark and exports every product
+	Full bool `json:"full"`
+	// PageSize overrides the configured number of products read per page
+	PageSize int `json:"page_size"`
+	// Destino selects where segments are written (arquivo or exchange); empty uses the configured default
+	Destino string `json:"destino"`
+
+	// IdProdutos, CodigosRMS and IdRevendedores restrict the export to the given products,
+	// RMS codes and dealer mixes. Scoped runs ignore the watermark.
+	IdProdutos     []int `json:"id_produtos,omitempty"`
+	CodigosRMS     []int `json:"codigos_rms,omitempty"`
+	IdRevendedores []int `json:"id_revendedores,omitempty"`
+}
+
+// IsScoped reports whether the run is restricted to products, RMS codes or dealers
+func (o ProductExportOptions) IsScoped() bool {
+	return len(o.IdProdutos) > 0 || len(o.CodigosRMS) > 0 || len(o.IdRevendedores) > 0
+}
+
+// ProductExportFilter represents the criteria used to read PRODUTO pages for export
+type ProductExportFilter struct {
+	ChangedSince   *time.Time
+	IdProdutos     []int
+	CodigosRMS     []int
+	IdRevendedores []int
+}
+
+// ProductExportRef is a product selected for export with its last update date
+type ProductExportRef struct {
+	IdProduto       int        `json:"id_produto"`
+	DataAtualizacao *time.Time `json:"data_atualizacao"`
+}
+
+// ProductExportResult represents the result of an export run
+type ProductExportResult struct {
+	Success       bool       `json:"success"`
+	Message       string     `json:"message"`
+	Mode          string     `json:"mode"`
+	Destino       string     `json:"destino"`
+	Arquivo       string     `json:"arquivo,omitempty"`
+	ExportedCount int        `json:"exported_count"`
+	FailedCount   int        `json:"failed_count"`
+	PagesRead     int        `json:"pages_read"`
+	Watermark     *time.Time `json:"watermark,omitempty"`
+}
+
+// Constants for product export
+const (
+	PRODUCT_EXPORT_MODE_FULL        = "full"
+	PRODUCT_EXPORT_MODE_INCREMENTAL = "incremental"
+
+	PRODUCT_EXPORT_DESTINATION_FILE     = "arquivo"  // one NDJSON file per run
+	PRODUCT_EXPORT_DESTINATION_EXCHANGE = "exchange" // one message per product
+
+	PARAM_PRODUTO_EXPORTACAO_ULTIMA_EXECUCAO = "PRODUTO_EXPORTACAO_ULTIMA_EXECUCAO"
+
+	DEFAULT_PRODUCT_EXPORT_DIR       = "exports/produtos"
+	DEFAULT_PRODUCT_EXPORT_EXCHANGE  = "produto.exportacao"
+	DEFAULT_PRODUCT_EXPORT_PAGE_SIZE = 500
+)
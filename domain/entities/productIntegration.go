@@ -67,6 +67,7 @@ type ProductNew struct {
 	Gift                     *int               `json:"gift"`
 	Observacao               string             `json:"observacao"`
 	ReferenciaFabricante     string             `json:"referencia_fabricante"`
+	Industria                string             `json:"industria"`
 }
 
 // ProductPackaging represents product packaging information
@@ -120,6 +121,50 @@ type MarketingStructure struct {
 	DescricaoEstrutura       string `json:"descricao_estrutura" db:"DESCRICAO_ESTRUTURA"`
 }
 
+// MarketingStructureInJson is the inbound marketing structure payload sent by RMS
+type MarketingStructureInJson struct {
+	Estruturas []MarketingStructureIn `json:"estruturas"`
+}
+
+// MarketingStructureIn is one ESTRUTURA_MERCADOLOGICA node of the inbound payload.
+// Department and section are upserted when their ID and name are both informed.
+type MarketingStructureIn struct {
+	Id               int    `json:"id"`
+	IdPai            *int   `json:"idPai"`
+	Nivel            int    `json:"nivel"`
+	Descricao        string `json:"descricao"`
+	IdDepartamento   *int   `json:"idDepartamento"`
+	NomeDepartamento string `json:"nomeDepartamento"`
+	IdSecao          *int   `json:"idSecao"`
+	NomeSecao        string `json:"nomeSecao"`
+}
+
+// MARKETING_STRUCTURE_LEVELS is the depth of a complete marketing structure (level 4 is the subclass)
+const MARKETING_STRUCTURE_LEVELS = 4
+
+// Marketing structure validation issue types
+const (
+	MARKETING_STRUCTURE_ISSUE_ORPHAN = "ORFAO"        // parent ID not found
+	MARKETING_STRUCTURE_ISSUE_CYCLE  = "CICLO"        // node is its own ancestor
+	MARKETING_STRUCTURE_ISSUE_DEPTH  = "PROFUNDIDADE" // deeper than 4 levels or leaf above level 4
+)
+
+// MarketingStructureIssue is a problem found when validating the marketing structure tree
+type MarketingStructureIssue struct {
+	Tipo                     string `json:"tipo"`
+	IdEstruturaMercadologica int    `json:"idEstruturaMercadologica"`
+	Descricao                string `json:"descricao"`
+	Mensagem                 string `json:"mensagem"`
+}
+
+// MarketingStructurePath is the complete level 1-4 path of a level 4 marketing structure
+type MarketingStructurePath struct {
+	Nivel1 MarketingStructure `json:"nivel1"`
+	Nivel2 MarketingStructure `json:"nivel2"`
+	Nivel3 MarketingStructure `json:"nivel3"`
+	Nivel4 MarketingStructure `json:"nivel4"`
+}
+
 // Brand represents brand information
 type Brand struct {
 	IdMarca       *int   `json:"id_marca" db:"ID_MARCA"`
@@ -136,6 +181,20 @@ type Industry struct {
 	StatusIndustria int    `json:"status_industria" db:"STATUS_INDUSTRIA"`
 }
 
+// CatalogDuplicateGroup lists brands or industries whose names normalize to the same key
+type CatalogDuplicateGroup struct {
+	Chave string   `json:"chave"`
+	Ids   []int    `json:"ids"`
+	Nomes []string `json:"nomes"`
+}
+
+// CatalogDuplicateReport lists suspected duplicate brands and industries
+type CatalogDuplicateReport struct {
+	GeradoEm   time.Time               `json:"geradoEm"`
+	Marcas     []CatalogDuplicateGroup `json:"marcas"`
+	Industrias []CatalogDuplicateGroup `json:"industrias"`
+}
+
 // IntegrRmsProductIn represents the RMS product integration input
 type IntegrRmsProductIn struct {
 	IprID           *int       `json:"ipr_id" db:"IPR_ID"`
@@ -157,8 +216,58 @@ type LogIntegrRMS struct {
 
 // LogValidate represents validation log structure
 type LogValidate struct {
+	Message string   `json:"message"`
+	Success bool     `json:"success"`
+	Avisos  []string `json:"avisos,omitempty"`
+
+	// SemAlteracao marks payloads skipped because their content hash did not change
+	SemAlteracao bool `json:"semAlteracao,omitempty"`
+
+	// Adiado marks rows left in INTEGR_RMS_PRODUTO_IN because the procedure's circuit breaker
+	// is open; Err holds the rejection
+	Adiado bool  `json:"adiado,omitempty"`
+	Err    error `json:"-"`
+}
+
+// ProductIntegrationOptions controls a product import run
+type ProductIntegrationOptions struct {
+	// Force integrates every row even when its content hash matches the stored one
+	Force bool `json:"force"`
+	// IdExecucao identifies the run in the log events; generated when empty
+	IdExecucao string `json:"id_execucao,omitempty"`
+}
+
+// ProductContentHash is the normalized payload hash last integrated for a CODIGO_RMS
+type ProductContentHash struct {
+	CodigoRMS       int        `json:"codigo_rms" db:"CODIGO_RMS"`
+	HashConteudo    string     `json:"hash_conteudo" db:"HASH_CONTEUDO"`
+	DataAtualizacao *time.Time `json:"data_atualizacao" db:"DATA_ATUALIZACAO"`
+}
+
+// SchemaViolation is a field of an inbound JSON document that breaks its schema
+type SchemaViolation struct {
+	Path    string `json:"path"`
 	Message string `json:"message"`
-	Success bool   `json:"success"`
+}
+
+// ProductShadowDiscrepancy is a field where the PL/SQL package wrote something
+// different from what the Go pipeline would have written
+type ProductShadowDiscrepancy struct {
+	Tabela   string `json:"tabela"`
+	Chave    string `json:"chave"`
+	Campo    string `json:"campo"`
+	Esperado string `json:"esperado"`
+	Atual    string `json:"atual"`
+}
+
+// ProductShadowReport is one shadow comparison of an INTEGR_RMS_PRODUTO_IN row
+type ProductShadowReport struct {
+	IprID          *int                       `json:"iprId"`
+	CodigoRMS      []int                      `json:"codigoRms,omitempty"`
+	ComparadoEm    time.Time                  `json:"comparadoEm"`
+	SucessoPlsql   bool                       `json:"sucessoPlsql"`
+	Divergencias   []ProductShadowDiscrepancy `json:"divergencias"`
+	ErroComparacao string                     `json:"erroComparacao,omitempty"`
 }
 
 // JsonProductSegment represents product segment JSON structure for integration
@@ -216,13 +325,17 @@ type QueueMessage struct {
 	Values []interface{} `json:"values"`
 }
 
+// PRODUCT_STATUS_VALUES are the status values accepted in ProductSelectIntegration.Status
+var PRODUCT_STATUS_VALUES = []string{CONST_ATIVO_A, CONST_INATIVO_I}
+
 // Constants for product integration
 const (
-	CONST_TRUE    = "true"
-	CONST_FALSE   = "false"
-	CONST_ATIVO   = 1
-	CONST_ATIVO_A = "A"
-	CONST_EMPTY   = ""
+	CONST_TRUE      = "true"
+	CONST_FALSE     = "false"
+	CONST_ATIVO     = 1
+	CONST_ATIVO_A   = "A"
+	CONST_INATIVO_I = "I"
+	CONST_EMPTY     = ""
 
 	CB_BARRA_EAN   = "EAN"
 	CB_BARRA_EAN13 = "EAN13"
@@ -234,4 +347,25 @@ const (
 	NOTABILIDADE = "Não Notável"
 
 	MSG_IMPORT_PRODUCT_NOT_FOUND = "Produto não encontrado"
+	MSG_IMPORT_PRODUCT_NO_CHANGE = "Produto sem alterações desde a última integração"
+
+	// PARAM_PRODUTO_INTEGRACAO_MODO selects how INTEGR_RMS_PRODUTO_IN rows are integrated
+	PARAM_PRODUTO_INTEGRACAO_MODO = "PRODUTO_INTEGRACAO_MODO"
+
+	PRODUCT_INTEGRATION_MODE_PLSQL  = "PLSQL"  // pkg_integra_produto.prc_integra_hermes (default)
+	PRODUCT_INTEGRATION_MODE_GO     = "GO"     // Go upsert of PRODUTO and EMBALAGEM_PRODUTO
+	PRODUCT_INTEGRATION_MODE_SHADOW = "SHADOW" // PL/SQL package plus Go comparison report
+
+	DEFAULT_PRODUCT_SHADOW_REPORT_PATH = "logs/product_shadow_report.ndjson"
+
+	DEFAULT_PRODUCT_CATALOG_CACHE_TTL_SECONDS = 600
+
+	// DEFAULT_LOG_QUEUE receives the LogIntegrRMS audit messages
+	DEFAULT_LOG_QUEUE = "log"
+
+	// LOG_MESSAGE_TABLE is the "tabela" of every LogIntegrRMS queue message
+	LOG_MESSAGE_TABLE = "LogIntegrRMS"
+
+	DEFAULT_LOG_CONSUMER_BATCH_SIZE             = 100
+	DEFAULT_LOG_CONSUMER_FLUSH_INTERVAL_SECONDS = 2
 )
@@ -1,6 +1,9 @@
 package entities
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PromotionNormalization represents the main promotion normalization structure
 type PromotionNormalization struct {
@@ -13,6 +16,9 @@ type PromotionNormalization struct {
 	Enviando             *string    `json:"enviando" db:"ENVIANDO"`
 	Transacao            *string    `json:"transacao" db:"TRANSACAO"`
 	DataInicioEnvio      *time.Time `json:"data_inicio_envio" db:"DATA_INICIO_ENVIO"`
+
+	// VersaoAtualizacao is DATA_ATUALIZACAO as read, with full precision, used for optimistic concurrency
+	VersaoAtualizacao *string `json:"-"`
 }
 
 // PromotionJsonData represents the structure of the JSON field in promotions
@@ -43,6 +49,90 @@ type PromotionNormalizationResult struct {
 	ProcessedCount         int    `json:"processed_count"`
 	UpdatedCount           int    `json:"updated_count"`
 	TotalRemovedDuplicates int    `json:"total_removed_duplicates"`
+	FailedCount            int    `json:"failed_count"`
+	SkippedCount           int    `json:"skipped_count"`
+	SkippedSendingCount    int    `json:"skipped_sending_count"`
+	ConflictCount          int    `json:"conflict_count"`
+	PagesRead              int    `json:"pages_read"`
+
+	Mode      string     `json:"mode"`
+	DryRun    bool       `json:"dry_run"`
+	Watermark *time.Time `json:"watermark,omitempty"`
+
+	Diffs        []PromotionNormalizationDiff `json:"diffs,omitempty"`
+	DiffsOmitted int                          `json:"diffs_omitted,omitempty"`
+
+	RuleResults []PromotionRuleResult `json:"rule_results,omitempty"`
+}
+
+// PromotionNormalizationOptions represents the options of a normalization run
+type PromotionNormalizationOptions struct {
+	// Full ignores the watermark and scans the whole table
+	Full bool `json:"full"`
+	// PageSize overrides the configured number of records read per page
+	PageSize int `json:"page_size"`
+
+	// IdRevendedores, IdPromocoes and CodMix restrict the run to the given
+	// dealers, promotions and codMix values. Scoped runs ignore the watermark.
+	IdRevendedores []int    `json:"id_revendedores,omitempty"`
+	IdPromocoes    []int    `json:"id_promocoes,omitempty"`
+	CodMix         []string `json:"cod_mix,omitempty"`
+
+	// DryRun computes the changes without calling UpdateRecord
+	DryRun bool `json:"dry_run"`
+
+	// IdExecucao identifies the run in the log events; generated when empty
+	IdExecucao string `json:"id_execucao,omitempty"`
+}
+
+// IsScoped reports whether the run is restricted to dealers, promotions or codMix values
+func (o PromotionNormalizationOptions) IsScoped() bool {
+	return len(o.IdRevendedores) > 0 || len(o.IdPromocoes) > 0 || len(o.CodMix) > 0
+}
+
+// PromotionNormalizationFilter represents the criteria used to read INTEGRACAO_PROMOCAO pages
+type PromotionNormalizationFilter struct {
+	ChangedSince   *time.Time
+	IdRevendedores []int
+	IdPromocoes    []int
+}
+
+// PromotionNormalizationDiff represents the before and after JSON of a record changed by normalization
+type PromotionNormalizationDiff struct {
+	IdIntegracaoPromocao int                   `json:"id_integracao_promocao"`
+	IdRevendedor         int                   `json:"id_revendedor"`
+	IdPromocao           int                   `json:"id_promocao"`
+	CodMix               string                `json:"cod_mix"`
+	RemovedDuplicates    int                   `json:"removed_duplicates"`
+	Before               json.RawMessage       `json:"before"`
+	After                json.RawMessage       `json:"after"`
+	RuleResults          []PromotionRuleResult `json:"rule_results,omitempty"`
+}
+
+// PromotionRuleResult represents the change counts reported by a single normalization rule
+type PromotionRuleResult struct {
+	Rule            string                 `json:"rule"`
+	RecordsAffected int                    `json:"records_affected"`
+	ItemsRemoved    int                    `json:"items_removed"`
+	ItemsModified   int                    `json:"items_modified"`
+	ItemsFlagged    int                    `json:"items_flagged"`
+	Findings        []PromotionRuleFinding `json:"findings,omitempty"`
+	FindingsOmitted int                    `json:"findings_omitted,omitempty"`
+}
+
+// HasChanges reports whether the rule modified the promotion JSON
+func (r PromotionRuleResult) HasChanges() bool {
+	return r.ItemsRemoved > 0 || r.ItemsModified > 0
+}
+
+// PromotionRuleFinding represents an item reported by a normalization rule
+type PromotionRuleFinding struct {
+	IdIntegracaoPromocao int     `json:"id_integracao_promocao"`
+	Grupo                string  `json:"grupo"`
+	CodBarra             string  `json:"cod_barra"`
+	Desc                 string  `json:"desc"`
+	Preco                float64 `json:"preco"`
+	Message              string  `json:"message"`
 }
 
 // PromotionNormalizationLog represents log information for normalization
@@ -52,6 +142,8 @@ type PromotionNormalizationLog struct {
 	IdRevendedor         int    `json:"id_revendedor"`
 	CodMix               string `json:"cod_mix"`
 	RemovedDuplicates    int    `json:"removed_duplicates"`
+
+	RuleResults []PromotionRuleResult `json:"rule_results,omitempty"`
 }
 
 // Constants for promotion normalization
@@ -59,6 +151,22 @@ const (
 	MSG_START_IMPORT_PROMOTION_RMS = "Iniciando importação de promoções RMS"
 	MSG_END_IMPORT_PROMOTION_RMS   = "Finalizando importação de promoções RMS"
 
+	PROMOTION_NORMALIZATION_MODE_FULL        = "full"
+	PROMOTION_NORMALIZATION_MODE_INCREMENTAL = "incremental"
+
+	PARAM_PROMOCAO_NORMALIZACAO_ULTIMA_EXECUCAO = "PROMOCAO_NORMALIZACAO_ULTIMA_EXECUCAO"
+
+	DEFAULT_PROMOTION_NORMALIZATION_PAGE_SIZE = 500
+
+	// MAX_PROMOTION_RULE_FINDINGS caps the findings kept per rule in the run totals, so a
+	// full run stays bounded by the page size; the remaining ones are only counted
+	MAX_PROMOTION_RULE_FINDINGS = 100
+
+	// MAX_PROMOTION_NORMALIZATION_DIFFS caps the before/after diffs a dry run keeps, so an
+	// unscoped dry run neither loads the table into memory nor into one reply; the remaining
+	// changed records are only counted
+	MAX_PROMOTION_NORMALIZATION_DIFFS = 100
+
 	FRANQUIA       = "FRANQUIA"
 	LICENCA        = "LICENCA"
 	OXXO_PROPRIA   = "OXXO_PROPRIA"
@@ -12,19 +12,19 @@ import (
 
 // IntegrationComboRepositoryImpl implements the IntegrationComboRepository interface
 type IntegrationComboRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 // NewIntegrationComboRepository creates a new instance of IntegrationComboRepository
 func NewIntegrationComboRepository(db *sql.DB) entities.IntegrationComboRepository {
 	return &IntegrationComboRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
 // GetIntegrationUpdateComboByDate retrieves combo integrations by update date
-func (r *IntegrationComboRepositoryImpl) GetIntegrationUpdateComboByDate(date time.Time) ([]entities.IntegrationCombo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationComboRepositoryImpl) GetIntegrationUpdateComboByDate(ctx context.Context, date time.Time) ([]entities.IntegrationCombo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -65,8 +65,8 @@ func (r *IntegrationComboRepositoryImpl) GetIntegrationUpdateComboByDate(date ti
 }
 
 // GetIntegrationComboByDate retrieves combo integrations being sent by date
-func (r *IntegrationComboRepositoryImpl) GetIntegrationComboByDate(date time.Time) ([]entities.IntegrationCombo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationComboRepositoryImpl) GetIntegrationComboByDate(ctx context.Context, date time.Time) ([]entities.IntegrationCombo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -108,8 +108,8 @@ func (r *IntegrationComboRepositoryImpl) GetIntegrationComboByDate(date time.Tim
 }
 
 // RemoveIntegrationCombo removes combo integrations by cutoff date
-func (r *IntegrationComboRepositoryImpl) RemoveIntegrationCombo(date time.Time, doPurge string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationComboRepositoryImpl) RemoveIntegrationCombo(ctx context.Context, date time.Time, doPurge string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	if doPurge == "" {
@@ -129,13 +129,13 @@ func (r *IntegrationComboRepositoryImpl) RemoveIntegrationCombo(date time.Time,
 }
 
 // MoveIntegrationComboStaging moves combo integrations to staging
-func (r *IntegrationComboRepositoryImpl) MoveIntegrationComboStaging(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationComboRepositoryImpl) MoveIntegrationComboStaging(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingCombo(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_COMBO, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingCombo: %v", err)
 		return fmt.Errorf("erro ao mover combo para staging: %w", err)
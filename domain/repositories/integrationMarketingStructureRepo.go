@@ -12,19 +12,19 @@ import (
 
 // IntegrationMarketingStructureRepositoryImpl implements the IntegrationMarketingStructureRepository interface
 type IntegrationMarketingStructureRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 // NewIntegrationMarketingStructureRepository creates a new instance of IntegrationMarketingStructureRepository
 func NewIntegrationMarketingStructureRepository(db *sql.DB) entities.IntegrationMarketingStructureRepository {
 	return &IntegrationMarketingStructureRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
 // GetIntegrationUpdateByDate retrieves integrations by update date
-func (r *IntegrationMarketingStructureRepositoryImpl) GetIntegrationUpdateByDate(date time.Time) ([]entities.IntegrationMarketingStructure, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationMarketingStructureRepositoryImpl) GetIntegrationUpdateByDate(ctx context.Context, date time.Time) ([]entities.IntegrationMarketingStructure, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -65,8 +65,8 @@ func (r *IntegrationMarketingStructureRepositoryImpl) GetIntegrationUpdateByDate
 }
 
 // GetIntegrations retrieves integrations excluding a specific one
-func (r *IntegrationMarketingStructureRepositoryImpl) GetIntegrations(ip *entities.IntegrationMarketingStructure) ([]entities.IntegrationMarketingStructure, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationMarketingStructureRepositoryImpl) GetIntegrations(ctx context.Context, ip *entities.IntegrationMarketingStructure) ([]entities.IntegrationMarketingStructure, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -110,8 +110,8 @@ func (r *IntegrationMarketingStructureRepositoryImpl) GetIntegrations(ip *entiti
 }
 
 // GetIMSByDate retrieves integrations being sent by date
-func (r *IntegrationMarketingStructureRepositoryImpl) GetIMSByDate(date time.Time) ([]entities.IntegrationMarketingStructure, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationMarketingStructureRepositoryImpl) GetIMSByDate(ctx context.Context, date time.Time) ([]entities.IntegrationMarketingStructure, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -153,8 +153,8 @@ func (r *IntegrationMarketingStructureRepositoryImpl) GetIMSByDate(date time.Tim
 }
 
 // RemoveById removes an integration by ID
-func (r *IntegrationMarketingStructureRepositoryImpl) RemoveById(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationMarketingStructureRepositoryImpl) RemoveById(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `DELETE FROM INTEGRACAO_ESTRUTURA_MERCADOLOGICA WHERE ID_INTEGRACAO_ESTRUTURA_MERCADOLOGICA = :1`
@@ -181,8 +181,8 @@ func (r *IntegrationMarketingStructureRepositoryImpl) RemoveById(id int) error {
 }
 
 // RemoverTransacaoIntegracaoEstruturaMercadologica removes transactions by cutoff date
-func (r *IntegrationMarketingStructureRepositoryImpl) RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte time.Time, fazExpurgo string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationMarketingStructureRepositoryImpl) RemoverTransacaoIntegracaoEstruturaMercadologica(ctx context.Context, dataCorte time.Time, fazExpurgo string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	if fazExpurgo == "" {
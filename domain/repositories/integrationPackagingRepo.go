@@ -12,19 +12,19 @@ import (
 
 // IntegrationPackagingRepositoryImpl implements the IntegrationPackagingRepository interface
 type IntegrationPackagingRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 // NewIntegrationPackagingRepository creates a new instance of IntegrationPackagingRepository
 func NewIntegrationPackagingRepository(db *sql.DB) entities.IntegrationPackagingRepository {
 	return &IntegrationPackagingRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
 // GetIntegrationUpdateByDate retrieves integrations by update date
-func (r *IntegrationPackagingRepositoryImpl) GetIntegrationUpdateByDate(date time.Time) ([]entities.IntegrationPackaging, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) GetIntegrationUpdateByDate(ctx context.Context, date time.Time) ([]entities.IntegrationPackaging, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -65,8 +65,8 @@ func (r *IntegrationPackagingRepositoryImpl) GetIntegrationUpdateByDate(date tim
 }
 
 // GetIntegrationsByCodIbm retrieves integrations by IBM code and transaction ID
-func (r *IntegrationPackagingRepositoryImpl) GetIntegrationsByCodIbm(codigoIbm string, transactionId string) ([]entities.IntegrationPackaging, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) GetIntegrationsByCodIbm(ctx context.Context, codigoIbm string, transactionId string) ([]entities.IntegrationPackaging, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	// First get dealer by IBM code
@@ -124,8 +124,8 @@ func (r *IntegrationPackagingRepositoryImpl) GetIntegrationsByCodIbm(codigoIbm s
 }
 
 // GetIntegrations retrieves integrations excluding a specific one
-func (r *IntegrationPackagingRepositoryImpl) GetIntegrations(ip *entities.IntegrationPackaging) ([]entities.IntegrationPackaging, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) GetIntegrations(ctx context.Context, ip *entities.IntegrationPackaging) ([]entities.IntegrationPackaging, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -169,8 +169,8 @@ func (r *IntegrationPackagingRepositoryImpl) GetIntegrations(ip *entities.Integr
 }
 
 // GetTransactionByRemove retrieves integrations being sent by date
-func (r *IntegrationPackagingRepositoryImpl) GetTransactionByRemove(date time.Time) ([]entities.IntegrationPackaging, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) GetTransactionByRemove(ctx context.Context, date time.Time) ([]entities.IntegrationPackaging, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -212,8 +212,8 @@ func (r *IntegrationPackagingRepositoryImpl) GetTransactionByRemove(date time.Ti
 }
 
 // RemoveById removes an integration by ID
-func (r *IntegrationPackagingRepositoryImpl) RemoveById(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) RemoveById(ctx context.Context, id int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `DELETE FROM INTEGRACAO_EMBALAGEM WHERE ID_INTEGRACAO_EMBALAGEM = :1`
@@ -240,13 +240,13 @@ func (r *IntegrationPackagingRepositoryImpl) RemoveById(id int) error {
 }
 
 // MoveIntegrationPackagingStaging moves integrations to staging
-func (r *IntegrationPackagingRepositoryImpl) MoveIntegrationPackagingStaging(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) MoveIntegrationPackagingStaging(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingEmbalagem(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_EMBALAGEM, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingEmbalagem: %v", err)
 		return fmt.Errorf("erro ao mover embalagem para staging: %w", err)
@@ -257,8 +257,8 @@ func (r *IntegrationPackagingRepositoryImpl) MoveIntegrationPackagingStaging(dat
 }
 
 // ClearIntegrationPackagingByDealer clears integrations by dealer
-func (r *IntegrationPackagingRepositoryImpl) ClearIntegrationPackagingByDealer(idDealer int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) ClearIntegrationPackagingByDealer(ctx context.Context, idDealer int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_LimparIntegracaoEmbalagemRevendedor(:1); END;`
@@ -274,8 +274,8 @@ func (r *IntegrationPackagingRepositoryImpl) ClearIntegrationPackagingByDealer(i
 }
 
 // ClearIntegrationPackagingByCutOffDate clears integrations by cutoff date
-func (r *IntegrationPackagingRepositoryImpl) ClearIntegrationPackagingByCutOffDate(cutOffDate time.Time, doPurge string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationPackagingRepositoryImpl) ClearIntegrationPackagingByCutOffDate(ctx context.Context, cutOffDate time.Time, doPurge string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	if doPurge == "" {
@@ -12,19 +12,19 @@ import (
 
 // IntegrationRepositoryImpl implements the IntegrationRepository interface
 type IntegrationRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 // NewIntegrationRepository creates a new instance of IntegrationRepository
 func NewIntegrationRepository(db *sql.DB) entities.IntegrationRepository {
 	return &IntegrationRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
 // Transaction removal methods
-func (r *IntegrationRepositoryImpl) RemoveIntegrationCombo(dataCorte time.Time, expurgo ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) RemoveIntegrationCombo(ctx context.Context, dataCorte time.Time, expurgo ...string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	// Set default value for expurgo if not provided
@@ -45,8 +45,8 @@ func (r *IntegrationRepositoryImpl) RemoveIntegrationCombo(dataCorte time.Time,
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) ClearIntegrationPackagingByCutOffDate(dataCorte time.Time, expurgo ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) ClearIntegrationPackagingByCutOffDate(ctx context.Context, dataCorte time.Time, expurgo ...string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	var query string
@@ -65,8 +65,8 @@ func (r *IntegrationRepositoryImpl) ClearIntegrationPackagingByCutOffDate(dataCo
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte time.Time, expurgo ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoEstruturaMercadologica(ctx context.Context, dataCorte time.Time, expurgo ...string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	var query string
@@ -85,8 +85,8 @@ func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoEstruturaMercadolo
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoProduto(dataCorte time.Time, expurgo ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoProduto(ctx context.Context, dataCorte time.Time, expurgo ...string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	var query string
@@ -105,8 +105,8 @@ func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoProduto(dataCorte
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoPromocao(dataCorte time.Time, expurgo ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoPromocao(ctx context.Context, dataCorte time.Time, expurgo ...string) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	var query string
@@ -125,9 +125,9 @@ func (r *IntegrationRepositoryImpl) RemoverTransacaoIntegracaoPromocao(dataCorte
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) CheckMarketingStructure() (bool, error) {
+func (r *IntegrationRepositoryImpl) CheckMarketingStructure(ctx context.Context) (bool, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT COUNT(*) FROM INTEGR_ESTRUTURA_MERCADOLOGICA WHERE STATUS_PROCESSAMENTO = 'PENDENTE'`
@@ -142,9 +142,9 @@ func (r *IntegrationRepositoryImpl) CheckMarketingStructure() (bool, error) {
 	return count > 0, nil
 }
 
-func (r *IntegrationRepositoryImpl) CheckProductIntegration() (bool, error) {
+func (r *IntegrationRepositoryImpl) CheckProductIntegration(ctx context.Context) (bool, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT COUNT(*) FROM INTEGR_PRODUTO WHERE STATUS_PROCESSAMENTO = 'PENDENTE'`
@@ -159,9 +159,9 @@ func (r *IntegrationRepositoryImpl) CheckProductIntegration() (bool, error) {
 	return count > 0, nil
 }
 
-func (r *IntegrationRepositoryImpl) CheckPackagingIntegration() (bool, error) {
+func (r *IntegrationRepositoryImpl) CheckPackagingIntegration(ctx context.Context) (bool, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 	query := `SELECT COUNT(*) FROM INTEGR_EMBALAGEM WHERE STATUS_PROCESSAMENTO = 'PENDENTE'`
 
@@ -175,9 +175,9 @@ func (r *IntegrationRepositoryImpl) CheckPackagingIntegration() (bool, error) {
 	return count > 0, nil
 }
 
-func (r *IntegrationRepositoryImpl) CheckComboIntegration() (bool, error) {
+func (r *IntegrationRepositoryImpl) CheckComboIntegration(ctx context.Context) (bool, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 	query := `SELECT COUNT(*) FROM INTEGR_COMBO WHERE STATUS_PROCESSAMENTO = 'PENDENTE'`
 
@@ -191,9 +191,9 @@ func (r *IntegrationRepositoryImpl) CheckComboIntegration() (bool, error) {
 	return count > 0, nil
 }
 
-func (r *IntegrationRepositoryImpl) CheckPromotionIntegration() (bool, error) {
+func (r *IntegrationRepositoryImpl) CheckPromotionIntegration(ctx context.Context) (bool, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 	query := `SELECT COUNT(*) FROM INTEGR_PROMOCAO WHERE STATUS_PROCESSAMENTO = 'PENDENTE'`
 
@@ -208,13 +208,13 @@ func (r *IntegrationRepositoryImpl) CheckPromotionIntegration() (bool, error) {
 }
 
 // Data movement methods
-func (r *IntegrationRepositoryImpl) MoveIntegrationMarketingStructure(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) MoveIntegrationMarketingStructure(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingEstruturaMercadologica(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_ESTRUTURA, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingEstruturaMercadologica: %v", err)
 		return fmt.Errorf("erro ao mover estrutura mercadológica para staging: %w", err)
@@ -224,13 +224,13 @@ func (r *IntegrationRepositoryImpl) MoveIntegrationMarketingStructure(dataCorte
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) MoveIntegrationProductStaging(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) MoveIntegrationProductStaging(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingProduto(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_PRODUTO, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingProduto: %v", err)
 		return fmt.Errorf("erro ao mover produto para staging: %w", err)
@@ -240,13 +240,13 @@ func (r *IntegrationRepositoryImpl) MoveIntegrationProductStaging(dataCorte time
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) MoveIntegrationPackagingStaging(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) MoveIntegrationPackagingStaging(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingEmbalagem(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_EMBALAGEM, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingEmbalagem: %v", err)
 		return fmt.Errorf("erro ao mover embalagem para staging: %w", err)
@@ -256,13 +256,13 @@ func (r *IntegrationRepositoryImpl) MoveIntegrationPackagingStaging(dataCorte ti
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) MoveIntegrationComboStaging(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) MoveIntegrationComboStaging(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingCombo(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_COMBO, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingCombo: %v", err)
 		return fmt.Errorf("erro ao mover combo para staging: %w", err)
@@ -272,13 +272,13 @@ func (r *IntegrationRepositoryImpl) MoveIntegrationComboStaging(dataCorte time.T
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) MoveIntegrationPromotionStaging(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) MoveIntegrationPromotionStaging(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingPromocao(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_PROMOCAO, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingPromocao: %v", err)
 		return fmt.Errorf("erro ao mover promoção para staging: %w", err)
@@ -289,8 +289,8 @@ func (r *IntegrationRepositoryImpl) MoveIntegrationPromotionStaging(dataCorte ti
 }
 
 // Expiry methods
-func (r *IntegrationRepositoryImpl) GetIntegrationUpdateComboByDate(dataCorte time.Time) ([]entities.IntegrationCombo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) GetIntegrationUpdateComboByDate(ctx context.Context, dataCorte time.Time) ([]entities.IntegrationCombo, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -328,8 +328,8 @@ func (r *IntegrationRepositoryImpl) GetIntegrationUpdateComboByDate(dataCorte ti
 	return combos, nil
 }
 
-func (r *IntegrationRepositoryImpl) DeleteIntegrationCombo(idIntegracaoCombo int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) DeleteIntegrationCombo(ctx context.Context, idIntegracaoCombo int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `DELETE FROM INTEGR_COMBO WHERE ID_INTEGRACAO_COMBO = :1`
@@ -343,8 +343,8 @@ func (r *IntegrationRepositoryImpl) DeleteIntegrationCombo(idIntegracaoCombo int
 	return nil
 }
 
-func (r *IntegrationRepositoryImpl) UpdateExpiredSlaSolicitation() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *IntegrationRepositoryImpl) UpdateExpiredSlaSolicitation(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_AtualizarVencimentoSlaSolicitacoes(); END;`
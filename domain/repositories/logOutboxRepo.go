@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// LogOutboxRepository handles the LOG_INTEGR_OUTBOX table. Messages are written in the
+// transaction of the business change and published later by the outbox relay.
+type LogOutboxRepository struct {
+	db sqlExecutor
+}
+
+// NewLogOutboxRepository creates a new instance of LogOutboxRepository
+func NewLogOutboxRepository(db *sql.DB) *LogOutboxRepository {
+	return &LogOutboxRepository{
+		db: newRetryExecutor(db),
+	}
+}
+
+// WithTx returns a copy of the repository that runs every statement inside tx
+func (r *LogOutboxRepository) WithTx(tx *sql.Tx) *LogOutboxRepository {
+	return &LogOutboxRepository{
+		db: tx,
+	}
+}
+
+// Enqueue stores an event, in the versioned format, to be published as soon as possible
+func (r *LogOutboxRepository) Enqueue(ctx context.Context, event entities.LogEvent) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling outbox message: %w", err)
+	}
+
+	query := `INSERT INTO LOG_INTEGR_OUTBOX (MENSAGEM, STATUS, TENTATIVAS, DATA_CRIACAO, PROXIMA_TENTATIVA) 
+			  VALUES (:1, :2, 0, SYSTIMESTAMP, SYSTIMESTAMP)`
+
+	if _, err := r.db.ExecContext(ctx, query, string(body), entities.LOG_OUTBOX_STATUS_PENDING); err != nil {
+		return fmt.Errorf("error inserting outbox message: %w", err)
+	}
+	return nil
+}
+
+// ClaimPending locks up to limit messages due for publishing, oldest first. Rows locked by
+// another relay are skipped, so it must run inside a transaction obtained with WithTx.
+// With SKIP LOCKED Oracle locks rows as they are fetched, so the limit is applied while
+// reading the cursor: a ROWNUM filter would be evaluated before the locked rows are skipped
+// and leave concurrent relays with nothing to claim.
+func (r *LogOutboxRepository) ClaimPending(ctx context.Context, limit int) ([]entities.LogOutboxEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ID_OUTBOX, MENSAGEM, TENTATIVAS, DATA_CRIACAO 
+			  FROM LOG_INTEGR_OUTBOX 
+			  WHERE STATUS = :1 AND PROXIMA_TENTATIVA <= SYSTIMESTAMP 
+			  ORDER BY ID_OUTBOX 
+			  FOR UPDATE SKIP LOCKED`
+
+	rows, err := r.db.QueryContext(ctx, query, entities.LOG_OUTBOX_STATUS_PENDING)
+	if err != nil {
+		return nil, fmt.Errorf("error querying pending outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entities.LogOutboxEntry
+	for len(results) < limit && rows.Next() {
+		var entry entities.LogOutboxEntry
+		if err := rows.Scan(&entry.IdOutbox, &entry.Mensagem, &entry.Tentativas, &entry.DataCriacao); err != nil {
+			return nil, fmt.Errorf("error scanning outbox message: %w", err)
+		}
+		results = append(results, entry)
+	}
+
+	return results, rows.Err()
+}
+
+// MarkSent flags a message as published
+func (r *LogOutboxRepository) MarkSent(ctx context.Context, idOutbox int) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE LOG_INTEGR_OUTBOX SET STATUS = :1, DATA_ENVIO = SYSTIMESTAMP, ULTIMO_ERRO = NULL 
+			  WHERE ID_OUTBOX = :2`
+
+	if _, err := execIdempotent(ctx, r.db, query, entities.LOG_OUTBOX_STATUS_SENT, idOutbox); err != nil {
+		return fmt.Errorf("error marking outbox message %d as sent: %w", idOutbox, err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and when the message should be tried again
+func (r *LogOutboxRepository) MarkRetry(ctx context.Context, idOutbox int, tentativas int, proximaTentativa time.Time, erro string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE LOG_INTEGR_OUTBOX SET TENTATIVAS = :1, PROXIMA_TENTATIVA = :2, ULTIMO_ERRO = :3 
+			  WHERE ID_OUTBOX = :4`
+
+	if _, err := execIdempotent(ctx, r.db, query, tentativas, proximaTentativa, erro, idOutbox); err != nil {
+		return fmt.Errorf("error scheduling retry of outbox message %d: %w", idOutbox, err)
+	}
+	return nil
+}
+
+// MarkFailed stops retrying a message; failed rows are kept for inspection
+func (r *LogOutboxRepository) MarkFailed(ctx context.Context, idOutbox int, tentativas int, erro string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE LOG_INTEGR_OUTBOX SET STATUS = :1, TENTATIVAS = :2, ULTIMO_ERRO = :3 
+			  WHERE ID_OUTBOX = :4`
+
+	if _, err := execIdempotent(ctx, r.db, query, entities.LOG_OUTBOX_STATUS_FAILED, tentativas, erro, idOutbox); err != nil {
+		return fmt.Errorf("error marking outbox message %d as failed: %w", idOutbox, err)
+	}
+	return nil
+}
+
+// PurgeSent deletes messages published before the given time
+func (r *LogOutboxRepository) PurgeSent(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM LOG_INTEGR_OUTBOX WHERE STATUS = :1 AND DATA_ENVIO < :2`
+
+	result, err := execIdempotent(ctx, r.db, query, entities.LOG_OUTBOX_STATUS_SENT, before)
+	if err != nil {
+		return 0, fmt.Errorf("error purging sent outbox messages: %w", err)
+	}
+	return result.RowsAffected()
+}
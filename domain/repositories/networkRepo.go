@@ -12,19 +12,19 @@ import (
 
 // NetworkRepositoryImpl implements the NetworkRepository interface
 type NetworkRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 // NewNetworkRepository creates a new instance of NetworkRepository
 func NewNetworkRepository(db *sql.DB) entities.NetworkRepository {
 	return &NetworkRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
 // GetNetwork retrieves all networks with replication enabled
-func (r *NetworkRepositoryImpl) GetNetwork() ([]entities.Network, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) GetNetwork(ctx context.Context) ([]entities.Network, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -69,8 +69,8 @@ func (r *NetworkRepositoryImpl) GetNetwork() ([]entities.Network, error) {
 }
 
 // ListByAllByIdDealerNew retrieves dealers by ID based on network principal dealer
-func (r *NetworkRepositoryImpl) ListByAllByIdDealerNew(idDealer int) ([]entities.DealerNetwork, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) ListByAllByIdDealerNew(ctx context.Context, idDealer int) ([]entities.DealerNetwork, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -105,8 +105,8 @@ func (r *NetworkRepositoryImpl) ListByAllByIdDealerNew(idDealer int) ([]entities
 }
 
 // ReplicateProductNetwork replicates products for a network
-func (r *NetworkRepositoryImpl) ReplicateProductNetwork(idRede int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) ReplicateProductNetwork(ctx context.Context, idRede int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	// This should be implemented based on your business logic
@@ -126,8 +126,8 @@ func (r *NetworkRepositoryImpl) ReplicateProductNetwork(idRede int) error {
 }
 
 // GetNetworkReplicadosByDealer gets replicated data by dealer (limited to first row)
-func (r *NetworkRepositoryImpl) GetNetworkReplicadosByDealer(idRevendedor int) ([]interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) GetNetworkReplicadosByDealer(ctx context.Context, idRevendedor int) ([]interface{}, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -163,8 +163,8 @@ func (r *NetworkRepositoryImpl) GetNetworkReplicadosByDealer(idRevendedor int) (
 }
 
 // GetProductsByReplicateNetworkServiceNew gets products for replication
-func (r *NetworkRepositoryImpl) GetProductsByReplicateNetworkServiceNew(idRevendedor int) ([]entities.ProductSelect, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) GetProductsByReplicateNetworkServiceNew(ctx context.Context, idRevendedor int) ([]entities.ProductSelect, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT Cod FROM Produtos WHERE IdRevendedor = :1 AND StatusReplicacao = 'PENDENTE'`
@@ -192,8 +192,8 @@ func (r *NetworkRepositoryImpl) GetProductsByReplicateNetworkServiceNew(idRevend
 }
 
 // GetProductsByReplicateNetworkReplicate gets products by replication network (legacy method)
-func (r *NetworkRepositoryImpl) GetProductsByReplicateNetworkReplicate(idProduto int) ([]entities.ProductSelect, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) GetProductsByReplicateNetworkReplicate(ctx context.Context, idProduto int) ([]entities.ProductSelect, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT Cod FROM Produtos WHERE IdProduto = :1 AND StatusReplicacao = 'ATIVO'`
@@ -221,8 +221,8 @@ func (r *NetworkRepositoryImpl) GetProductsByReplicateNetworkReplicate(idProduto
 }
 
 // GetNetworkByDealer retrieves a network by dealer ID
-func (r *NetworkRepositoryImpl) GetNetworkByDealer(idDealer int) (*entities.Network, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) GetNetworkByDealer(ctx context.Context, idDealer int) (*entities.Network, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -258,8 +258,8 @@ func (r *NetworkRepositoryImpl) GetNetworkByDealer(idDealer int) (*entities.Netw
 }
 
 // UpdateNetwork updates a network
-func (r *NetworkRepositoryImpl) UpdateNetwork(network *entities.Network) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) UpdateNetwork(ctx context.Context, network *entities.Network) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -305,8 +305,8 @@ func (r *NetworkRepositoryImpl) UpdateNetwork(network *entities.Network) error {
 }
 
 // GetNetworkReplicados retrieves all replicated products
-func (r *NetworkRepositoryImpl) GetNetworkReplicados() ([]entities.ProductReplicate, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) GetNetworkReplicados(ctx context.Context) ([]entities.ProductReplicate, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `
@@ -336,8 +336,8 @@ func (r *NetworkRepositoryImpl) GetNetworkReplicados() ([]entities.ProductReplic
 }
 
 // ReplicateProductNetworkSP executes the stored procedure to replicate products
-func (r *NetworkRepositoryImpl) ReplicateProductNetworkSP(idNetwork int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) ReplicateProductNetworkSP(ctx context.Context, idNetwork int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_ReplicarProdutoRede(:1); END;`
@@ -353,8 +353,8 @@ func (r *NetworkRepositoryImpl) ReplicateProductNetworkSP(idNetwork int) error {
 }
 
 // RequestReplicateProducts requests product replication for a network
-func (r *NetworkRepositoryImpl) RequestReplicateProducts(idNetwork int, userLogin string) (*entities.Success, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) RequestReplicateProducts(ctx context.Context, idNetwork int, userLogin string) (*entities.Success, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	result := &entities.Success{Message: "", Success: false}
@@ -386,7 +386,7 @@ func (r *NetworkRepositoryImpl) RequestReplicateProducts(idNetwork int, userLogi
 			replicarProduto = '1' 
 		WHERE IdRede = :2`
 
-	execResult, err := r.db.ExecContext(ctx, query, usuarioReplicou, network.IdRede)
+	execResult, err := execIdempotent(ctx, r.db, query, usuarioReplicou, network.IdRede)
 	if err != nil {
 		log.Printf("Erro ao solicitar replicação de produtos: %v", err)
 		result.Message = "Falha de execução: requestReplicateProducts"
@@ -413,13 +413,13 @@ func (r *NetworkRepositoryImpl) RequestReplicateProducts(idNetwork int, userLogi
 }
 
 // MoveIntegrationMarketingStructure moves staging marketing structure data
-func (r *NetworkRepositoryImpl) MoveIntegrationMarketingStructure(dataCorte time.Time) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *NetworkRepositoryImpl) MoveIntegrationMarketingStructure(ctx context.Context, dataCorte time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `BEGIN sp_MoverStagingEstruturaMercadologica(:1); END;`
 
-	_, err := r.db.ExecContext(ctx, query, dataCorte)
+	err := execProcedure(ctx, r.db, entities.PROC_MOVER_STAGING_ESTRUTURA, query, dataCorte)
 	if err != nil {
 		log.Printf("Erro ao executar sp_MoverStagingEstruturaMercadologica: %v", err)
 		return fmt.Errorf("erro ao mover dados de staging da estrutura mercadológica: %w", err)
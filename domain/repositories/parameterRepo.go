@@ -5,26 +5,25 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/thiagohmm/integracaocron/domain/entities"
 )
 
 // ParameterRepositoryImpl implements the ParameterRepository interface
 type ParameterRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 // NewParameterRepository creates a new instance of ParameterRepository
 func NewParameterRepository(db *sql.DB) entities.ParameterRepository {
 	return &ParameterRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
 // ListByCodeParameter retrieves a parameter by its code
-func (r *ParameterRepositoryImpl) ListByCodeParameter(codigo string) (*entities.IParameter, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *ParameterRepositoryImpl) ListByCodeParameter(ctx context.Context, codigo string) (*entities.IParameter, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT ID_PARAMETRO, AMBIENTE, CODIGO, VALOR, DESCRICAO FROM PARAMETROS WHERE CODIGO = :1`
@@ -52,13 +51,13 @@ func (r *ParameterRepositoryImpl) ListByCodeParameter(codigo string) (*entities.
 }
 
 // Update updates a parameter
-func (r *ParameterRepositoryImpl) Update(param *entities.IParameter) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *ParameterRepositoryImpl) Update(ctx context.Context, param *entities.IParameter) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `UPDATE PARAMETROS SET AMBIENTE = :1, CODIGO = :2, VALOR = :3, DESCRICAO = :4 WHERE ID_PARAMETRO = :5`
 
-	result, err := r.db.ExecContext(ctx, query, param.Ambiente, param.Codigo, param.Valor, param.Descricao, param.IdParametro)
+	result, err := execIdempotent(ctx, r.db, query, param.Ambiente, param.Codigo, param.Valor, param.Descricao, param.IdParametro)
 	if err != nil {
 		log.Printf("Erro ao atualizar parâmetro %d: %v", param.IdParametro, err)
 		return fmt.Errorf("erro ao atualizar parâmetro: %w", err)
@@ -80,8 +79,8 @@ func (r *ParameterRepositoryImpl) Update(param *entities.IParameter) error {
 }
 
 // Delete deletes a parameter by ID
-func (r *ParameterRepositoryImpl) Delete(idParametro int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *ParameterRepositoryImpl) Delete(ctx context.Context, idParametro int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `DELETE FROM PARAMETROS WHERE ID_PARAMETRO = :1`
@@ -108,8 +107,8 @@ func (r *ParameterRepositoryImpl) Delete(idParametro int) error {
 }
 
 // ListById retrieves a parameter by its ID
-func (r *ParameterRepositoryImpl) ListById(idParametro int) (*entities.IParameter, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *ParameterRepositoryImpl) ListById(ctx context.Context, idParametro int) (*entities.IParameter, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT ID_PARAMETRO, AMBIENTE, CODIGO, VALOR, DESCRICAO FROM PARAMETROS WHERE ID_PARAMETRO = :1`
@@ -137,8 +136,8 @@ func (r *ParameterRepositoryImpl) ListById(idParametro int) (*entities.IParamete
 }
 
 // ListGridPerFilter retrieves parameters based on filter criteria
-func (r *ParameterRepositoryImpl) ListGridPerFilter(filter *entities.IFilterParameter) ([]entities.IParameter, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *ParameterRepositoryImpl) ListGridPerFilter(ctx context.Context, filter *entities.IFilterParameter) ([]entities.IParameter, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	baseQuery := `SELECT ID_PARAMETRO, AMBIENTE, CODIGO, VALOR, DESCRICAO FROM PARAMETROS WHERE 1=1`
@@ -189,8 +188,8 @@ func (r *ParameterRepositoryImpl) ListGridPerFilter(filter *entities.IFilterPara
 }
 
 // Create creates a new parameter
-func (r *ParameterRepositoryImpl) Create(param *entities.IParameter) (*entities.IParameter, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *ParameterRepositoryImpl) Create(ctx context.Context, param *entities.IParameter) (*entities.IParameter, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `INSERT INTO PARAMETROS (AMBIENTE, CODIGO, VALOR, DESCRICAO) 
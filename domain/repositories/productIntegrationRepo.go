@@ -1,31 +1,81 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/thiagohmm/integracaocron/domain/entities"
 )
 
+// sqlExecutor is implemented by both *sql.DB and *sql.Tx
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // ProductIntegrationRepository handles product integration database operations
 type ProductIntegrationRepository struct {
-	db *sql.DB
+	db           sqlExecutor
+	logPublisher entities.LogPublisher
 }
 
 // NewProductIntegrationRepository creates a new instance of ProductIntegrationRepository
 func NewProductIntegrationRepository(db *sql.DB) *ProductIntegrationRepository {
 	return &ProductIntegrationRepository{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
+// WithTx returns a copy of the repository that runs every statement inside tx
+func (r *ProductIntegrationRepository) WithTx(tx *sql.Tx) *ProductIntegrationRepository {
+	return &ProductIntegrationRepository{
+		db:           tx,
+		logPublisher: r.logPublisher,
+	}
+}
+
+// SetLogPublisher sets the publisher used by SendToQueue
+func (r *ProductIntegrationRepository) SetLogPublisher(publisher entities.LogPublisher) {
+	r.logPublisher = publisher
+}
+
+// Savepoint creates a savepoint in the transaction of a repository obtained with WithTx
+func (r *ProductIntegrationRepository) Savepoint(ctx context.Context, name string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `SAVEPOINT `+name); err != nil {
+		return fmt.Errorf("error creating savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// RollbackToSavepoint undoes the changes made after the savepoint, keeping the transaction open
+func (r *ProductIntegrationRepository) RollbackToSavepoint(ctx context.Context, name string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+name); err != nil {
+		return fmt.Errorf("error rolling back to savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
 // GetIntegrRmsProductsIn retrieves all pending RMS product integrations
-func (r *ProductIntegrationRepository) GetIntegrRmsProductsIn() ([]entities.IntegrRmsProductIn, error) {
+func (r *ProductIntegrationRepository) GetIntegrRmsProductsIn(ctx context.Context) ([]entities.IntegrRmsProductIn, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT IPR_ID, JSON, DATARECEBIMENTO FROM INTEGR_RMS_PRODUTO_IN ORDER BY DATARECEBIMENTO ASC`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("error querying integr_rms_produto_in: %w", err)
 	}
@@ -45,9 +95,12 @@ func (r *ProductIntegrationRepository) GetIntegrRmsProductsIn() ([]entities.Inte
 }
 
 // RemoveProductService removes a processed product integration record
-func (r *ProductIntegrationRepository) RemoveProductService(rms entities.IntegrRmsProductIn) error {
+func (r *ProductIntegrationRepository) RemoveProductService(ctx context.Context, rms entities.IntegrRmsProductIn) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM INTEGR_RMS_PRODUTO_IN WHERE IPR_ID = :1`
-	_, err := r.db.Exec(query, rms.IprID)
+	_, err := r.db.ExecContext(ctx, query, rms.IprID)
 	if err != nil {
 		return fmt.Errorf("error removing product service: %w", err)
 	}
@@ -55,12 +108,15 @@ func (r *ProductIntegrationRepository) RemoveProductService(rms entities.IntegrR
 }
 
 // GetMarketingStructureLevel2 retrieves marketing structure level 2 information
-func (r *ProductIntegrationRepository) GetMarketingStructureLevel2(idLevel2 int) (*entities.MarketingStructure, error) {
+func (r *ProductIntegrationRepository) GetMarketingStructureLevel2(ctx context.Context, idLevel2 int) (*entities.MarketingStructure, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT ID_ESTRUTURA_MERCADOLOGICA, ID_NIVEL_PAI, ID_DEPARTAMENTO, ID_SECAO, DESCRICAO_ESTRUTURA 
 			  FROM ESTRUTURA_MERCADOLOGICA WHERE ID_ESTRUTURA_MERCADOLOGICA = :1`
 
 	var ms entities.MarketingStructure
-	err := r.db.QueryRow(query, idLevel2).Scan(
+	err := r.db.QueryRowContext(ctx, query, idLevel2).Scan(
 		&ms.IdEstruturaMercadologica,
 		&ms.IdNivelPai,
 		&ms.IdDepartamento,
@@ -78,11 +134,14 @@ func (r *ProductIntegrationRepository) GetMarketingStructureLevel2(idLevel2 int)
 }
 
 // GetMarketingStructureLevel4 retrieves marketing structure level 4 information
-func (r *ProductIntegrationRepository) GetMarketingStructureLevel4(idLevel4 int) ([]entities.MarketingStructure, error) {
+func (r *ProductIntegrationRepository) GetMarketingStructureLevel4(ctx context.Context, idLevel4 int) ([]entities.MarketingStructure, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT ID_ESTRUTURA_MERCADOLOGICA, ID_NIVEL_PAI, ID_DEPARTAMENTO, ID_SECAO, DESCRICAO_ESTRUTURA 
 			  FROM ESTRUTURA_MERCADOLOGICA WHERE ID_ESTRUTURA_MERCADOLOGICA = :1`
 
-	rows, err := r.db.Query(query, idLevel4)
+	rows, err := r.db.QueryContext(ctx, query, idLevel4)
 	if err != nil {
 		return nil, fmt.Errorf("error querying marketing structure level 4: %w", err)
 	}
@@ -101,14 +160,175 @@ func (r *ProductIntegrationRepository) GetMarketingStructureLevel4(idLevel4 int)
 	return results, nil
 }
 
+// ListMarketingStructures retrieves every ESTRUTURA_MERCADOLOGICA row
+func (r *ProductIntegrationRepository) ListMarketingStructures(ctx context.Context) ([]entities.MarketingStructure, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ID_ESTRUTURA_MERCADOLOGICA, ID_NIVEL_PAI, ID_DEPARTAMENTO, ID_SECAO, DESCRICAO_ESTRUTURA 
+			  FROM ESTRUTURA_MERCADOLOGICA`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying marketing structures: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entities.MarketingStructure
+	for rows.Next() {
+		var ms entities.MarketingStructure
+		err := rows.Scan(&ms.IdEstruturaMercadologica, &ms.IdNivelPai, &ms.IdDepartamento, &ms.IdSecao, &ms.DescricaoEstrutura)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning marketing structure row: %w", err)
+		}
+		results = append(results, ms)
+	}
+
+	return results, rows.Err()
+}
+
+// UpsertDepartment inserts or renames a DEPARTAMENTO row
+func (r *ProductIntegrationRepository) UpsertDepartment(ctx context.Context, department entities.Department) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if department.IdDepartamento == nil {
+		return fmt.Errorf("error upserting department: ID_DEPARTAMENTO is required")
+	}
+
+	query := `MERGE INTO DEPARTAMENTO d 
+			  USING (SELECT :1 AS ID_DEPARTAMENTO FROM DUAL) src 
+			  ON (d.ID_DEPARTAMENTO = src.ID_DEPARTAMENTO) 
+			  WHEN MATCHED THEN UPDATE SET d.NOME_DEPARTAMENTO = :2 
+			  WHEN NOT MATCHED THEN INSERT (ID_DEPARTAMENTO, NOME_DEPARTAMENTO) VALUES (:3, :4)`
+
+	_, err := execIdempotent(ctx, r.db, query, *department.IdDepartamento, department.NomeDepartamento,
+		*department.IdDepartamento, department.NomeDepartamento)
+	if err != nil {
+		return fmt.Errorf("error upserting department %d: %w", *department.IdDepartamento, err)
+	}
+	return nil
+}
+
+// UpsertSection inserts or renames a SECAO row
+func (r *ProductIntegrationRepository) UpsertSection(ctx context.Context, section entities.Section) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if section.IdSecao == nil {
+		return fmt.Errorf("error upserting section: ID_SECAO is required")
+	}
+
+	query := `MERGE INTO SECAO s 
+			  USING (SELECT :1 AS ID_SECAO FROM DUAL) src 
+			  ON (s.ID_SECAO = src.ID_SECAO) 
+			  WHEN MATCHED THEN UPDATE SET s.NOME_SECAO = :2 
+			  WHEN NOT MATCHED THEN INSERT (ID_SECAO, NOME_SECAO) VALUES (:3, :4)`
+
+	_, err := execIdempotent(ctx, r.db, query, *section.IdSecao, section.NomeSecao, *section.IdSecao, section.NomeSecao)
+	if err != nil {
+		return fmt.Errorf("error upserting section %d: %w", *section.IdSecao, err)
+	}
+	return nil
+}
+
+// UpsertMarketingStructure inserts or updates an ESTRUTURA_MERCADOLOGICA row keyed by its ID.
+// A nil IdDepartamento or IdSecao keeps the value already stored.
+func (r *ProductIntegrationRepository) UpsertMarketingStructure(ctx context.Context, ms entities.MarketingStructure) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if ms.IdEstruturaMercadologica == nil {
+		return fmt.Errorf("error upserting marketing structure: ID_ESTRUTURA_MERCADOLOGICA is required")
+	}
+
+	query := `MERGE INTO ESTRUTURA_MERCADOLOGICA em 
+			  USING (SELECT :1 AS ID_ESTRUTURA_MERCADOLOGICA FROM DUAL) src 
+			  ON (em.ID_ESTRUTURA_MERCADOLOGICA = src.ID_ESTRUTURA_MERCADOLOGICA) 
+			  WHEN MATCHED THEN UPDATE SET em.ID_NIVEL_PAI = :2, em.ID_DEPARTAMENTO = NVL(:3, em.ID_DEPARTAMENTO), 
+			    em.ID_SECAO = NVL(:4, em.ID_SECAO), em.DESCRICAO_ESTRUTURA = :5 
+			  WHEN NOT MATCHED THEN INSERT (ID_ESTRUTURA_MERCADOLOGICA, ID_NIVEL_PAI, ID_DEPARTAMENTO, 
+			    ID_SECAO, DESCRICAO_ESTRUTURA) 
+			    VALUES (:6, :7, :8, :9, :10)`
+
+	id := *ms.IdEstruturaMercadologica
+	_, err := execIdempotent(ctx, r.db, query,
+		id,
+		ms.IdNivelPai, ms.IdDepartamento, ms.IdSecao, ms.DescricaoEstrutura,
+		id, ms.IdNivelPai, ms.IdDepartamento, ms.IdSecao, ms.DescricaoEstrutura,
+	)
+	if err != nil {
+		return fmt.Errorf("error upserting marketing structure %d: %w", id, err)
+	}
+	return nil
+}
+
+// ListBrands retrieves every brand with its industry name
+func (r *ProductIntegrationRepository) ListBrands(ctx context.Context) ([]entities.Brand, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT m.ID_MARCA, m.NOME_MARCA, m.ID_INDUSTRIA, m.STATUS_MARCA, i.NOME_INDUSTRIA 
+			  FROM MARCA m 
+			  JOIN INDUSTRIA i ON m.ID_INDUSTRIA = i.ID_INDUSTRIA 
+			  ORDER BY m.ID_MARCA`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying brands: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entities.Brand
+	for rows.Next() {
+		var brand entities.Brand
+		err := rows.Scan(&brand.IdMarca, &brand.NomeMarca, &brand.IdIndustria, &brand.StatusMarca, &brand.NomeIndustria)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning brand row: %w", err)
+		}
+		results = append(results, brand)
+	}
+
+	return results, rows.Err()
+}
+
+// ListIndustries retrieves every industry
+func (r *ProductIntegrationRepository) ListIndustries(ctx context.Context) ([]entities.Industry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ID_INDUSTRIA, NOME_INDUSTRIA, STATUS_INDUSTRIA FROM INDUSTRIA ORDER BY ID_INDUSTRIA`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying industries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entities.Industry
+	for rows.Next() {
+		var industry entities.Industry
+		err := rows.Scan(&industry.IdIndustria, &industry.NomeIndustria, &industry.StatusIndustria)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning industry row: %w", err)
+		}
+		results = append(results, industry)
+	}
+
+	return results, rows.Err()
+}
+
 // GetBrandByIndustryName retrieves brands by industry and name
-func (r *ProductIntegrationRepository) GetBrandByIndustryName(brandName, industryName string) ([]entities.Brand, error) {
+func (r *ProductIntegrationRepository) GetBrandByIndustryName(ctx context.Context, brandName, industryName string) ([]entities.Brand, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT m.ID_MARCA, m.NOME_MARCA, m.ID_INDUSTRIA, m.STATUS_MARCA, i.NOME_INDUSTRIA 
 			  FROM MARCA m 
 			  JOIN INDUSTRIA i ON m.ID_INDUSTRIA = i.ID_INDUSTRIA 
 			  WHERE UPPER(m.NOME_MARCA) = UPPER(:1) AND UPPER(i.NOME_INDUSTRIA) = UPPER(:2)`
 
-	rows, err := r.db.Query(query, brandName, industryName)
+	rows, err := r.db.QueryContext(ctx, query, brandName, industryName)
 	if err != nil {
 		return nil, fmt.Errorf("error querying brands: %w", err)
 	}
@@ -128,13 +348,16 @@ func (r *ProductIntegrationRepository) GetBrandByIndustryName(brandName, industr
 }
 
 // GetIndustryByNameAndStatus retrieves industry by name and status
-func (r *ProductIntegrationRepository) GetIndustryByNameAndStatus(nomeIndustria string, statusIndustria int) (*entities.Industry, error) {
+func (r *ProductIntegrationRepository) GetIndustryByNameAndStatus(ctx context.Context, nomeIndustria string, statusIndustria int) (*entities.Industry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT ID_INDUSTRIA, NOME_INDUSTRIA, STATUS_INDUSTRIA 
 			  FROM INDUSTRIA 
 			  WHERE UPPER(NOME_INDUSTRIA) = UPPER(:1) AND STATUS_INDUSTRIA = :2`
 
 	var industry entities.Industry
-	err := r.db.QueryRow(query, nomeIndustria, statusIndustria).Scan(
+	err := r.db.QueryRowContext(ctx, query, nomeIndustria, statusIndustria).Scan(
 		&industry.IdIndustria,
 		&industry.NomeIndustria,
 		&industry.StatusIndustria,
@@ -150,12 +373,15 @@ func (r *ProductIntegrationRepository) GetIndustryByNameAndStatus(nomeIndustria
 }
 
 // SaveIndustry saves a new industry
-func (r *ProductIntegrationRepository) SaveIndustry(industry entities.Industry) (*entities.Industry, error) {
+func (r *ProductIntegrationRepository) SaveIndustry(ctx context.Context, industry entities.Industry) (*entities.Industry, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO INDUSTRIA (NOME_INDUSTRIA, STATUS_INDUSTRIA) 
 			  VALUES (:1, :2) RETURNING ID_INDUSTRIA INTO :3`
 
 	var newID int
-	_, err := r.db.Exec(query, industry.NomeIndustria, industry.StatusIndustria, &newID)
+	_, err := r.db.ExecContext(ctx, query, industry.NomeIndustria, industry.StatusIndustria, &newID)
 	if err != nil {
 		return nil, fmt.Errorf("error saving industry: %w", err)
 	}
@@ -165,12 +391,15 @@ func (r *ProductIntegrationRepository) SaveIndustry(industry entities.Industry)
 }
 
 // SaveBrand saves a new brand
-func (r *ProductIntegrationRepository) SaveBrand(brand entities.Brand) (*entities.Brand, error) {
+func (r *ProductIntegrationRepository) SaveBrand(ctx context.Context, brand entities.Brand) (*entities.Brand, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO MARCA (NOME_MARCA, ID_INDUSTRIA, STATUS_MARCA) 
 			  VALUES (:1, :2, :3) RETURNING ID_MARCA INTO :4`
 
 	var newID int
-	_, err := r.db.Exec(query, brand.NomeMarca, brand.IdIndustria, brand.StatusMarca, &newID)
+	_, err := r.db.ExecContext(ctx, query, brand.NomeMarca, brand.IdIndustria, brand.StatusMarca, &newID)
 	if err != nil {
 		return nil, fmt.Errorf("error saving brand: %w", err)
 	}
@@ -180,7 +409,10 @@ func (r *ProductIntegrationRepository) SaveBrand(brand entities.Brand) (*entitie
 }
 
 // GetProductByCodeRMS retrieves product by RMS code
-func (r *ProductIntegrationRepository) GetProductByCodeRMS(codeRms int) (*entities.Product, error) {
+func (r *ProductIntegrationRepository) GetProductByCodeRMS(ctx context.Context, codeRms int) (*entities.Product, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT ID_PRODUTO, ATIVO, CONTEUDO_EMBALAGEM, DESCRICAO_CUPOM, DESCRICAO_PRODUTO, 
 			  DIRETORIO_ANEXO, GIFT, ID_ESTRUTURA_MERCADOLOGICA, ID_MARCA, ID_NIVEL1_ESTR_MERC, 
 			  ID_NIVEL2_ESTR_MERC, ID_NIVEL3_ESTR_MERC, ID_UNIDADE_MEDIDA, MARKUP, NOTABILIDADE, 
@@ -190,7 +422,7 @@ func (r *ProductIntegrationRepository) GetProductByCodeRMS(codeRms int) (*entiti
 			  FROM PRODUTO WHERE CODIGO_RMS = :1`
 
 	var product entities.Product
-	err := r.db.QueryRow(query, codeRms).Scan(
+	err := r.db.QueryRowContext(ctx, query, codeRms).Scan(
 		&product.IdProduto, &product.Ativo, &product.ConteudoEmbalagem, &product.DescricaoCupom,
 		&product.DescricaoProduto, &product.DiretorioAnexo, &product.Gift, &product.IdEstruturaMercadologica,
 		&product.IdMarca, &product.IdNivel1EstrMerc, &product.IdNivel2EstrMerc, &product.IdNivel3EstrMerc,
@@ -209,13 +441,207 @@ func (r *ProductIntegrationRepository) GetProductByCodeRMS(codeRms int) (*entiti
 	return &product, nil
 }
 
+// GetProductByID retrieves product by ID_PRODUTO
+func (r *ProductIntegrationRepository) GetProductByID(ctx context.Context, idProduto int) (*entities.Product, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ID_PRODUTO, ATIVO, CONTEUDO_EMBALAGEM, DESCRICAO_CUPOM, DESCRICAO_PRODUTO, 
+			  DIRETORIO_ANEXO, GIFT, ID_ESTRUTURA_MERCADOLOGICA, ID_MARCA, ID_NIVEL1_ESTR_MERC, 
+			  ID_NIVEL2_ESTR_MERC, ID_NIVEL3_ESTR_MERC, ID_UNIDADE_MEDIDA, MARKUP, NOTABILIDADE, 
+			  OBSERVACAO, PERIODO_SHELF_LIFE, REFERENCIA_FABRICANTE, SHELF_LIFE, TIPO_PRODUTO, 
+			  PRODUCAO, PITSTOP, FORA_MIX, REGIONAL, PRODU_DATA_ULTIMA_ATUALIZACAO, CODIGO_RMS, 
+			  INDUSTRIA, ID_ESTRUTURA_COMPRA
+			  FROM PRODUTO WHERE ID_PRODUTO = :1`
+
+	var product entities.Product
+	err := r.db.QueryRowContext(ctx, query, idProduto).Scan(
+		&product.IdProduto, &product.Ativo, &product.ConteudoEmbalagem, &product.DescricaoCupom,
+		&product.DescricaoProduto, &product.DiretorioAnexo, &product.Gift, &product.IdEstruturaMercadologica,
+		&product.IdMarca, &product.IdNivel1EstrMerc, &product.IdNivel2EstrMerc, &product.IdNivel3EstrMerc,
+		&product.IdUnidadeMedida, &product.MarkUp, &product.Notabilidade, &product.Observacao,
+		&product.PeriodoShelfLife, &product.ReferenciaFabricante, &product.ShelfLife, &product.TipoProduto,
+		&product.Producao, &product.PitStop, &product.ForaMix, &product.Regional,
+		&product.ProduDataUltimaAtualizacao, &product.CodigoRMS, &product.Industria, &product.IdEstruturaCompra,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting product by ID: %w", err)
+	}
+
+	return &product, nil
+}
+
+// InsertProduct inserts a new PRODUTO row and returns its ID
+func (r *ProductIntegrationRepository) InsertProduct(ctx context.Context, product entities.ProductNew) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO PRODUTO (ATIVO, CONTEUDO_EMBALAGEM, DESCRICAO_CUPOM, DESCRICAO_PRODUTO, 
+			  DIRETORIO_ANEXO, GIFT, ID_ESTRUTURA_MERCADOLOGICA, ID_MARCA, ID_NIVEL1_ESTR_MERC, 
+			  ID_NIVEL2_ESTR_MERC, ID_NIVEL3_ESTR_MERC, ID_UNIDADE_MEDIDA, MARKUP, NOTABILIDADE, 
+			  OBSERVACAO, PERIODO_SHELF_LIFE, REFERENCIA_FABRICANTE, SHELF_LIFE, TIPO_PRODUTO, 
+			  PRODUCAO, PITSTOP, FORA_MIX, REGIONAL, PRODU_DATA_ULTIMA_ATUALIZACAO, CODIGO_RMS, INDUSTRIA) 
+			  VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11, :12, :13, :14, :15, :16, :17, :18, 
+			  :19, :20, :21, :22, :23, :24, :25, :26) 
+			  RETURNING ID_PRODUTO INTO :27`
+
+	var newID int
+	_, err := r.db.ExecContext(ctx, query,
+		boolToInt(product.Ativo), product.ConteudoEmbalagem, product.DescricaoCupom, product.DescricaoProduto,
+		product.DiretorioAnexo, product.Gift, product.IdEstruturaMercadologica, product.IdMarca, product.IdNivel1EstrMerc,
+		product.IdNivel2EstrMerc, product.IdNivel3EstrMerc, product.IdUnidadeMedida, product.MarkUp, product.Notabilidade,
+		product.Observacao, product.PeriodoShelfLife, product.ReferenciaFabricante, product.ShelfLife, product.TipoProduto,
+		product.Producao, product.PitStop, product.ForaMix, product.Regional, product.DataUltimaAtualizacao,
+		product.CodigoRMS, product.Industria,
+		&newID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting product: %w", err)
+	}
+
+	return newID, nil
+}
+
+// UpdateProduct updates the RMS maintained columns of an existing PRODUTO row.
+// Columns maintained by the stores (markup, shelf life, mix...) are preserved.
+func (r *ProductIntegrationRepository) UpdateProduct(ctx context.Context, product entities.ProductNew) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if product.IdProduto == nil {
+		return fmt.Errorf("error updating product: ID_PRODUTO is required")
+	}
+
+	query := `UPDATE PRODUTO SET ATIVO = :1, DESCRICAO_CUPOM = :2, DESCRICAO_PRODUTO = :3, 
+			  ID_ESTRUTURA_MERCADOLOGICA = :4, ID_MARCA = :5, ID_NIVEL1_ESTR_MERC = :6, 
+			  ID_NIVEL2_ESTR_MERC = :7, ID_NIVEL3_ESTR_MERC = :8, ID_UNIDADE_MEDIDA = :9, PITSTOP = :10, 
+			  PRODU_DATA_ULTIMA_ATUALIZACAO = :11, CODIGO_RMS = :12, INDUSTRIA = :13 
+			  WHERE ID_PRODUTO = :14`
+
+	result, err := r.db.ExecContext(ctx, query,
+		boolToInt(product.Ativo), product.DescricaoCupom, product.DescricaoProduto,
+		product.IdEstruturaMercadologica, product.IdMarca, product.IdNivel1EstrMerc,
+		product.IdNivel2EstrMerc, product.IdNivel3EstrMerc, product.IdUnidadeMedida, product.PitStop,
+		product.DataUltimaAtualizacao, product.CodigoRMS, product.Industria,
+		*product.IdProduto,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating product: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking product update: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("error updating product: product %d not found", *product.IdProduto)
+	}
+
+	return nil
+}
+
+// UpsertProductPackaging inserts or updates an EMBALAGEM_PRODUTO row keyed by barcode
+func (r *ProductIntegrationRepository) UpsertProductPackaging(ctx context.Context, pkg entities.ProductPackaging) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if pkg.IdProduto == nil {
+		return fmt.Errorf("error upserting product packaging: ID_PRODUTO is required")
+	}
+
+	query := `MERGE INTO EMBALAGEM_PRODUTO ep 
+			  USING (SELECT :1 AS CODIGO_BARRAS FROM DUAL) src 
+			  ON (ep.CODIGO_BARRAS = src.CODIGO_BARRAS) 
+			  WHEN MATCHED THEN UPDATE SET ep.ID_PRODUTO = :2, ep.PRINCIPAL = :3, 
+			    ep.QUANTIDADE_EMBALAGEM = :4, ep.ID_UNIDADE_MEDIDA = :5, ep.TIPO_CODIGO_BARRAS = :6 
+			  WHEN NOT MATCHED THEN INSERT (ID_PRODUTO, CODIGO_BARRAS, PRINCIPAL, QUANTIDADE_EMBALAGEM, 
+			    ID_UNIDADE_MEDIDA, TIPO_CODIGO_BARRAS) 
+			    VALUES (:7, :8, :9, :10, :11, :12)`
+
+	principal := boolToInt(pkg.Principal)
+	_, err := r.db.ExecContext(ctx, query,
+		pkg.CodigoBarras,
+		*pkg.IdProduto, principal, pkg.QuantidadeEmbalagem, pkg.IdUnidadeMedida, pkg.TipoCodigoBarras,
+		*pkg.IdProduto, pkg.CodigoBarras, principal, pkg.QuantidadeEmbalagem, pkg.IdUnidadeMedida, pkg.TipoCodigoBarras,
+	)
+	if err != nil {
+		return fmt.Errorf("error upserting product packaging %s: %w", pkg.CodigoBarras, err)
+	}
+
+	return nil
+}
+
+// boolToInt converts a boolean flag to the 1/0 representation used by the tables
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// GetProductContentHashes retrieves the stored content hash of each CODIGO_RMS; codes
+// never integrated are absent from the map
+func (r *ProductIntegrationRepository) GetProductContentHashes(ctx context.Context, codigosRMS []int) (map[int]string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	hashes := make(map[int]string, len(codigosRMS))
+	if len(codigosRMS) == 0 {
+		return hashes, nil
+	}
+
+	var args []interface{}
+	query := `SELECT CODIGO_RMS, HASH_CONTEUDO FROM PRODUTO_INTEGRACAO_HASH WHERE CODIGO_RMS IN (` + bindList(&args, codigosRMS) + `)`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying product content hashes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item entities.ProductContentHash
+		if err := rows.Scan(&item.CodigoRMS, &item.HashConteudo); err != nil {
+			return nil, fmt.Errorf("error scanning product content hash: %w", err)
+		}
+		hashes[item.CodigoRMS] = item.HashConteudo
+	}
+
+	return hashes, rows.Err()
+}
+
+// SaveProductContentHash stores the content hash last integrated for a CODIGO_RMS
+func (r *ProductIntegrationRepository) SaveProductContentHash(ctx context.Context, codigoRMS int, hash string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `MERGE INTO PRODUTO_INTEGRACAO_HASH h 
+			  USING (SELECT :1 AS CODIGO_RMS FROM DUAL) src 
+			  ON (h.CODIGO_RMS = src.CODIGO_RMS) 
+			  WHEN MATCHED THEN UPDATE SET h.HASH_CONTEUDO = :2, h.DATA_ATUALIZACAO = SYSDATE 
+			  WHEN NOT MATCHED THEN INSERT (CODIGO_RMS, HASH_CONTEUDO, DATA_ATUALIZACAO) 
+			    VALUES (:3, :4, SYSDATE)`
+
+	_, err := execIdempotent(ctx, r.db, query, codigoRMS, hash, codigoRMS, hash)
+	if err != nil {
+		return fmt.Errorf("error saving product content hash for RMS %d: %w", codigoRMS, err)
+	}
+	return nil
+}
+
 // GetProductPackagingByBarCode retrieves product packaging by barcode
-func (r *ProductIntegrationRepository) GetProductPackagingByBarCode(barCode string) (*entities.ProductPackaging, error) {
+func (r *ProductIntegrationRepository) GetProductPackagingByBarCode(ctx context.Context, barCode string) (*entities.ProductPackaging, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT ID_PRODUTO, CODIGO_BARRAS, PRINCIPAL, QUANTIDADE_EMBALAGEM, ID_UNIDADE_MEDIDA, TIPO_CODIGO_BARRAS 
 			  FROM EMBALAGEM_PRODUTO WHERE CODIGO_BARRAS = :1`
 
 	var pkg entities.ProductPackaging
-	err := r.db.QueryRow(query, barCode).Scan(
+	err := r.db.QueryRowContext(ctx, query, barCode).Scan(
 		&pkg.IdProduto, &pkg.CodigoBarras, &pkg.Principal,
 		&pkg.QuantidadeEmbalagem, &pkg.IdUnidadeMedida, &pkg.TipoCodigoBarras,
 	)
@@ -229,13 +655,108 @@ func (r *ProductIntegrationRepository) GetProductPackagingByBarCode(barCode stri
 	return &pkg, nil
 }
 
+// GetProductPackagingsByProductID retrieves every packaging of a product
+func (r *ProductIntegrationRepository) GetProductPackagingsByProductID(ctx context.Context, idProduto int) ([]entities.ProductPackaging, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ID_PRODUTO, CODIGO_BARRAS, PRINCIPAL, QUANTIDADE_EMBALAGEM, ID_UNIDADE_MEDIDA, TIPO_CODIGO_BARRAS 
+			  FROM EMBALAGEM_PRODUTO WHERE ID_PRODUTO = :1`
+
+	rows, err := r.db.QueryContext(ctx, query, idProduto)
+	if err != nil {
+		return nil, fmt.Errorf("error querying product packagings: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entities.ProductPackaging
+	for rows.Next() {
+		var pkg entities.ProductPackaging
+		err := rows.Scan(
+			&pkg.IdProduto, &pkg.CodigoBarras, &pkg.Principal,
+			&pkg.QuantidadeEmbalagem, &pkg.IdUnidadeMedida, &pkg.TipoCodigoBarras,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning product packaging row: %w", err)
+		}
+		results = append(results, pkg)
+	}
+
+	return results, rows.Err()
+}
+
+// GetProductExportPage retrieves the next page of products to export, ordered by ID_PRODUTO.
+// Dealers are matched through their product mix in Produtos.
+func (r *ProductIntegrationRepository) GetProductExportPage(ctx context.Context, afterID int, pageSize int, filter entities.ProductExportFilter) ([]entities.ProductExportRef, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT P.ID_PRODUTO, P.PRODU_DATA_ULTIMA_ATUALIZACAO
+			  FROM PRODUTO P
+			  WHERE P.ID_PRODUTO > :1`
+	args := []interface{}{afterID}
+
+	if filter.ChangedSince != nil {
+		args = append(args, *filter.ChangedSince)
+		query += fmt.Sprintf(` AND P.PRODU_DATA_ULTIMA_ATUALIZACAO > :%d`, len(args))
+	}
+	if len(filter.IdProdutos) > 0 {
+		query += ` AND P.ID_PRODUTO IN (` + bindList(&args, filter.IdProdutos) + `)`
+	}
+	if len(filter.CodigosRMS) > 0 {
+		query += ` AND P.CODIGO_RMS IN (` + bindList(&args, filter.CodigosRMS) + `)`
+	}
+	if len(filter.IdRevendedores) > 0 {
+		query += ` AND P.ID_PRODUTO IN (SELECT IdProduto FROM Produtos WHERE IdRevendedor IN (` + bindList(&args, filter.IdRevendedores) + `))`
+	}
+
+	args = append(args, pageSize)
+	query += fmt.Sprintf(` ORDER BY P.ID_PRODUTO ASC FETCH FIRST :%d ROWS ONLY`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying product export page: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]entities.ProductExportRef, 0, pageSize)
+	for rows.Next() {
+		var ref entities.ProductExportRef
+		if err := rows.Scan(&ref.IdProduto, &ref.DataAtualizacao); err != nil {
+			return nil, fmt.Errorf("error scanning product export row: %w", err)
+		}
+		results = append(results, ref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating product export page: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetDatabaseTime returns the current database timestamp, used as export watermark
+func (r *ProductIntegrationRepository) GetDatabaseTime(ctx context.Context) (time.Time, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var now time.Time
+	if err := r.db.QueryRowContext(ctx, `SELECT SYSTIMESTAMP FROM DUAL`).Scan(&now); err != nil {
+		return time.Time{}, fmt.Errorf("error getting database time: %w", err)
+	}
+	return now, nil
+}
+
 // GetUnitOfMeasurementByID retrieves unit of measurement by ID
-func (r *ProductIntegrationRepository) GetUnitOfMeasurementByID(id int) (*entities.UnitOfMeasurement, error) {
+func (r *ProductIntegrationRepository) GetUnitOfMeasurementByID(ctx context.Context, id int) (*entities.UnitOfMeasurement, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT ID_UNIDADE_MEDIDA, CODIGO_UNIDADE_MEDIDA, DESCRICAO_UNIDADE_MEDIDA 
 			  FROM UNIDADE_MEDIDA WHERE ID_UNIDADE_MEDIDA = :1`
 
 	var unit entities.UnitOfMeasurement
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&unit.IdUnidadeMedida, &unit.CodigoUnidadeMedida, &unit.DescricaoUnidadeMedida,
 	)
 	if err != nil {
@@ -249,7 +770,10 @@ func (r *ProductIntegrationRepository) GetUnitOfMeasurementByID(id int) (*entiti
 }
 
 // GetDepartmentNameByID retrieves department name by ID
-func (r *ProductIntegrationRepository) GetDepartmentNameByID(id *int) (string, error) {
+func (r *ProductIntegrationRepository) GetDepartmentNameByID(ctx context.Context, id *int) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	if id == nil {
 		return "Não encontrado", nil
 	}
@@ -257,7 +781,7 @@ func (r *ProductIntegrationRepository) GetDepartmentNameByID(id *int) (string, e
 	query := `SELECT NOME_DEPARTAMENTO FROM DEPARTAMENTO WHERE ID_DEPARTAMENTO = :1`
 
 	var name string
-	err := r.db.QueryRow(query, *id).Scan(&name)
+	err := r.db.QueryRowContext(ctx, query, *id).Scan(&name)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "Não encontrado", nil
@@ -269,7 +793,10 @@ func (r *ProductIntegrationRepository) GetDepartmentNameByID(id *int) (string, e
 }
 
 // GetSectionNameByID retrieves section name by ID
-func (r *ProductIntegrationRepository) GetSectionNameByID(id *int) (*entities.Section, error) {
+func (r *ProductIntegrationRepository) GetSectionNameByID(ctx context.Context, id *int) (*entities.Section, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	if id == nil {
 		return &entities.Section{NomeSecao: "Não encontrado"}, nil
 	}
@@ -277,7 +804,7 @@ func (r *ProductIntegrationRepository) GetSectionNameByID(id *int) (*entities.Se
 	query := `SELECT ID_SECAO, NOME_SECAO FROM SECAO WHERE ID_SECAO = :1`
 
 	var section entities.Section
-	err := r.db.QueryRow(query, *id).Scan(&section.IdSecao, &section.NomeSecao)
+	err := r.db.QueryRowContext(ctx, query, *id).Scan(&section.IdSecao, &section.NomeSecao)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return &entities.Section{NomeSecao: "Não encontrado"}, nil
@@ -289,14 +816,17 @@ func (r *ProductIntegrationRepository) GetSectionNameByID(id *int) (*entities.Se
 }
 
 // GetBrandDescByID retrieves brand description by ID
-func (r *ProductIntegrationRepository) GetBrandDescByID(id *int) ([]entities.Brand, error) {
+func (r *ProductIntegrationRepository) GetBrandDescByID(ctx context.Context, id *int) ([]entities.Brand, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	if id == nil {
 		return []entities.Brand{}, nil
 	}
 
 	query := `SELECT ID_MARCA, NOME_MARCA, ID_INDUSTRIA, STATUS_MARCA FROM MARCA WHERE ID_MARCA = :1`
 
-	rows, err := r.db.Query(query, *id)
+	rows, err := r.db.QueryContext(ctx, query, *id)
 	if err != nil {
 		return nil, fmt.Errorf("error querying brand description: %w", err)
 	}
@@ -316,10 +846,17 @@ func (r *ProductIntegrationRepository) GetBrandDescByID(id *int) ([]entities.Bra
 }
 
 // DoPackageProductIntegration executes Oracle stored procedure for product integration
-func (r *ProductIntegrationRepository) DoPackageProductIntegration(iprID int) (*entities.LogValidate, error) {
+func (r *ProductIntegrationRepository) DoPackageProductIntegration(ctx context.Context, iprID int) (*entities.LogValidate, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `BEGIN pkg_integra_produto.prc_integra_hermes(:1); END;`
 
-	_, err := r.db.Exec(query, iprID)
+	err := execProcedure(ctx, r.db, entities.PROC_PRODUTO_INTEGRA_HERMES, query, iprID)
+	if errors.Is(err, entities.ErrCircuitOpen) {
+		// The procedure was not called; the row must be integrated later
+		return nil, err
+	}
 	if err != nil {
 		log.Printf("Error executing pkg_integra_produto.prc_integra_hermes: %v", err)
 		return &entities.LogValidate{
@@ -335,12 +872,15 @@ func (r *ProductIntegrationRepository) DoPackageProductIntegration(iprID int) (*
 }
 
 // SaveLogIntegration saves integration log
-func (r *ProductIntegrationRepository) SaveLogIntegration(log entities.LogIntegrRMS) error {
+func (r *ProductIntegrationRepository) SaveLogIntegration(ctx context.Context, log entities.LogIntegrRMS) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT INTO LOG_INTEGR_RMS (TRANSACAO, TABELA, DATARECEBIMENTO, DATAPROCESSAMENTO, 
 			  STATUSPROCESSAMENTO, JSON, DESCRICAOERRO) 
 			  VALUES (:1, :2, :3, :4, :5, :6, :7)`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		log.Transacao,
 		log.Tabela,
 		log.DataRecebimento,
@@ -356,26 +896,94 @@ func (r *ProductIntegrationRepository) SaveLogIntegration(log entities.LogIntegr
 	return nil
 }
 
-// SendToQueue sends a message to queue (placeholder implementation)
-func (r *ProductIntegrationRepository) SendToQueue(message entities.QueueMessage) error {
-	// This would integrate with RabbitMQ or other message queue
-	// For now, we'll just log the message
-	messageJSON, _ := json.Marshal(message)
+// SaveLogIntegrationBatch inserts several integration logs with a single array-bound statement
+func (r *ProductIntegrationRepository) SaveLogIntegrationBatch(ctx context.Context, logs []entities.LogIntegrRMS) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	if len(logs) == 0 {
+		return nil
+	}
+
+	transacoes := make([]string, len(logs))
+	tabelas := make([]string, len(logs))
+	datasRecebimento := make([]time.Time, len(logs))
+	datasProcessamento := make([]time.Time, len(logs))
+	status := make([]int, len(logs))
+	jsons := make([]string, len(logs))
+	descricoes := make([]string, len(logs))
+
+	now := time.Now()
+	for i, l := range logs {
+		transacoes[i] = l.Transacao
+		tabelas[i] = l.Tabela
+		datasRecebimento[i] = now
+		if l.DataRecebimento != nil {
+			datasRecebimento[i] = *l.DataRecebimento
+		}
+		datasProcessamento[i] = now
+		if l.DataProcessamento != nil {
+			datasProcessamento[i] = *l.DataProcessamento
+		}
+		status[i] = l.StatusProcessamento
+		jsons[i] = l.JSON
+		descricoes[i] = l.DescricaoErro
+	}
+
+	query := `INSERT INTO LOG_INTEGR_RMS (TRANSACAO, TABELA, DATARECEBIMENTO, DATAPROCESSAMENTO, 
+			  STATUSPROCESSAMENTO, JSON, DESCRICAOERRO) 
+			  VALUES (:1, :2, :3, :4, :5, :6, :7)`
+
+	_, err := r.db.ExecContext(ctx, query, transacoes, tabelas, datasRecebimento, datasProcessamento, status, jsons, descricoes)
+	if err != nil {
+		return fmt.Errorf("error saving %d log integrations: %w", len(logs), err)
+	}
+
+	return nil
+}
+
+// SendToQueue publishes a log message through the log publisher, only logging it when none is set
+func (r *ProductIntegrationRepository) SendToQueue(event entities.LogEvent) error {
+	if r.logPublisher != nil {
+		return r.logPublisher.Publish(event)
+	}
+	messageJSON, _ := json.Marshal(event)
 	log.Printf("Sending to queue: %s", string(messageJSON))
 	return nil
 }
 
 // RemoverCaracteresEspeciais removes special characters from string
+// Accented letters are folded to their base letter (e.g. "AÇÚCAR" -> "ACUCAR") before
+// the remaining non alphanumeric characters are removed
 func (r *ProductIntegrationRepository) RemoverCaracteresEspeciais(input string) string {
-	// Simple implementation - you may want to make this more sophisticated
-	result := ""
+	var result strings.Builder
 	for _, char := range input {
+		if folded, ok := accentFolding[char]; ok {
+			char = folded
+		}
 		if (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
 			(char >= '0' && char <= '9') || char == ' ' {
-			result += string(char)
+			result.WriteRune(char)
 		}
 	}
-	return result
+	return result.String()
+}
+
+// accentFolding maps the accented Latin-1 letters used in Portuguese names to their base letter
+var accentFolding = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'Á': 'A', 'À': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'É': 'E', 'È': 'E', 'Ê': 'E', 'Ë': 'E',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'Í': 'I', 'Ì': 'I', 'Î': 'I', 'Ï': 'I',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'Ó': 'O', 'Ò': 'O', 'Ô': 'O', 'Õ': 'O', 'Ö': 'O',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'Ú': 'U', 'Ù': 'U', 'Û': 'U', 'Ü': 'U',
+	'ç': 'c', 'Ç': 'C', 'ñ': 'n', 'Ñ': 'N',
+	// Punctuation commonly used as separator becomes a space ("COCA-COLA" == "COCA COLA")
+	'-': ' ', '/': ' ', '.': ' ', '&': ' ', '_': ' ',
 }
 
 // ValidateMarketingStructureLevel2 validates marketing structure level 2
@@ -1,34 +1,64 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/thiagohmm/integracaocron/domain/entities"
 )
 
+// ErrPromotionRecordConflict is returned by UpdateRecord when the row changed since it was read
+var ErrPromotionRecordConflict = errors.New("promotion record changed since it was read")
+
+// promotionVersionExpr renders DATA_ATUALIZACAO with full precision so it can be compared as read
+const promotionVersionExpr = `TO_CHAR(CAST(DATA_ATUALIZACAO AS TIMESTAMP), 'YYYY-MM-DD HH24:MI:SS.FF9')`
+
+// promotionNormalizationColumns lists the INTEGRACAO_PROMOCAO columns read by scanPromotionNormalization
+const promotionNormalizationColumns = `ID_INTEGRACAO_PROMOCAO, ID_REVENDEDOR, ID_PROMOCAO, JSON, 
+			  DATA_ATUALIZACAO, DATA_RECEBIMENTO, ENVIANDO, TRANSACAO, DATA_INICIO_ENVIO, ` + promotionVersionExpr
+
 // PromotionNormalizationRepository handles promotion normalization database operations
 type PromotionNormalizationRepository struct {
-	db *sql.DB
+	db           sqlExecutor
+	logPublisher entities.LogPublisher
 }
 
 // NewPromotionNormalizationRepository creates a new instance of PromotionNormalizationRepository
 func NewPromotionNormalizationRepository(db *sql.DB) *PromotionNormalizationRepository {
 	return &PromotionNormalizationRepository{
-		db: db,
+		db: newRetryExecutor(db),
 	}
 }
 
+// WithTx returns a copy of the repository that runs every statement inside tx
+func (r *PromotionNormalizationRepository) WithTx(tx *sql.Tx) *PromotionNormalizationRepository {
+	return &PromotionNormalizationRepository{
+		db:           tx,
+		logPublisher: r.logPublisher,
+	}
+}
+
+// SetLogPublisher sets the publisher used by SendToQueue
+func (r *PromotionNormalizationRepository) SetLogPublisher(publisher entities.LogPublisher) {
+	r.logPublisher = publisher
+}
+
 // GetAllRecords retrieves all records from the integration promotion table
-func (r *PromotionNormalizationRepository) GetAllRecords() ([]entities.PromotionNormalization, error) {
-	query := `SELECT ID_INTEGRACAO_PROMOCAO, ID_REVENDEDOR, ID_PROMOCAO, JSON, 
-			  DATA_ATUALIZACAO, DATA_RECEBIMENTO, ENVIANDO, TRANSACAO, DATA_INICIO_ENVIO 
+func (r *PromotionNormalizationRepository) GetAllRecords(ctx context.Context) ([]entities.PromotionNormalization, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + promotionNormalizationColumns + ` 
 			  FROM INTEGRACAO_PROMOCAO 
 			  ORDER BY ID_INTEGRACAO_PROMOCAO ASC`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("error querying promotion records: %w", err)
 	}
@@ -36,59 +66,186 @@ func (r *PromotionNormalizationRepository) GetAllRecords() ([]entities.Promotion
 
 	var results []entities.PromotionNormalization
 	for rows.Next() {
-		var record entities.PromotionNormalization
-		var jsonBytes []byte
-
-		err := rows.Scan(
-			&record.IdIntegracaoPromocao,
-			&record.IdRevendedor,
-			&record.IdPromocao,
-			&jsonBytes,
-			&record.DataAtualizacao,
-			&record.DataRecebimento,
-			&record.Enviando,
-			&record.Transacao,
-			&record.DataInicioEnvio,
-		)
+		record, err := scanPromotionNormalization(rows)
 		if err != nil {
-			return nil, fmt.Errorf("error scanning promotion record: %w", err)
+			return nil, err
 		}
+		results = append(results, record)
+	}
+
+	return results, nil
+}
+
+// GetRecordsPage retrieves up to pageSize records with ID_INTEGRACAO_PROMOCAO greater than afterID
+// using keyset pagination, restricted by the given filter
+func (r *PromotionNormalizationRepository) GetRecordsPage(ctx context.Context, afterID int, pageSize int, filter entities.PromotionNormalizationFilter) ([]entities.PromotionNormalization, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-		// Convert JSON bytes to string
-		record.JSON = string(jsonBytes)
+	query := `SELECT ` + promotionNormalizationColumns + ` 
+			  FROM INTEGRACAO_PROMOCAO 
+			  WHERE ID_INTEGRACAO_PROMOCAO > :1`
+	args := []interface{}{afterID}
+
+	if filter.ChangedSince != nil {
+		args = append(args, *filter.ChangedSince)
+		query += fmt.Sprintf(` AND DATA_ATUALIZACAO > :%d`, len(args))
+	}
+	if len(filter.IdRevendedores) > 0 {
+		query += ` AND ID_REVENDEDOR IN (` + bindList(&args, filter.IdRevendedores) + `)`
+	}
+	if len(filter.IdPromocoes) > 0 {
+		query += ` AND ID_PROMOCAO IN (` + bindList(&args, filter.IdPromocoes) + `)`
+	}
+
+	args = append(args, pageSize)
+	query += fmt.Sprintf(` ORDER BY ID_INTEGRACAO_PROMOCAO ASC FETCH FIRST :%d ROWS ONLY`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying promotion records page: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]entities.PromotionNormalization, 0, pageSize)
+	for rows.Next() {
+		record, err := scanPromotionNormalization(rows)
+		if err != nil {
+			return nil, err
+		}
 		results = append(results, record)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating promotion records page: %w", err)
+	}
+
 	return results, nil
 }
 
-// UpdateRecord updates a promotion record with normalized JSON
-func (r *PromotionNormalizationRepository) UpdateRecord(record entities.PromotionNormalization, updatedJSON string) error {
+// GetDatabaseTime returns the current database timestamp, used as normalization watermark
+func (r *PromotionNormalizationRepository) GetDatabaseTime(ctx context.Context) (time.Time, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	var now time.Time
+	if err := r.db.QueryRowContext(ctx, `SELECT SYSTIMESTAMP FROM DUAL`).Scan(&now); err != nil {
+		return time.Time{}, fmt.Errorf("error getting database time: %w", err)
+	}
+	return now, nil
+}
+
+// bindList appends the values to args and returns their positional placeholders
+func bindList(args *[]interface{}, values []int) string {
+	placeholders := make([]string, 0, len(values))
+	for _, value := range values {
+		*args = append(*args, value)
+		placeholders = append(placeholders, fmt.Sprintf(":%d", len(*args)))
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// scanPromotionNormalization scans a single INTEGRACAO_PROMOCAO row
+func scanPromotionNormalization(rows *sql.Rows) (entities.PromotionNormalization, error) {
+	var record entities.PromotionNormalization
+	var jsonBytes []byte
+
+	err := rows.Scan(
+		&record.IdIntegracaoPromocao,
+		&record.IdRevendedor,
+		&record.IdPromocao,
+		&jsonBytes,
+		&record.DataAtualizacao,
+		&record.DataRecebimento,
+		&record.Enviando,
+		&record.Transacao,
+		&record.DataInicioEnvio,
+		&record.VersaoAtualizacao,
+	)
+	if err != nil {
+		return record, fmt.Errorf("error scanning promotion record: %w", err)
+	}
+
+	// Convert JSON bytes to string
+	record.JSON = string(jsonBytes)
+	return record, nil
+}
+
+// GetRecordByID retrieves a single promotion record, returning nil when it no longer exists
+func (r *PromotionNormalizationRepository) GetRecordByID(ctx context.Context, idIntegracaoPromocao int) (*entities.PromotionNormalization, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + promotionNormalizationColumns + ` 
+			  FROM INTEGRACAO_PROMOCAO 
+			  WHERE ID_INTEGRACAO_PROMOCAO = :1`
+
+	rows, err := r.db.QueryContext(ctx, query, idIntegracaoPromocao)
+	if err != nil {
+		return nil, fmt.Errorf("error querying promotion record %d: %w", idIntegracaoPromocao, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error querying promotion record %d: %w", idIntegracaoPromocao, err)
+		}
+		return nil, nil
+	}
+
+	record, err := scanPromotionNormalization(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// UpdateRecord updates a promotion record with normalized JSON. The update only applies while
+// DATA_ATUALIZACAO and ENVIANDO still hold the values that were read; otherwise
+// ErrPromotionRecordConflict is returned.
+func (r *PromotionNormalizationRepository) UpdateRecord(ctx context.Context, record entities.PromotionNormalization, updatedJSON string, updatedAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	// DECODE treats two NULLs as equal, so NULL columns also match what was read
 	query := `UPDATE INTEGRACAO_PROMOCAO 
 			  SET JSON = :1, DATA_ATUALIZACAO = :2 
 			  WHERE ID_INTEGRACAO_PROMOCAO = :3 
 			    AND ID_REVENDEDOR = :4 
-			    AND ID_PROMOCAO = :5`
+			    AND ID_PROMOCAO = :5 
+			    AND DECODE(` + promotionVersionExpr + `, :6, 1, 0) = 1 
+			    AND DECODE(ENVIANDO, :7, 1, 0) = 1`
 
-	_, err := r.db.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		updatedJSON,
-		record.DataAtualizacao,
+		updatedAt,
 		record.IdIntegracaoPromocao,
 		record.IdRevendedor,
 		record.IdPromocao,
+		record.VersaoAtualizacao,
+		record.Enviando,
 	)
 	if err != nil {
 		return fmt.Errorf("error updating promotion record: %w", err)
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error checking promotion record update: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrPromotionRecordConflict
+	}
+
 	return nil
 }
 
-// SendToQueue sends a message to the queue (placeholder implementation)
-func (r *PromotionNormalizationRepository) SendToQueue(message entities.QueueMessage) error {
-	// This would integrate with RabbitMQ or other message queue
-	// For now, we'll just log the message
-	messageJSON, _ := json.Marshal(message)
+// SendToQueue publishes a log message through the log publisher, only logging it when none is set
+func (r *PromotionNormalizationRepository) SendToQueue(event entities.LogEvent) error {
+	if r.logPublisher != nil {
+		return r.logPublisher.Publish(event)
+	}
+	messageJSON, _ := json.Marshal(event)
 	log.Printf("Sending to queue: %s", string(messageJSON))
 	return nil
 }
@@ -104,89 +261,14 @@ func (r *PromotionNormalizationRepository) ParsePromotionJSON(jsonStr string) (*
 	return &promotionData, nil
 }
 
-// NormalizePromotionGroups removes duplicate items from promotion groups based on codBarra
-func (r *PromotionNormalizationRepository) NormalizePromotionGroups(data *entities.PromotionJsonData) (bool, int) {
-	hasChanges := false
-	totalRemovedDuplicates := 0
-
-	if len(data.Grupos) == 0 {
-		log.Println("No grupos array found or grupos is empty")
-		return hasChanges, totalRemovedDuplicates
-	}
-
-	log.Printf("Found grupos array with %d groups", len(data.Grupos))
-
-	for i, grupo := range data.Grupos {
-		log.Printf("Processing group %d: %s", i+1, grupo.Desc)
-
-		if len(grupo.Items) == 0 {
-			log.Printf("Group %d - no items array or empty items", i+1)
-			continue
-		}
-
-		log.Printf("Group %d has %d items", i+1, len(grupo.Items))
-
-		uniqueItems := []entities.PromotionGroupItem{}
-		seen := make(map[string]bool)
-
-		// Remove duplicates from items array based on codBarra
-		for _, item := range grupo.Items {
-			if item.CodBarra != "" && !seen[item.CodBarra] {
-				seen[item.CodBarra] = true
-				uniqueItems = append(uniqueItems, item)
-			}
-		}
-
-		log.Printf("Group %d - Original items: %d, Unique items: %d", i+1, len(grupo.Items), len(uniqueItems))
-
-		// If duplicates were found and removed, update the group
-		if len(uniqueItems) != len(grupo.Items) {
-			log.Printf("Updating group %d - removing %d duplicates", i+1, len(grupo.Items)-len(uniqueItems))
-			data.Grupos[i].Items = uniqueItems
-			// Update qtdeItem to reflect the new count after removing duplicates
-			data.Grupos[i].QtdeItem = len(uniqueItems)
-			hasChanges = true
-			totalRemovedDuplicates += len(grupo.Items) - len(uniqueItems)
-		} else {
-			log.Printf("Group %d - no duplicates found", i+1)
-		}
-	}
+// CreateLogMessage creates a success log event of the run idExecucao
+func (r *PromotionNormalizationRepository) CreateLogMessage(transacao, tabela, descricao string, jsonData interface{}, idExecucao string) entities.LogEvent {
+	return entities.NewLogEvent(transacao, tabela, time.Time{}, entities.LOG_EVENT_STATUS_SUCCESS, r.marshalToJSON(jsonData), descricao, idExecucao)
+}
 
-	return hasChanges, totalRemovedDuplicates
-}
-
-// CreateLogMessage creates a log message for queue
-func (r *PromotionNormalizationRepository) CreateLogMessage(transacao, tabela, descricao string, jsonData interface{}) entities.QueueMessage {
-	return entities.QueueMessage{
-		Tabela: "LogIntegrRMS",
-		Fields: []string{"TRANSACAO", "TABELA", "DATARECEBIMENTO", "DATAPROCESSAMENTO", "STATUSPROCESSAMENTO", "JSON", "DESCRICAOERRO"},
-		Values: []interface{}{
-			transacao,
-			tabela,
-			"SYSDATE", // Oracle function for current date
-			"SYSDATE", // Oracle function for current date
-			1,         // Status: 1 for success, 0 for error
-			r.marshalToJSON(jsonData),
-			descricao,
-		},
-	}
-}
-
-// CreateErrorLogMessage creates an error log message for queue
-func (r *PromotionNormalizationRepository) CreateErrorLogMessage(transacao, tabela, descricao string, jsonData interface{}) entities.QueueMessage {
-	return entities.QueueMessage{
-		Tabela: "LogIntegrRMS",
-		Fields: []string{"TRANSACAO", "TABELA", "DATARECEBIMENTO", "DATAPROCESSAMENTO", "STATUSPROCESSAMENTO", "JSON", "DESCRICAOERRO"},
-		Values: []interface{}{
-			transacao,
-			tabela,
-			"SYSDATE", // Oracle function for current date
-			"SYSDATE", // Oracle function for current date
-			0,         // Status: 0 for error
-			r.marshalToJSON(jsonData),
-			descricao,
-		},
-	}
+// CreateErrorLogMessage creates an error log event of the run idExecucao
+func (r *PromotionNormalizationRepository) CreateErrorLogMessage(transacao, tabela, descricao string, jsonData interface{}, idExecucao string) entities.LogEvent {
+	return entities.NewLogEvent(transacao, tabela, time.Time{}, entities.LOG_EVENT_STATUS_ERROR, r.marshalToJSON(jsonData), descricao, idExecucao)
 }
 
 // marshalToJSON safely marshals data to JSON string
@@ -3,34 +3,45 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/thiagohmm/integracaocron/domain/entities"
 )
 
 type PromotionRepositoryImpl struct {
-	db *sql.DB
+	db sqlExecutor
 }
 
 func NewPromotionRepository(db *sql.DB) entities.PromotionRepository {
 	return &PromotionRepositoryImpl{
-		db: db,
+		db: newRetryExecutor(db),
+	}
+}
+
+// WithTx returns a copy of the repository that runs every statement inside tx
+func (r *PromotionRepositoryImpl) WithTx(tx *sql.Tx) entities.PromotionRepository {
+	return &PromotionRepositoryImpl{
+		db: tx,
 	}
 }
 
 // Dopkg_promotion executes the Oracle stored procedure pkg_integra_promocao.prc_integra_hermes
-func (r *PromotionRepositoryImpl) Dopkg_promotion(pIprId int) (*entities.PromotionResult, error) {
+func (r *PromotionRepositoryImpl) Dopkg_promotion(ctx context.Context, pIprId int) (*entities.PromotionResult, error) {
 	// Create context with timeout for the database operation
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	// Prepare the PL/SQL block to call the stored procedure
 	query := "BEGIN pkg_integra_promocao.prc_integra_hermes(:parametro1); END;"
 
 	// Execute the stored procedure
-	_, err := r.db.ExecContext(ctx, query, pIprId)
+	err := execProcedure(ctx, r.db, entities.PROC_PROMOCAO_INTEGRA_HERMES, query, pIprId)
+	if errors.Is(err, entities.ErrCircuitOpen) {
+		// The procedure was not called; the promotion must be processed later
+		return nil, err
+	}
 	if err != nil {
 		log.Printf("Erro ao executar pkg_integra_promocao.prc_integra_hermes: %v", err)
 		return &entities.PromotionResult{
@@ -47,8 +58,8 @@ func (r *PromotionRepositoryImpl) Dopkg_promotion(pIprId int) (*entities.Promoti
 }
 
 // GetIntegrRMSPromocaoIN retrieves promotion data for integration
-func (r *PromotionRepositoryImpl) GetIntegrRMSPromocaoIN() ([]entities.Promotion, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *PromotionRepositoryImpl) GetIntegrRMSPromocaoIN(ctx context.Context) ([]entities.Promotion, error) {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := `SELECT IPMD_ID, JSON_DATA, DATARECEBIMENTO 
@@ -90,8 +101,8 @@ func (r *PromotionRepositoryImpl) GetIntegrRMSPromocaoIN() ([]entities.Promotion
 }
 
 // DeletePorObjeto deletes a promotion record by IPMD_ID
-func (r *PromotionRepositoryImpl) DeletePorObjeto(ipmID int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (r *PromotionRepositoryImpl) DeletePorObjeto(ctx context.Context, ipmID int) error {
+	ctx, cancel := withQueryTimeout(ctx)
 	defer cancel()
 
 	query := "DELETE FROM INTEGR_RMS_PROMOCAO_IN WHERE IPMD_ID = :1"
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// queryTimeout bounds every repository operation, on top of the deadline of the caller's context
+var queryTimeout atomic.Int64
+
+func init() {
+	queryTimeout.Store(int64(entities.DEFAULT_DB_QUERY_TIMEOUT_SECONDS * time.Second))
+}
+
+// SetQueryTimeout sets the timeout of each repository operation (DB_QUERY_TIMEOUT).
+// Non-positive values keep the current timeout.
+func SetQueryTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		queryTimeout.Store(int64(timeout))
+	}
+}
+
+// QueryTimeout returns the timeout of each repository operation
+func QueryTimeout() time.Duration {
+	return time.Duration(queryTimeout.Load())
+}
+
+// withQueryTimeout derives the context of a single operation from the caller's context,
+// so shutdown, job abort and message deadlines cancel the statement
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithTimeout(ctx, QueryTimeout())
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
+)
+
+// retryExecutor retries statements run directly on the pool when they fail with a retryable
+// Oracle error (deadlock, resource busy, unavailable listener). After a lost connection only
+// queries and statements run through execIdempotent are retried, since the statement may
+// have committed. Statements inside a transaction are not retried here: repositories bound
+// with WithTx use the *sql.Tx and the whole unit of work is retried by requeueing the message.
+type retryExecutor struct {
+	db *sql.DB
+}
+
+func newRetryExecutor(db *sql.DB) sqlExecutor {
+	return &retryExecutor{db: db}
+}
+
+func (e *retryExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return e.exec(ctx, database.Retry, query, args...)
+}
+
+func (e *retryExecutor) exec(ctx context.Context, retry func(context.Context, string, func() error) error, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := retry(ctx, "Exec", func() error {
+		var err error
+		result, err = e.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (e *retryExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := database.RetryIdempotent(ctx, "Query", func() error {
+		var err error
+		rows, err = e.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext is not retried: its error only surfaces on Scan. database/sql already
+// retries it on a broken pooled connection.
+func (e *retryExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return e.db.QueryRowContext(ctx, query, args...)
+}
+
+// execIdempotent runs a statement that has the same effect when run twice (MERGE, UPDATE to
+// fixed values by key), so on the pool it is retried after a lost connection as well
+func execIdempotent(ctx context.Context, db sqlExecutor, query string, args ...interface{}) (sql.Result, error) {
+	if e, ok := db.(*retryExecutor); ok {
+		return e.exec(ctx, database.RetryIdempotent, query, args...)
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+// execProcedure runs a stored procedure call through the circuit breaker of the procedure,
+// so a degraded database fails fast instead of every worker waiting for the query timeout.
+// Procedures are never retried after a lost connection: they may have committed.
+func execProcedure(ctx context.Context, db sqlExecutor, procedure, query string, args ...interface{}) error {
+	return database.Circuit(procedure).Execute(func() error {
+		_, err := db.ExecContext(ctx, query, args...)
+		return err
+	})
+}
@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// maxInternalBarcodeLength is the longest code accepted as a PLU for weighable products
+const maxInternalBarcodeLength = 7
+
+// ClassifyBarcode validates the GS1 check digit of EAN-8, UPC-A, EAN-13 and GTIN-14
+// codes and classifies them. EAN-13 codes with prefix 2 (restricted circulation) are
+// in-store codes: for weighable products they are variable-weight labels, otherwise
+// internal codes. Weighable products also accept short numeric PLU codes.
+func ClassifyBarcode(code string, pesavel bool) entities.BarcodeInfo {
+	info := entities.BarcodeInfo{Code: strings.TrimSpace(code), Type: entities.BARCODE_INVALID}
+
+	if info.Code == "" {
+		info.Reason = "código de barras vazio"
+		return info
+	}
+	if !isDigits(info.Code) {
+		info.Reason = "código de barras deve conter apenas dígitos"
+		return info
+	}
+
+	if pesavel && len(info.Code) <= maxInternalBarcodeLength {
+		info.Type = entities.BARCODE_INTERNAL
+		info.Valid = true
+		return info
+	}
+
+	switch len(info.Code) {
+	case 8:
+		info.Type = entities.BARCODE_EAN8
+	case 12:
+		info.Type = entities.BARCODE_UPCA
+	case 13:
+		info.Type = entities.BARCODE_EAN13
+		if info.Code[0] == '2' {
+			info.Type = entities.BARCODE_INTERNAL
+			if pesavel {
+				info.Type = entities.BARCODE_VARIABLE_WEIGHT
+			}
+		}
+	case 14:
+		info.Type = entities.BARCODE_GTIN14
+	default:
+		info.Reason = fmt.Sprintf("tamanho %d inválido (esperado 8, 12, 13 ou 14 dígitos)", len(info.Code))
+		return info
+	}
+
+	expected := gs1CheckDigit(info.Code[:len(info.Code)-1])
+	if actual := info.Code[len(info.Code)-1]; actual != expected {
+		info.Reason = fmt.Sprintf("dígito verificador %c inválido (esperado %c)", actual, expected)
+		info.Type = entities.BARCODE_INVALID
+		return info
+	}
+
+	info.Valid = true
+	return info
+}
+
+// gs1CheckDigit computes the GS1 mod-10 check digit of the given digits
+func gs1CheckDigit(digits string) byte {
+	sum := 0
+	// Weights alternate 3,1 starting from the rightmost digit
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package usecases
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -34,11 +35,11 @@ func NewIntegrationJobUseCase(
 }
 
 // ProductNetworkMain is the Go equivalent of the main TypeScript function
-func (uc *IntegrationJobUseCase) ProductNetworkMain(dataCorte time.Time) error {
+func (uc *IntegrationJobUseCase) ProductNetworkMain(ctx context.Context, dataCorte time.Time) error {
 	log.Println("Job Integração - Início")
 
 	// Begin transaction
-	tx, err := uc.db.Begin()
+	tx, err := uc.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("erro ao iniciar transação: %w", err)
 	}
@@ -50,22 +51,22 @@ func (uc *IntegrationJobUseCase) ProductNetworkMain(dataCorte time.Time) error {
 	}()
 
 	// Execute all integration jobs
-	if err := uc.IntegrationJob(); err != nil {
+	if err := uc.IntegrationJob(ctx); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("erro no integration job: %w", err)
 	}
 
-	if err := uc.ReplicateNetworkProductsJob(); err != nil {
+	if err := uc.ReplicateNetworkProductsJob(ctx); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("erro no replicate network products job: %w", err)
 	}
 
-	if err := uc.MoveDataJob(dataCorte); err != nil {
+	if err := uc.MoveDataJob(ctx, dataCorte); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("erro no move data job: %w", err)
 	}
 
-	if err := uc.UpdateExpirationSlaRequestsJob(); err != nil {
+	if err := uc.UpdateExpirationSlaRequestsJob(ctx); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("erro no update expiration SLA requests job: %w", err)
 	}
@@ -86,14 +87,14 @@ func (uc *IntegrationJobUseCase) FormatDateForOracle(date time.Time) string {
 }
 
 // IntegrationJob handles the main integration cleanup and expiry operations
-func (uc *IntegrationJobUseCase) IntegrationJob() error {
+func (uc *IntegrationJobUseCase) IntegrationJob(ctx context.Context) error {
 	log.Println("Remover Transação - Início")
 
 	dataCorte := time.Now()
 	dataCorteExpurgo := time.Now()
 
 	// Get parameter for transaction removal
-	paramJob, err := uc.GetValueParameterRemoveTransactionJob()
+	paramJob, err := uc.GetValueParameterRemoveTransactionJob(ctx)
 	if err != nil {
 		return fmt.Errorf("erro ao obter parâmetro de remoção de transação: %w", err)
 	}
@@ -115,29 +116,29 @@ func (uc *IntegrationJobUseCase) IntegrationJob() error {
 	dataCorte = dataCorte.Add(-time.Duration(min) * time.Minute)
 
 	// Remove transactions
-	if err := uc.RemoverTransacaoIntegracaoCombo(dataCorte); err != nil {
+	if err := uc.RemoverTransacaoIntegracaoCombo(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.RemoverTransacaoIntegracaoEmbalagem(dataCorte); err != nil {
+	if err := uc.RemoverTransacaoIntegracaoEmbalagem(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte); err != nil {
+	if err := uc.RemoverTransacaoIntegracaoEstruturaMercadologica(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.RemoverTransacaoIntegracaoProduto(dataCorte); err != nil {
+	if err := uc.RemoverTransacaoIntegracaoProduto(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.RemoverTransacaoIntegracaoPromocao(dataCorte); err != nil {
+	if err := uc.RemoverTransacaoIntegracaoPromocao(ctx, dataCorte); err != nil {
 		return err
 	}
 
 	// Update parameter
-	if err := uc.SetValueParameterEndTransactionJob(); err != nil {
+	if err := uc.SetValueParameterEndTransactionJob(ctx); err != nil {
 		return err
 	}
 
 	// Get expiry parameter
-	paramExpurgo, err := uc.GetValueParameterExpurgoDiasJob()
+	paramExpurgo, err := uc.GetValueParameterExpurgoDiasJob(ctx)
 	if err != nil {
 		return fmt.Errorf("erro ao obter parâmetro de expurgo: %w", err)
 	}
@@ -155,23 +156,23 @@ func (uc *IntegrationJobUseCase) IntegrationJob() error {
 	log.Printf("Data Corte Expurgo: %v", dataCorteExpurgo)
 
 	// Execute expiry operations
-	if err := uc.ExpurgoIntegracaoCombo(dataCorteExpurgo); err != nil {
+	if err := uc.ExpurgoIntegracaoCombo(ctx, dataCorteExpurgo); err != nil {
 		return err
 	}
-	if err := uc.ExpurgoIntegracaoEmbalagem(dataCorteExpurgo); err != nil {
+	if err := uc.ExpurgoIntegracaoEmbalagem(ctx, dataCorteExpurgo); err != nil {
 		return err
 	}
-	if err := uc.ExpurgoIntegracaoEstruturaMercadologica(dataCorteExpurgo); err != nil {
+	if err := uc.ExpurgoIntegracaoEstruturaMercadologica(ctx, dataCorteExpurgo); err != nil {
 		return err
 	}
-	if err := uc.ExpurgoIntegracaoProduto(dataCorteExpurgo); err != nil {
+	if err := uc.ExpurgoIntegracaoProduto(ctx, dataCorteExpurgo); err != nil {
 		return err
 	}
-	if err := uc.ExpurgoIntegracaoPromocao(dataCorteExpurgo); err != nil {
+	if err := uc.ExpurgoIntegracaoPromocao(ctx, dataCorteExpurgo); err != nil {
 		return err
 	}
 
-	if err := uc.SetValueParameterExpurgoUltimaExcucaoJob(); err != nil {
+	if err := uc.SetValueParameterExpurgoUltimaExcucaoJob(ctx); err != nil {
 		return err
 	}
 
@@ -180,14 +181,14 @@ func (uc *IntegrationJobUseCase) IntegrationJob() error {
 }
 
 // Expiry operations
-func (uc *IntegrationJobUseCase) ExpurgoIntegracaoCombo(dataCorte time.Time) error {
-	data, err := uc.integrationRepo.GetIntegrationUpdateComboByDate(dataCorte)
+func (uc *IntegrationJobUseCase) ExpurgoIntegracaoCombo(ctx context.Context, dataCorte time.Time) error {
+	data, err := uc.integrationRepo.GetIntegrationUpdateComboByDate(ctx, dataCorte)
 	if err != nil {
 		return err
 	}
 
 	for _, item := range data {
-		if err := uc.integrationRepo.DeleteIntegrationCombo(item.IdIntegracaoCombo); err != nil {
+		if err := uc.integrationRepo.DeleteIntegrationCombo(ctx, item.IdIntegracaoCombo); err != nil {
 			log.Printf("Erro ao deletar combo %d: %v", item.IdIntegracaoCombo, err)
 			// Continue with other items
 		}
@@ -195,26 +196,26 @@ func (uc *IntegrationJobUseCase) ExpurgoIntegracaoCombo(dataCorte time.Time) err
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) ExpurgoIntegracaoEmbalagem(dataCorte time.Time) error {
-	return uc.integrationRepo.ClearIntegrationPackagingByCutOffDate(dataCorte, "SIM")
+func (uc *IntegrationJobUseCase) ExpurgoIntegracaoEmbalagem(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.ClearIntegrationPackagingByCutOffDate(ctx, dataCorte, "SIM")
 }
 
-func (uc *IntegrationJobUseCase) ExpurgoIntegracaoEstruturaMercadologica(dataCorte time.Time) error {
-	return uc.integrationRepo.RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte, "SIM")
+func (uc *IntegrationJobUseCase) ExpurgoIntegracaoEstruturaMercadologica(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.RemoverTransacaoIntegracaoEstruturaMercadologica(ctx, dataCorte, "SIM")
 }
 
-func (uc *IntegrationJobUseCase) ExpurgoIntegracaoProduto(dataCorte time.Time) error {
-	return uc.integrationRepo.RemoverTransacaoIntegracaoProduto(dataCorte, "SIM")
+func (uc *IntegrationJobUseCase) ExpurgoIntegracaoProduto(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.RemoverTransacaoIntegracaoProduto(ctx, dataCorte, "SIM")
 }
 
-func (uc *IntegrationJobUseCase) ExpurgoIntegracaoPromocao(dataCorte time.Time) error {
-	return uc.integrationRepo.RemoverTransacaoIntegracaoPromocao(dataCorte, "SIM")
+func (uc *IntegrationJobUseCase) ExpurgoIntegracaoPromocao(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.RemoverTransacaoIntegracaoPromocao(ctx, dataCorte, "SIM")
 }
 
 // Transaction removal operations
-func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoCombo(dataCorte time.Time) error {
+func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoCombo(ctx context.Context, dataCorte time.Time) error {
 	log.Println("RemoverTransacaoIntegracaoCombo - Início")
-	err := uc.integrationRepo.RemoveIntegrationCombo(dataCorte, "SIM")
+	err := uc.integrationRepo.RemoveIntegrationCombo(ctx, dataCorte, "SIM")
 	if err != nil {
 		return err
 	}
@@ -222,9 +223,9 @@ func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoCombo(dataCorte time.
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoEmbalagem(dataCorte time.Time) error {
+func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoEmbalagem(ctx context.Context, dataCorte time.Time) error {
 	log.Println("Remover transação integração embalagem - Início")
-	err := uc.integrationRepo.ClearIntegrationPackagingByCutOffDate(dataCorte)
+	err := uc.integrationRepo.ClearIntegrationPackagingByCutOffDate(ctx, dataCorte)
 	if err != nil {
 		return err
 	}
@@ -232,9 +233,9 @@ func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoEmbalagem(dataCorte t
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte time.Time) error {
+func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoEstruturaMercadologica(ctx context.Context, dataCorte time.Time) error {
 	log.Println("Remover Transação Integração Estrutura Mercadológica - Início")
-	err := uc.integrationRepo.RemoverTransacaoIntegracaoEstruturaMercadologica(dataCorte)
+	err := uc.integrationRepo.RemoverTransacaoIntegracaoEstruturaMercadologica(ctx, dataCorte)
 	if err != nil {
 		return err
 	}
@@ -242,9 +243,9 @@ func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoEstruturaMercadologic
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoProduto(dataCorte time.Time) error {
+func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoProduto(ctx context.Context, dataCorte time.Time) error {
 	log.Println("Remover transação integração produto - Início")
-	err := uc.integrationRepo.RemoverTransacaoIntegracaoProduto(dataCorte)
+	err := uc.integrationRepo.RemoverTransacaoIntegracaoProduto(ctx, dataCorte)
 	if err != nil {
 		return err
 	}
@@ -252,9 +253,9 @@ func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoProduto(dataCorte tim
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoPromocao(dataCorte time.Time) error {
+func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoPromocao(ctx context.Context, dataCorte time.Time) error {
 	log.Println("Remover transação integração promoção - Início")
-	err := uc.integrationRepo.RemoverTransacaoIntegracaoPromocao(dataCorte)
+	err := uc.integrationRepo.RemoverTransacaoIntegracaoPromocao(ctx, dataCorte)
 	if err != nil {
 		return err
 	}
@@ -263,68 +264,68 @@ func (uc *IntegrationJobUseCase) RemoverTransacaoIntegracaoPromocao(dataCorte ti
 }
 
 // Parameter operations
-func (uc *IntegrationJobUseCase) GetValueParameterRemoveTransactionJob() (*entities.IParameter, error) {
-	return uc.parameterRepo.ListByCodeParameter("REMOVER_TRANSACAO_MINUTOS")
+func (uc *IntegrationJobUseCase) GetValueParameterRemoveTransactionJob(ctx context.Context) (*entities.IParameter, error) {
+	return uc.parameterRepo.ListByCodeParameter(ctx, "REMOVER_TRANSACAO_MINUTOS")
 }
 
-func (uc *IntegrationJobUseCase) GetValueParameterExpurgoDiasJob() (*entities.IParameter, error) {
-	return uc.parameterRepo.ListByCodeParameter("EXPURGO_INTEGRACAO_DIAS")
+func (uc *IntegrationJobUseCase) GetValueParameterExpurgoDiasJob(ctx context.Context) (*entities.IParameter, error) {
+	return uc.parameterRepo.ListByCodeParameter(ctx, "EXPURGO_INTEGRACAO_DIAS")
 }
 
-func (uc *IntegrationJobUseCase) SetValueParameterExpurgoUltimaExcucaoJob() error {
-	param, err := uc.parameterRepo.ListByCodeParameter("Parametro_ExpurgoIntegracaoUltimaExecucao")
+func (uc *IntegrationJobUseCase) SetValueParameterExpurgoUltimaExcucaoJob(ctx context.Context) error {
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, "Parametro_ExpurgoIntegracaoUltimaExecucao")
 	if err != nil {
 		return err
 	}
 	if param != nil && param.Ambiente == "*" {
 		param.Valor = time.Now().String()
-		return uc.parameterRepo.Update(param)
+		return uc.parameterRepo.Update(ctx, param)
 	}
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) SetValueParameterEndTransactionJob() error {
-	param, err := uc.parameterRepo.ListByCodeParameter("RemoverTransacaoUltimaExecucao")
+func (uc *IntegrationJobUseCase) SetValueParameterEndTransactionJob(ctx context.Context) error {
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, "RemoverTransacaoUltimaExecucao")
 	if err != nil {
 		return err
 	}
 	if param != nil && param.Ambiente == "*" {
 		param.Valor = time.Now().String()
-		return uc.parameterRepo.Update(param)
+		return uc.parameterRepo.Update(ctx, param)
 	}
 	return nil
 }
 
 // ReplicateNetworkProductsJob replicates products across networks
-func (uc *IntegrationJobUseCase) ReplicateNetworkProductsJob() error {
+func (uc *IntegrationJobUseCase) ReplicateNetworkProductsJob(ctx context.Context) error {
 	log.Println("Replicar produtos redes - Início.")
 
-	networks, err := uc.networkRepo.GetNetwork()
+	networks, err := uc.networkRepo.GetNetwork(ctx)
 	if err != nil {
 		return fmt.Errorf("erro ao obter redes: %w", err)
 	}
 
 	for _, net := range networks {
-		lojas, err := uc.networkRepo.ListByAllByIdDealerNew(net.IdRevendedor)
+		lojas, err := uc.networkRepo.ListByAllByIdDealerNew(ctx, net.IdRevendedor)
 		if err != nil {
 			log.Printf("Erro ao obter lojas para revendedor %d: %v", net.IdRevendedor, err)
 			continue
 		}
 
-		err = uc.networkRepo.ReplicateProductNetwork(net.IdRede)
+		err = uc.networkRepo.ReplicateProductNetwork(ctx, net.IdRede)
 		if err != nil {
 			log.Printf("Erro ao replicar produtos da rede %d: %v", net.IdRede, err)
 			continue
 		}
 
 		for _, ljsItem := range lojas {
-			_, err := uc.networkRepo.GetNetworkReplicadosByDealer(ljsItem.IdRevendedor)
+			_, err := uc.networkRepo.GetNetworkReplicadosByDealer(ctx, ljsItem.IdRevendedor)
 			if err != nil {
 				log.Printf("Erro ao obter replicados do revendedor %d: %v", ljsItem.IdRevendedor, err)
 				continue
 			}
 
-			_, err = uc.networkRepo.GetProductsByReplicateNetworkServiceNew(ljsItem.IdRevendedor)
+			_, err = uc.networkRepo.GetProductsByReplicateNetworkServiceNew(ctx, ljsItem.IdRevendedor)
 			if err != nil {
 				log.Printf("Erro ao obter produtos para replicação do revendedor %d: %v", ljsItem.IdRevendedor, err)
 				continue
@@ -337,46 +338,46 @@ func (uc *IntegrationJobUseCase) ReplicateNetworkProductsJob() error {
 }
 
 // MoveDataJob moves data between staging tables
-func (uc *IntegrationJobUseCase) MoveDataJob(dataCorte time.Time) error {
-	if err := uc.MoverEstruturaMercadologica(dataCorte); err != nil {
+func (uc *IntegrationJobUseCase) MoveDataJob(ctx context.Context, dataCorte time.Time) error {
+	if err := uc.MoverEstruturaMercadologica(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.MoverProduto(dataCorte); err != nil {
+	if err := uc.MoverProduto(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.MoverEmbalagem(dataCorte); err != nil {
+	if err := uc.MoverEmbalagem(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.MoverCombo(dataCorte); err != nil {
+	if err := uc.MoverCombo(ctx, dataCorte); err != nil {
 		return err
 	}
-	if err := uc.MoverPromocao(dataCorte); err != nil {
+	if err := uc.MoverPromocao(ctx, dataCorte); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (uc *IntegrationJobUseCase) MoverEstruturaMercadologica(dataCorte time.Time) error {
-	return uc.integrationRepo.MoveIntegrationMarketingStructure(dataCorte)
+func (uc *IntegrationJobUseCase) MoverEstruturaMercadologica(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.MoveIntegrationMarketingStructure(ctx, dataCorte)
 }
 
-func (uc *IntegrationJobUseCase) MoverProduto(dataCorte time.Time) error {
-	return uc.integrationRepo.MoveIntegrationProductStaging(dataCorte)
+func (uc *IntegrationJobUseCase) MoverProduto(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.MoveIntegrationProductStaging(ctx, dataCorte)
 }
 
-func (uc *IntegrationJobUseCase) MoverEmbalagem(dataCorte time.Time) error {
-	return uc.integrationRepo.MoveIntegrationPackagingStaging(dataCorte)
+func (uc *IntegrationJobUseCase) MoverEmbalagem(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.MoveIntegrationPackagingStaging(ctx, dataCorte)
 }
 
-func (uc *IntegrationJobUseCase) MoverCombo(dataCorte time.Time) error {
-	return uc.integrationRepo.MoveIntegrationComboStaging(dataCorte)
+func (uc *IntegrationJobUseCase) MoverCombo(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.MoveIntegrationComboStaging(ctx, dataCorte)
 }
 
-func (uc *IntegrationJobUseCase) MoverPromocao(dataCorte time.Time) error {
-	return uc.integrationRepo.MoveIntegrationPromotionStaging(dataCorte)
+func (uc *IntegrationJobUseCase) MoverPromocao(ctx context.Context, dataCorte time.Time) error {
+	return uc.integrationRepo.MoveIntegrationPromotionStaging(ctx, dataCorte)
 }
 
 // UpdateExpirationSlaRequestsJob updates expired SLA requests
-func (uc *IntegrationJobUseCase) UpdateExpirationSlaRequestsJob() error {
-	return uc.integrationRepo.UpdateExpiredSlaSolicitation()
+func (uc *IntegrationJobUseCase) UpdateExpirationSlaRequestsJob(ctx context.Context) error {
+	return uc.integrationRepo.UpdateExpiredSlaSolicitation(ctx)
 }
@@ -0,0 +1,65 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+)
+
+// LogConsumerUseCase persists the LogIntegrRMS messages of the "log" queue into LOG_INTEGR_RMS
+type LogConsumerUseCase struct {
+	repo *repositories.ProductIntegrationRepository
+	db   *sql.DB
+}
+
+// NewLogConsumerUseCase creates a new instance of LogConsumerUseCase
+func NewLogConsumerUseCase(repo *repositories.ProductIntegrationRepository, db *sql.DB) *LogConsumerUseCase {
+	return &LogConsumerUseCase{
+		repo: repo,
+		db:   db,
+	}
+}
+
+// ParseLogMessage decodes a versioned or legacy queue message body and checks its shape
+func (uc *LogConsumerUseCase) ParseLogMessage(body []byte) (entities.LogIntegrRMS, error) {
+	event, err := entities.DecodeLogEvent(body)
+	if err != nil {
+		return entities.LogIntegrRMS{}, err
+	}
+	return event.ToLogIntegrRMS(), nil
+}
+
+// SaveBatch inserts the logs in a single transaction; the caller acknowledges the messages
+// only after it returns nil
+func (uc *LogConsumerUseCase) SaveBatch(ctx context.Context, logs []entities.LogIntegrRMS) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	tx, err := uc.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := uc.repo.WithTx(tx).SaveLogIntegrationBatch(ctx, logs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	log.Printf("%d logs gravados em LOG_INTEGR_RMS", len(logs))
+	return nil
+}
@@ -0,0 +1,248 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+)
+
+// logOutboxMaxBackoff caps the delay between publishing attempts of an outbox message
+const logOutboxMaxBackoff = time.Hour
+
+// logOutboxPurgeInterval is how often the relay deletes published messages
+const logOutboxPurgeInterval = time.Hour
+
+// LogOutbox writes LogIntegrRMS messages to LOG_INTEGR_OUTBOX in the transaction of the
+// business change, so the log exists if and only if the change was committed. A nil
+// *LogOutbox commits the transaction and publishes the message directly instead.
+type LogOutbox struct {
+	db   *sql.DB
+	repo *repositories.LogOutboxRepository
+}
+
+// NewLogOutbox creates a new instance of LogOutbox
+func NewLogOutbox(db *sql.DB, repo *repositories.LogOutboxRepository) *LogOutbox {
+	return &LogOutbox{
+		db:   db,
+		repo: repo,
+	}
+}
+
+// Begin starts a transaction for a business change logged through the outbox; it is rolled
+// back if ctx is cancelled before the commit
+func (o *LogOutbox) Begin(ctx context.Context) (*sql.Tx, error) {
+	return o.db.BeginTx(ctx, nil)
+}
+
+// CommitWithLog enqueues message in tx and commits it. The transaction is rolled back when
+// the message cannot be enqueued.
+func (o *LogOutbox) CommitWithLog(ctx context.Context, tx *sql.Tx, message entities.LogEvent, publish func(entities.LogEvent) error) error {
+	if o != nil {
+		if err := o.repo.WithTx(tx).Enqueue(ctx, message); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("Erro ao desfazer transação após falha no outbox: %v", rbErr)
+			}
+			return fmt.Errorf("erro ao gravar log no outbox: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	if o == nil {
+		if err := publish(message); err != nil {
+			log.Printf("Erro ao enviar log para fila: %v", err)
+		}
+	}
+	return nil
+}
+
+// Log records a message that has no business change attached, such as a rejected payload.
+// The message is kept even when ctx was already cancelled.
+func (o *LogOutbox) Log(ctx context.Context, message entities.LogEvent, publish func(entities.LogEvent) error) error {
+	if o == nil {
+		return publish(message)
+	}
+	return o.repo.Enqueue(context.WithoutCancel(ctx), message)
+}
+
+// LogOutboxRelay publishes pending outbox messages in the background, retrying failures with
+// exponential backoff, and purges published messages after the retention period. Delivery is
+// at least once: a message published right before a crash is published again.
+type LogOutboxRelay struct {
+	outbox    *LogOutbox
+	publisher entities.LogPublisher
+
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	retention   time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewLogOutboxRelay creates a relay with the default interval, batch size, attempts and retention
+func NewLogOutboxRelay(outbox *LogOutbox, publisher entities.LogPublisher) *LogOutboxRelay {
+	return &LogOutboxRelay{
+		outbox:      outbox,
+		publisher:   publisher,
+		interval:    entities.DEFAULT_LOG_OUTBOX_INTERVAL_SECONDS * time.Second,
+		batchSize:   entities.DEFAULT_LOG_OUTBOX_BATCH_SIZE,
+		maxAttempts: entities.DEFAULT_LOG_OUTBOX_MAX_ATTEMPTS,
+		retention:   entities.DEFAULT_LOG_OUTBOX_RETENTION_HOURS * time.Hour,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// SetInterval sets how often pending messages are read
+func (r *LogOutboxRelay) SetInterval(interval time.Duration) {
+	if interval > 0 {
+		r.interval = interval
+	}
+}
+
+// SetBatchSize sets how many messages are claimed per round
+func (r *LogOutboxRelay) SetBatchSize(batchSize int) {
+	if batchSize > 0 {
+		r.batchSize = batchSize
+	}
+}
+
+// SetMaxAttempts sets after how many failed attempts a message is marked as failed
+func (r *LogOutboxRelay) SetMaxAttempts(maxAttempts int) {
+	if maxAttempts > 0 {
+		r.maxAttempts = maxAttempts
+	}
+}
+
+// SetRetention sets how long published messages are kept
+func (r *LogOutboxRelay) SetRetention(retention time.Duration) {
+	if retention > 0 {
+		r.retention = retention
+	}
+}
+
+// Start runs the relay in a goroutine until Stop is called. Stop does not cancel the current
+// round, so claimed messages are always marked before the relay exits.
+func (r *LogOutboxRelay) Start() {
+	log.Printf("Relay do outbox de logs iniciado (intervalo %s, lote %d)", r.interval, r.batchSize)
+
+	go func() {
+		defer close(r.done)
+
+		ctx := context.Background()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		var lastPurge time.Time
+		for {
+			// Drain the backlog before waiting for the next tick
+			for {
+				relayed, err := r.RelayOnce(ctx)
+				if err != nil {
+					log.Printf("Erro no relay do outbox de logs: %v", err)
+				}
+				if err != nil || relayed < r.batchSize {
+					break
+				}
+			}
+
+			if time.Since(lastPurge) >= logOutboxPurgeInterval {
+				if purged, err := r.Purge(ctx); err != nil {
+					log.Printf("Erro ao expurgar outbox de logs: %v", err)
+				} else if purged > 0 {
+					log.Printf("Outbox de logs: %d mensagens enviadas expurgadas", purged)
+				}
+				lastPurge = time.Now()
+			}
+
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop signals the relay to finish and waits for the current round
+func (r *LogOutboxRelay) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+		log.Printf("Relay do outbox de logs finalizado")
+	})
+}
+
+// RelayOnce publishes one batch of pending messages and returns how many were claimed
+func (r *LogOutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	tx, err := r.outbox.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação do outbox: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	repo := r.outbox.repo.WithTx(tx)
+	entries, err := repo.ClaimPending(ctx, r.batchSize)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if err := r.relay(ctx, repo, entry); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao confirmar transação do outbox: %w", err)
+	}
+	return len(entries), nil
+}
+
+// relay publishes a single message and records the outcome
+func (r *LogOutboxRelay) relay(ctx context.Context, repo *repositories.LogOutboxRepository, entry entities.LogOutboxEntry) error {
+	event, publishErr := entities.DecodeLogEvent([]byte(entry.Mensagem))
+	if publishErr == nil {
+		publishErr = r.publisher.Publish(event)
+	}
+	if publishErr == nil {
+		return repo.MarkSent(ctx, entry.IdOutbox)
+	}
+
+	attempts := entry.Tentativas + 1
+	if attempts >= r.maxAttempts {
+		log.Printf("Mensagem %d do outbox de logs descartada após %d tentativas: %v", entry.IdOutbox, attempts, publishErr)
+		return repo.MarkFailed(ctx, entry.IdOutbox, attempts, publishErr.Error())
+	}
+
+	backoff := r.interval << (attempts - 1)
+	if backoff <= 0 || backoff > logOutboxMaxBackoff {
+		backoff = logOutboxMaxBackoff
+	}
+	log.Printf("Falha ao publicar mensagem %d do outbox de logs (tentativa %d), nova tentativa em %s: %v", entry.IdOutbox, attempts, backoff, publishErr)
+	return repo.MarkRetry(ctx, entry.IdOutbox, attempts, time.Now().Add(backoff), publishErr.Error())
+}
+
+// Purge deletes messages published before the retention period
+func (r *LogOutboxRelay) Purge(ctx context.Context) (int64, error) {
+	return r.outbox.repo.PurgeSent(ctx, time.Now().Add(-r.retention))
+}
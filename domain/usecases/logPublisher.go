@@ -0,0 +1,196 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+	"github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
+)
+
+// logBrokerRetryInterval is how long the publisher goes straight to the fallback after the
+// broker could not be reached, so a RabbitMQ outage does not add a dial to every log
+const logBrokerRetryInterval = 30 * time.Second
+
+// logIntegrationStore writes LOG_INTEGR_RMS rows directly
+type logIntegrationStore interface {
+	SaveLogIntegration(ctx context.Context, log entities.LogIntegrRMS) error
+}
+
+// LogPublisher publishes log events to RabbitMQ, in the legacy tabela/fields/values format
+// unless SetFormat selects the versioned one. When an exchange is configured the queue is
+// bound to it with the queue name as routing key; otherwise the message goes to the queue
+// through the default exchange. If the broker is unavailable the log is written to
+// LOG_INTEGR_RMS through the fallback store.
+type LogPublisher struct {
+	publisher broker.Publisher
+	exchange  string
+	queue     string
+	format    string
+	store     logIntegrationStore
+
+	mu          sync.Mutex
+	retryAfter  time.Time
+	declared    bool   // queue, exchange and binding declared since the last failure or reconnection
+	unsubscribe func() // removes the reconnection subscription of publisher
+}
+
+// NewLogPublisher creates a publisher for the "log" queue; store may be nil to disable the fallback
+func NewLogPublisher(rabbitmqURL string, store logIntegrationStore) *LogPublisher {
+	return &LogPublisher{
+		publisher: rabbitmq.NewAMQPBroker(rabbitmqURL),
+		queue:     entities.DEFAULT_LOG_QUEUE,
+		format:    entities.LOG_MESSAGE_FORMAT_LEGACY,
+		store:     store,
+	}
+}
+
+// SetBroker replaces the broker logs are published to, e.g. with a broker.MemoryBroker
+func (p *LogPublisher) SetBroker(publisher broker.Publisher) {
+	if publisher == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unsubscribe != nil {
+		p.unsubscribe()
+		p.unsubscribe = nil
+	}
+	p.publisher = publisher
+	p.declared = false
+	p.retryAfter = time.Time{}
+}
+
+// reconnected forgets the declarations and the outage window once the broker connection is
+// back, since a restarted server may have lost non-durable topology
+func (p *LogPublisher) reconnected() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.declared = false
+	p.retryAfter = time.Time{}
+}
+
+// SetExchange sets the exchange logs are published to
+func (p *LogPublisher) SetExchange(exchange string) {
+	p.exchange = exchange
+}
+
+// SetQueue sets the queue logs are delivered to
+func (p *LogPublisher) SetQueue(queue string) {
+	if queue != "" {
+		p.queue = queue
+	}
+}
+
+// SetFormat selects the wire format: "legacy" (default) or "v1"
+func (p *LogPublisher) SetFormat(format string) {
+	switch format {
+	case entities.LOG_MESSAGE_FORMAT_LEGACY, entities.LOG_MESSAGE_FORMAT_V1:
+		p.format = format
+	case "":
+	default:
+		log.Printf("Formato de log desconhecido '%s', usando %s", format, p.format)
+	}
+}
+
+// Publish sends the event to the broker, falling back to LOG_INTEGR_RMS when it fails
+func (p *LogPublisher) Publish(event entities.LogEvent) error {
+	body, err := entities.EncodeLogEvent(event, p.format)
+	if err != nil {
+		return fmt.Errorf("erro ao converter log para JSON: %w", err)
+	}
+
+	publishErr := p.publish(body)
+	if publishErr == nil {
+		return nil
+	}
+	log.Printf("Erro ao publicar log no RabbitMQ: %v", publishErr)
+
+	if p.store == nil {
+		return publishErr
+	}
+
+	// The log must be kept even when the operation that produced it was cancelled
+	logIntegration := event.ToLogIntegrRMS()
+	if err := p.store.SaveLogIntegration(context.Background(), logIntegration); err != nil {
+		return fmt.Errorf("erro ao gravar log em LOG_INTEGR_RMS após falha no RabbitMQ (%v): %w", publishErr, err)
+	}
+
+	log.Printf("Log de %s gravado diretamente em LOG_INTEGR_RMS", logIntegration.Tabela)
+	return nil
+}
+
+// publish delivers body to the configured exchange or queue
+func (p *LogPublisher) publish(body []byte) error {
+	p.mu.Lock()
+	retryAfter := p.retryAfter
+	p.mu.Unlock()
+	if time.Now().Before(retryAfter) {
+		return fmt.Errorf("RabbitMQ indisponível até %s", retryAfter.Format(time.RFC3339))
+	}
+
+	err := p.declare()
+	if err == nil {
+		err = p.publisher.Publish(context.Background(), p.exchange, p.queue, broker.Message{
+			ContentType: "application/json",
+			Persistent:  true,
+			Body:        body,
+		})
+		if err != nil {
+			err = fmt.Errorf("erro ao enviar mensagem para fila %s: %w", p.queue, err)
+		}
+	}
+	if err != nil {
+		p.mu.Lock()
+		p.declared = false
+		if errors.Is(err, broker.ErrUnavailable) {
+			p.retryAfter = time.Now().Add(logBrokerRetryInterval)
+		}
+		p.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// declare declares the queue and, with an exchange, binds it with the queue name as
+// routing key; it runs again after a failed publish
+func (p *LogPublisher) declare() error {
+	p.mu.Lock()
+	declared := p.declared
+	if p.unsubscribe == nil {
+		// Subscribed on first use, so creating the publisher does not connect to the broker
+		if notifier, ok := p.publisher.(broker.ReconnectNotifier); ok {
+			p.unsubscribe = notifier.OnReconnect(p.reconnected)
+		} else {
+			p.unsubscribe = func() {}
+		}
+	}
+	p.mu.Unlock()
+	if declared {
+		return nil
+	}
+
+	if err := p.publisher.DeclareQueue(p.queue); err != nil {
+		return fmt.Errorf("erro ao declarar fila %s: %w", p.queue, err)
+	}
+	if p.exchange != "" {
+		if err := p.publisher.DeclareExchange(p.exchange, "direct"); err != nil {
+			return fmt.Errorf("erro ao declarar exchange %s: %w", p.exchange, err)
+		}
+		if err := p.publisher.BindQueue(p.queue, p.queue, p.exchange); err != nil {
+			return fmt.Errorf("erro ao vincular fila %s à exchange %s: %w", p.queue, p.exchange, err)
+		}
+	}
+
+	p.mu.Lock()
+	p.declared = true
+	p.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,240 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+)
+
+// MarketingStructureIntegrationUseCase ingests marketing structure changes sent by RMS
+// into ESTRUTURA_MERCADOLOGICA, DEPARTAMENTO and SECAO
+type MarketingStructureIntegrationUseCase struct {
+	repo   *repositories.ProductIntegrationRepository
+	db     *sql.DB
+	outbox *LogOutbox
+}
+
+// NewMarketingStructureIntegrationUseCase creates a new instance of MarketingStructureIntegrationUseCase
+func NewMarketingStructureIntegrationUseCase(repo *repositories.ProductIntegrationRepository, db *sql.DB) *MarketingStructureIntegrationUseCase {
+	return &MarketingStructureIntegrationUseCase{
+		repo: repo,
+		db:   db,
+	}
+}
+
+// SetLogOutbox makes LogIntegrRMS messages be written to the outbox in the upsert transaction
+func (uc *MarketingStructureIntegrationUseCase) SetLogOutbox(outbox *LogOutbox) {
+	uc.outbox = outbox
+}
+
+// ImportMarketingStructure validates the payload against the current hierarchy, upserts it
+// in a single transaction and logs the result to LogIntegrRMS like the product import.
+// Successful imports are logged in the upsert transaction.
+func (uc *MarketingStructureIntegrationUseCase) ImportMarketingStructure(ctx context.Context, payload string) *entities.LogValidate {
+	receivedAt := time.Now()
+	idExecucao := entities.NewIdExecucao()
+	log.Printf("Iniciando integração de estrutura mercadológica (execução %s)", idExecucao)
+
+	input, result := uc.validateMarketingStructurePayload(ctx, payload)
+	if input != nil {
+		result = &entities.LogValidate{
+			Success: true,
+			Message: fmt.Sprintf("Estrutura mercadológica integrada com sucesso: %d registro(s)", len(input.Estruturas)),
+		}
+		if err := uc.upsertMarketingStructures(ctx, input.Estruturas, uc.newLogMessage(receivedAt, payload, result, idExecucao)); err != nil {
+			result = &entities.LogValidate{Success: false, Message: err.Error()}
+		}
+	}
+
+	if !result.Success {
+		if err := uc.outbox.Log(ctx, uc.newLogMessage(receivedAt, payload, result, idExecucao), uc.repo.SendToQueue); err != nil {
+			log.Printf("Erro ao enviar log da estrutura mercadológica: %v", err)
+		}
+	}
+
+	log.Printf("Integração de estrutura mercadológica finalizada (sucesso: %t): %s", result.Success, result.Message)
+	return result
+}
+
+// newLogMessage builds the log event of an import
+func (uc *MarketingStructureIntegrationUseCase) newLogMessage(receivedAt time.Time, payload string, result *entities.LogValidate, idExecucao string) entities.LogEvent {
+	return entities.NewLogEvent("IN", "ESTRUTURA_MERCADOLOGICA", receivedAt, entities.LogEventStatusOf(result.Success),
+		payload, result.Message, idExecucao)
+}
+
+// validateMarketingStructurePayload parses and validates the payload, returning the input
+// only when it can be upserted
+func (uc *MarketingStructureIntegrationUseCase) validateMarketingStructurePayload(ctx context.Context, payload string) (*entities.MarketingStructureInJson, *entities.LogValidate) {
+	var input entities.MarketingStructureInJson
+	if err := json.Unmarshal([]byte(payload), &input); err != nil {
+		return nil, &entities.LogValidate{Success: false, Message: fmt.Sprintf("JSON da estrutura mercadológica inválido: %v", err)}
+	}
+
+	if violations := validateMarketingStructureInput(input); len(violations) > 0 {
+		return nil, &entities.LogValidate{Success: false, Message: "Estrutura mercadológica inválida: " + strings.Join(violations, "; ")}
+	}
+
+	existing, err := uc.repo.ListMarketingStructures(ctx)
+	if err != nil {
+		return nil, &entities.LogValidate{Success: false, Message: fmt.Sprintf("Erro ao carregar estrutura mercadológica: %v", err)}
+	}
+
+	if violations := validateAgainstHierarchy(existing, input.Estruturas); len(violations) > 0 {
+		return nil, &entities.LogValidate{Success: false, Message: "Estrutura mercadológica inconsistente: " + strings.Join(violations, "; ")}
+	}
+
+	return &input, nil
+}
+
+// validateMarketingStructureInput checks each node on its own
+func validateMarketingStructureInput(input entities.MarketingStructureInJson) []string {
+	var violations []string
+	if len(input.Estruturas) == 0 {
+		return []string{"$.estruturas: deve ter ao menos 1 item"}
+	}
+
+	seen := make(map[int]bool, len(input.Estruturas))
+	for i, in := range input.Estruturas {
+		path := fmt.Sprintf("$.estruturas[%d]", i)
+		if in.Id <= 0 {
+			violations = append(violations, path+".id: deve ser maior que 0")
+		} else if seen[in.Id] {
+			violations = append(violations, fmt.Sprintf("%s.id: %d repetido no payload", path, in.Id))
+		}
+		seen[in.Id] = true
+
+		if strings.TrimSpace(in.Descricao) == "" {
+			violations = append(violations, path+".descricao: campo obrigatório vazio")
+		}
+		if in.Nivel < 1 || in.Nivel > entities.MARKETING_STRUCTURE_LEVELS {
+			violations = append(violations, fmt.Sprintf("%s.nivel: deve estar entre 1 e %d", path, entities.MARKETING_STRUCTURE_LEVELS))
+		}
+		if in.Nivel == 1 && in.IdPai != nil && *in.IdPai > 0 {
+			violations = append(violations, path+".idPai: nível 1 não pode ter pai")
+		}
+		if in.Nivel > 1 && (in.IdPai == nil || *in.IdPai <= 0) {
+			violations = append(violations, path+".idPai: obrigatório a partir do nível 2")
+		}
+		if in.IdDepartamento != nil && *in.IdDepartamento <= 0 {
+			violations = append(violations, path+".idDepartamento: deve ser maior que 0")
+		}
+		if in.IdSecao != nil && *in.IdSecao <= 0 {
+			violations = append(violations, path+".idSecao: deve ser maior que 0")
+		}
+	}
+
+	return violations
+}
+
+// validateAgainstHierarchy merges the payload into the current rows and reports the
+// problems it would introduce. Problems that already exist in the table do not block
+// the import, and leaves above level 4 are accepted since children may come later.
+func validateAgainstHierarchy(existing []entities.MarketingStructure, incoming []entities.MarketingStructureIn) []string {
+	current := NewMarketingStructureTree(existing)
+	known := make(map[string]bool)
+	for _, issue := range current.Validate() {
+		known[fmt.Sprintf("%s:%d", issue.Tipo, issue.IdEstruturaMercadologica)] = true
+	}
+
+	merged := make(map[int]entities.MarketingStructure, len(existing)+len(incoming))
+	for _, ms := range existing {
+		if ms.IdEstruturaMercadologica != nil {
+			merged[*ms.IdEstruturaMercadologica] = ms
+		}
+	}
+	for _, in := range incoming {
+		merged[in.Id] = toMarketingStructure(in)
+	}
+
+	rows := make([]entities.MarketingStructure, 0, len(merged))
+	for _, ms := range merged {
+		rows = append(rows, ms)
+	}
+	tree := NewMarketingStructureTree(rows)
+
+	var violations []string
+	for _, issue := range tree.Validate() {
+		if known[fmt.Sprintf("%s:%d", issue.Tipo, issue.IdEstruturaMercadologica)] {
+			continue
+		}
+		if issue.Tipo == entities.MARKETING_STRUCTURE_ISSUE_DEPTH {
+			if level, err := tree.Level(issue.IdEstruturaMercadologica); err == nil && level <= entities.MARKETING_STRUCTURE_LEVELS {
+				continue
+			}
+		}
+		violations = append(violations, fmt.Sprintf("[%d] %s: %s", issue.IdEstruturaMercadologica, issue.Tipo, issue.Mensagem))
+	}
+
+	for _, in := range incoming {
+		if level, err := tree.Level(in.Id); err == nil && level != in.Nivel {
+			violations = append(violations, fmt.Sprintf("[%d] nível informado %d, mas a hierarquia resulta em nível %d", in.Id, in.Nivel, level))
+		}
+	}
+
+	return violations
+}
+
+// upsertMarketingStructures writes departments, sections and structures, parents first,
+// committing logMessage in the same transaction
+func (uc *MarketingStructureIntegrationUseCase) upsertMarketingStructures(ctx context.Context, incoming []entities.MarketingStructureIn, logMessage entities.LogEvent) error {
+	ordered := append([]entities.MarketingStructureIn(nil), incoming...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Nivel < ordered[j].Nivel })
+
+	tx, err := uc.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	txRepo := uc.repo.WithTx(tx)
+	for _, in := range ordered {
+		if err := upsertMarketingStructure(ctx, txRepo, in); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("Erro ao desfazer transação da estrutura mercadológica: %v", rbErr)
+			}
+			return err
+		}
+	}
+
+	return uc.outbox.CommitWithLog(ctx, tx, logMessage, uc.repo.SendToQueue)
+}
+
+// upsertMarketingStructure writes one node with its department and section
+func upsertMarketingStructure(ctx context.Context, repo *repositories.ProductIntegrationRepository, in entities.MarketingStructureIn) error {
+	if in.IdDepartamento != nil && strings.TrimSpace(in.NomeDepartamento) != "" {
+		if err := repo.UpsertDepartment(ctx, entities.Department{IdDepartamento: in.IdDepartamento, NomeDepartamento: strings.TrimSpace(in.NomeDepartamento)}); err != nil {
+			return err
+		}
+	}
+	if in.IdSecao != nil && strings.TrimSpace(in.NomeSecao) != "" {
+		if err := repo.UpsertSection(ctx, entities.Section{IdSecao: in.IdSecao, NomeSecao: strings.TrimSpace(in.NomeSecao)}); err != nil {
+			return err
+		}
+	}
+	return repo.UpsertMarketingStructure(ctx, toMarketingStructure(in))
+}
+
+// toMarketingStructure converts an inbound node to the table representation
+func toMarketingStructure(in entities.MarketingStructureIn) entities.MarketingStructure {
+	id := in.Id
+	return entities.MarketingStructure{
+		IdEstruturaMercadologica: &id,
+		IdNivelPai:               in.IdPai,
+		IdDepartamento:           in.IdDepartamento,
+		IdSecao:                  in.IdSecao,
+		DescricaoEstrutura:       strings.TrimSpace(in.Descricao),
+	}
+}
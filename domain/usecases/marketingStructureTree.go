@@ -0,0 +1,219 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// errMarketingStructureCycle is wrapped by errors caused by a cycle in ID_NIVEL_PAI
+var errMarketingStructureCycle = errors.New("ciclo na estrutura mercadológica")
+
+// marketingStructureNode is a node of the ESTRUTURA_MERCADOLOGICA hierarchy
+type marketingStructureNode struct {
+	structure entities.MarketingStructure
+	children  []int
+}
+
+// MarketingStructureTree is the full ESTRUTURA_MERCADOLOGICA hierarchy built from
+// ID_NIVEL_PAI: roots are level 1 and level 4 nodes are the subclasses used by products
+type MarketingStructureTree struct {
+	nodes map[int]*marketingStructureNode
+	roots []int
+}
+
+// NewMarketingStructureTree builds the tree from every ESTRUTURA_MERCADOLOGICA row.
+// Rows whose parent does not exist are kept as roots and reported by Validate.
+func NewMarketingStructureTree(structures []entities.MarketingStructure) *MarketingStructureTree {
+	tree := &MarketingStructureTree{nodes: make(map[int]*marketingStructureNode, len(structures))}
+
+	for _, ms := range structures {
+		if ms.IdEstruturaMercadologica == nil {
+			continue
+		}
+		tree.nodes[*ms.IdEstruturaMercadologica] = &marketingStructureNode{structure: ms}
+	}
+
+	for id, node := range tree.nodes {
+		parentID, hasParent := parentOf(node.structure)
+		if parent, ok := tree.nodes[parentID]; hasParent && ok && parentID != id {
+			parent.children = append(parent.children, id)
+			continue
+		}
+		tree.roots = append(tree.roots, id)
+	}
+
+	sort.Ints(tree.roots)
+	for _, node := range tree.nodes {
+		sort.Ints(node.children)
+	}
+
+	return tree
+}
+
+// parentOf returns ID_NIVEL_PAI when set
+func parentOf(ms entities.MarketingStructure) (int, bool) {
+	if ms.IdNivelPai == nil || *ms.IdNivelPai <= 0 {
+		return 0, false
+	}
+	return *ms.IdNivelPai, true
+}
+
+// Len returns the number of structures in the tree
+func (t *MarketingStructureTree) Len() int {
+	return len(t.nodes)
+}
+
+// Get returns the structure with the given ID, or nil
+func (t *MarketingStructureTree) Get(id int) *entities.MarketingStructure {
+	node, ok := t.nodes[id]
+	if !ok {
+		return nil
+	}
+	ms := node.structure
+	return &ms
+}
+
+// ancestors returns the chain from id up to its root (id first). It stops with an
+// error on a missing parent or a cycle.
+func (t *MarketingStructureTree) ancestors(id int) ([]int, error) {
+	var chain []int
+	visited := make(map[int]bool)
+
+	current := id
+	for {
+		node, ok := t.nodes[current]
+		if !ok {
+			if current == id {
+				return nil, fmt.Errorf("estrutura mercadológica %d não encontrada", id)
+			}
+			return chain, fmt.Errorf("estrutura mercadológica %d referencia pai inexistente %d", chain[len(chain)-1], current)
+		}
+		if visited[current] {
+			return chain, fmt.Errorf("%w a partir de %d", errMarketingStructureCycle, id)
+		}
+		visited[current] = true
+		chain = append(chain, current)
+
+		parentID, hasParent := parentOf(node.structure)
+		if !hasParent {
+			return chain, nil
+		}
+		current = parentID
+	}
+}
+
+// Level returns the depth of id (1 for roots)
+func (t *MarketingStructureTree) Level(id int) (int, error) {
+	chain, err := t.ancestors(id)
+	if err != nil {
+		return 0, err
+	}
+	return len(chain), nil
+}
+
+// Resolve returns the complete level 1-4 path of a level 4 structure
+func (t *MarketingStructureTree) Resolve(id int) (*entities.MarketingStructurePath, error) {
+	chain, err := t.ancestors(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(chain) != entities.MARKETING_STRUCTURE_LEVELS {
+		return nil, fmt.Errorf("estrutura mercadológica %d está no nível %d, esperado nível %d",
+			id, len(chain), entities.MARKETING_STRUCTURE_LEVELS)
+	}
+
+	return &entities.MarketingStructurePath{
+		Nivel1: t.nodes[chain[3]].structure,
+		Nivel2: t.nodes[chain[2]].structure,
+		Nivel3: t.nodes[chain[1]].structure,
+		Nivel4: t.nodes[chain[0]].structure,
+	}, nil
+}
+
+// Validate reports orphans, cycles, nodes deeper than level 4 and leaves above level 4
+func (t *MarketingStructureTree) Validate() []entities.MarketingStructureIssue {
+	var issues []entities.MarketingStructureIssue
+
+	ids := make([]int, 0, len(t.nodes))
+	for id := range t.nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		node := t.nodes[id]
+		issue := entities.MarketingStructureIssue{
+			IdEstruturaMercadologica: id,
+			Descricao:                node.structure.DescricaoEstrutura,
+		}
+
+		if parentID, hasParent := parentOf(node.structure); hasParent {
+			if parentID == id {
+				issue.Tipo = entities.MARKETING_STRUCTURE_ISSUE_CYCLE
+				issue.Mensagem = "estrutura é pai de si mesma"
+				issues = append(issues, issue)
+				continue
+			}
+			if _, ok := t.nodes[parentID]; !ok {
+				issue.Tipo = entities.MARKETING_STRUCTURE_ISSUE_ORPHAN
+				issue.Mensagem = fmt.Sprintf("pai %d não existe", parentID)
+				issues = append(issues, issue)
+				continue
+			}
+		}
+
+		chain, err := t.ancestors(id)
+		if err != nil {
+			// Orphans higher up are reported on their own node
+			if errors.Is(err, errMarketingStructureCycle) {
+				issue.Tipo = entities.MARKETING_STRUCTURE_ISSUE_CYCLE
+				issue.Mensagem = err.Error()
+				issues = append(issues, issue)
+			}
+			continue
+		}
+
+		switch level := len(chain); {
+		case level > entities.MARKETING_STRUCTURE_LEVELS:
+			issue.Tipo = entities.MARKETING_STRUCTURE_ISSUE_DEPTH
+			issue.Mensagem = fmt.Sprintf("nível %d excede o máximo de %d", level, entities.MARKETING_STRUCTURE_LEVELS)
+			issues = append(issues, issue)
+		case level < entities.MARKETING_STRUCTURE_LEVELS && len(node.children) == 0:
+			issue.Tipo = entities.MARKETING_STRUCTURE_ISSUE_DEPTH
+			issue.Mensagem = fmt.Sprintf("folha no nível %d, esperado nível %d", level, entities.MARKETING_STRUCTURE_LEVELS)
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+// Dump writes the tree indented by level. Nodes in a cycle are not reachable from a
+// root and are therefore not printed; use Validate to list them.
+func (t *MarketingStructureTree) Dump(w io.Writer) error {
+	var walk func(id, level int) error
+	walk = func(id, level int) error {
+		node := t.nodes[id]
+		if _, err := fmt.Fprintf(w, "%s[%d] N%d %s\n", strings.Repeat("  ", level-1), id, level, node.structure.DescricaoEstrutura); err != nil {
+			return err
+		}
+		for _, child := range node.children {
+			if err := walk(child, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range t.roots {
+		if err := walk(root, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
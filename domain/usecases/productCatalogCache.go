@@ -0,0 +1,398 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+)
+
+// industryLegalSuffixes are dropped from the end of industry names before matching
+var industryLegalSuffixes = map[string]bool{
+	"LTDA": true, "SA": true, "ME": true, "EPP": true, "EIRELI": true, "CIA": true,
+}
+
+// ProductCatalogCache keeps brands, industries and marketing structures in memory for
+// product import. Names are matched by normalized key (accents, punctuation, case and
+// extra whitespace ignored). The cache reloads after ttl or when Invalidate is called.
+// A brand or industry lookup that misses reloads brands and industries before answering,
+// so rows created by the PL/SQL package or another instance are found before a duplicate
+// is inserted.
+type ProductCatalogCache struct {
+	repo *repositories.ProductIntegrationRepository
+	ttl  time.Duration
+
+	// loadMu serializes reloads; queries run holding only loadMu, so lookups keep using
+	// the previous data while a reload is running
+	loadMu sync.Mutex
+
+	mu            sync.RWMutex
+	loadedAt      time.Time
+	namesLoadedAt time.Time                      // when brands and industries were last read
+	brands        map[string][]entities.Brand    // brand key + "|" + industry key
+	industries    map[string][]entities.Industry // industry key
+	tree          *MarketingStructureTree
+}
+
+// NewProductCatalogCache creates an empty cache, loaded on first use
+func NewProductCatalogCache(repo *repositories.ProductIntegrationRepository, ttl time.Duration) *ProductCatalogCache {
+	if ttl <= 0 {
+		ttl = entities.DEFAULT_PRODUCT_CATALOG_CACHE_TTL_SECONDS * time.Second
+	}
+	return &ProductCatalogCache{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// SetTTL changes how long loaded data is reused; non positive values are ignored
+func (c *ProductCatalogCache) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	c.ttl = ttl
+	c.mu.Unlock()
+}
+
+// Invalidate discards the loaded data so the next lookup reloads it
+func (c *ProductCatalogCache) Invalidate() {
+	c.mu.Lock()
+	c.loadedAt = time.Time{}
+	c.mu.Unlock()
+}
+
+// NormalizeBrandName returns the key used to match brand names
+func (c *ProductCatalogCache) NormalizeBrandName(name string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(c.repo.RemoverCaracteresEspeciais(name))), " ")
+}
+
+// NormalizeIndustryName returns the key used to match industry names; trailing legal
+// suffixes such as LTDA or S/A are ignored
+func (c *ProductCatalogCache) NormalizeIndustryName(name string) string {
+	tokens := strings.Fields(strings.ToUpper(c.repo.RemoverCaracteresEspeciais(name)))
+	for len(tokens) > 1 {
+		last := tokens[len(tokens)-1]
+		switch {
+		case industryLegalSuffixes[last]:
+			tokens = tokens[:len(tokens)-1]
+		case len(tokens) > 2 && last == "A" && tokens[len(tokens)-2] == "S":
+			tokens = tokens[:len(tokens)-2]
+		default:
+			return strings.Join(tokens, " ")
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func (c *ProductCatalogCache) brandKey(brandName, industryName string) string {
+	return c.NormalizeBrandName(brandName) + "|" + c.NormalizeIndustryName(industryName)
+}
+
+// ensureLoaded loads every table when the cache is empty or expired
+func (c *ProductCatalogCache) ensureLoaded(ctx context.Context) error {
+	if c.fresh() {
+		return nil
+	}
+
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+	if c.fresh() {
+		// Loaded by another caller while this one waited
+		return nil
+	}
+	return c.load(ctx, true)
+}
+
+func (c *ProductCatalogCache) fresh() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.loadedAt.IsZero() && time.Since(c.loadedAt) < c.ttl
+}
+
+// refreshNames reloads brands and industries unless they were read after since, so the
+// misses of concurrent lookups share one reload
+func (c *ProductCatalogCache) refreshNames(ctx context.Context, since time.Time) error {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	c.mu.RLock()
+	loaded := c.namesLoadedAt
+	c.mu.RUnlock()
+	if loaded.After(since) {
+		return nil
+	}
+	return c.load(ctx, false)
+}
+
+// load reads brands, industries and, when withStructures is set, the marketing structure
+// and swaps them in. Callers hold loadMu.
+func (c *ProductCatalogCache) load(ctx context.Context, withStructures bool) error {
+	started := time.Now()
+
+	brands, err := c.repo.ListBrands(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar marcas: %w", err)
+	}
+	industries, err := c.repo.ListIndustries(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar indústrias: %w", err)
+	}
+
+	var tree *MarketingStructureTree
+	if withStructures {
+		structures, err := c.repo.ListMarketingStructures(ctx)
+		if err != nil {
+			return fmt.Errorf("erro ao carregar estrutura mercadológica: %w", err)
+		}
+		tree = NewMarketingStructureTree(structures)
+		if issues := tree.Validate(); len(issues) > 0 {
+			log.Printf("Estrutura mercadológica com %d problema(s) de hierarquia", len(issues))
+		}
+	}
+
+	brandsByKey := make(map[string][]entities.Brand, len(brands))
+	for _, brand := range brands {
+		key := c.brandKey(brand.NomeMarca, brand.NomeIndustria)
+		brandsByKey[key] = append(brandsByKey[key], brand)
+	}
+
+	industriesByKey := make(map[string][]entities.Industry, len(industries))
+	for _, industry := range industries {
+		key := c.NormalizeIndustryName(industry.NomeIndustria)
+		industriesByKey[key] = append(industriesByKey[key], industry)
+	}
+
+	c.mu.Lock()
+	c.brands = brandsByKey
+	c.industries = industriesByKey
+	c.namesLoadedAt = started
+	if withStructures {
+		c.tree = tree
+		c.loadedAt = started
+	}
+	c.mu.Unlock()
+
+	if withStructures {
+		log.Printf("Cache de catálogo carregado: %d marcas, %d indústrias, %d estruturas mercadológicas",
+			len(brands), len(industries), tree.Len())
+	}
+	return nil
+}
+
+// FindBrand returns the most recent brand matching brandName within industryName, or nil.
+// A miss reloads brands and industries before giving up.
+func (c *ProductCatalogCache) FindBrand(ctx context.Context, brandName, industryName string) (*entities.Brand, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	checked := time.Now()
+	if brand := c.cachedBrand(brandName, industryName); brand != nil {
+		return brand, nil
+	}
+	if err := c.refreshNames(ctx, checked); err != nil {
+		return nil, err
+	}
+	return c.cachedBrand(brandName, industryName), nil
+}
+
+func (c *ProductCatalogCache) cachedBrand(brandName, industryName string) *entities.Brand {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	matches := c.brands[c.brandKey(brandName, industryName)]
+	if len(matches) == 0 {
+		return nil
+	}
+	brand := matches[len(matches)-1]
+	return &brand
+}
+
+// FindIndustry returns the first industry matching name with the given status, or nil.
+// A miss reloads brands and industries before giving up.
+func (c *ProductCatalogCache) FindIndustry(ctx context.Context, name string, status int) (*entities.Industry, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	checked := time.Now()
+	if industry := c.cachedIndustry(name, status); industry != nil {
+		return industry, nil
+	}
+	if err := c.refreshNames(ctx, checked); err != nil {
+		return nil, err
+	}
+	return c.cachedIndustry(name, status), nil
+}
+
+func (c *ProductCatalogCache) cachedIndustry(name string, status int) *entities.Industry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, industry := range c.industries[c.NormalizeIndustryName(name)] {
+		if industry.StatusIndustria == status {
+			found := industry
+			return &found
+		}
+	}
+	return nil
+}
+
+// GetMarketingStructure returns the ESTRUTURA_MERCADOLOGICA row with the given ID, or nil
+func (c *ProductCatalogCache) GetMarketingStructure(ctx context.Context, id int) (*entities.MarketingStructure, error) {
+	tree, err := c.MarketingStructureTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Get(id), nil
+}
+
+// MarketingStructureTree returns the loaded marketing structure hierarchy. The tree is
+// never modified after loading, so it can be used without holding the cache lock.
+func (c *ProductCatalogCache) MarketingStructureTree(ctx context.Context) (*MarketingStructureTree, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tree, nil
+}
+
+// AddBrand registers a brand committed during import
+func (c *ProductCatalogCache) AddBrand(brand entities.Brand) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.brands == nil {
+		return
+	}
+	key := c.brandKey(brand.NomeMarca, brand.NomeIndustria)
+	c.brands[key] = append(c.brands[key], brand)
+}
+
+// AddIndustry registers an industry committed during import
+func (c *ProductCatalogCache) AddIndustry(industry entities.Industry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.industries == nil {
+		return
+	}
+	key := c.NormalizeIndustryName(industry.NomeIndustria)
+	c.industries[key] = append(c.industries[key], industry)
+}
+
+// Changes starts tracking the brands and industries created in one row transaction
+func (c *ProductCatalogCache) Changes() *CatalogChanges {
+	return &CatalogChanges{cache: c}
+}
+
+// CatalogChanges holds the brands and industries created in a row transaction. Lookups see
+// them before the shared cache, which only receives them through Publish once the
+// transaction commits, so other workers never use an uncommitted ID_MARCA or ID_INDUSTRIA.
+type CatalogChanges struct {
+	cache      *ProductCatalogCache
+	brands     []entities.Brand
+	industries []entities.Industry
+}
+
+// FindBrand returns the brand created in the transaction or, failing that, the cached one
+func (t *CatalogChanges) FindBrand(ctx context.Context, brandName, industryName string) (*entities.Brand, error) {
+	key := t.cache.brandKey(brandName, industryName)
+	for i := len(t.brands) - 1; i >= 0; i-- {
+		if t.cache.brandKey(t.brands[i].NomeMarca, t.brands[i].NomeIndustria) == key {
+			brand := t.brands[i]
+			return &brand, nil
+		}
+	}
+	return t.cache.FindBrand(ctx, brandName, industryName)
+}
+
+// FindIndustry returns the industry created in the transaction or, failing that, the cached one
+func (t *CatalogChanges) FindIndustry(ctx context.Context, name string, status int) (*entities.Industry, error) {
+	key := t.cache.NormalizeIndustryName(name)
+	for _, industry := range t.industries {
+		if t.cache.NormalizeIndustryName(industry.NomeIndustria) == key && industry.StatusIndustria == status {
+			found := industry
+			return &found, nil
+		}
+	}
+	return t.cache.FindIndustry(ctx, name, status)
+}
+
+// AddBrand records a brand created in the transaction
+func (t *CatalogChanges) AddBrand(brand entities.Brand) {
+	t.brands = append(t.brands, brand)
+}
+
+// AddIndustry records an industry created in the transaction
+func (t *CatalogChanges) AddIndustry(industry entities.Industry) {
+	t.industries = append(t.industries, industry)
+}
+
+// Discard forgets the changes of a rolled back transaction
+func (t *CatalogChanges) Discard() {
+	t.brands, t.industries = nil, nil
+}
+
+// Publish adds the changes of the committed transaction to the shared cache
+func (t *CatalogChanges) Publish() {
+	for _, industry := range t.industries {
+		t.cache.AddIndustry(industry)
+	}
+	for _, brand := range t.brands {
+		t.cache.AddBrand(brand)
+	}
+	t.Discard()
+}
+
+// DuplicateReport lists brands and industries whose names normalize to the same key
+func (c *ProductCatalogCache) DuplicateReport(ctx context.Context) (*entities.CatalogDuplicateReport, error) {
+	if err := c.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := &entities.CatalogDuplicateReport{
+		GeradoEm:   time.Now(),
+		Marcas:     []entities.CatalogDuplicateGroup{},
+		Industrias: []entities.CatalogDuplicateGroup{},
+	}
+
+	for key, brands := range c.brands {
+		if len(brands) < 2 {
+			continue
+		}
+		group := entities.CatalogDuplicateGroup{Chave: key}
+		for _, brand := range brands {
+			group.Ids = append(group.Ids, getIntValue(brand.IdMarca))
+			group.Nomes = append(group.Nomes, brand.NomeMarca+" ("+brand.NomeIndustria+")")
+		}
+		report.Marcas = append(report.Marcas, group)
+	}
+
+	for key, industries := range c.industries {
+		if len(industries) < 2 {
+			continue
+		}
+		group := entities.CatalogDuplicateGroup{Chave: key}
+		for _, industry := range industries {
+			group.Ids = append(group.Ids, getIntValue(industry.IdIndustria))
+			group.Nomes = append(group.Nomes, industry.NomeIndustria)
+		}
+		report.Industrias = append(report.Industrias, group)
+	}
+
+	sort.Slice(report.Marcas, func(i, j int) bool { return report.Marcas[i].Chave < report.Marcas[j].Chave })
+	sort.Slice(report.Industrias, func(i, j int) bool { return report.Industrias[i].Chave < report.Industrias[j].Chave })
+
+	return report, nil
+}
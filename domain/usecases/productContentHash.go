@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+)
+
+// canonicalProduct is the normalized form of a ProductSelectIntegration used for hashing:
+// strings are trimmed with inner whitespace collapsed and barcode/packaging lists are sorted,
+// so RMS resending the same product in a different order hashes the same
+type canonicalProduct struct {
+	Desc      string                  `json:"desc"`
+	DescEcf   string                  `json:"descEcf"`
+	PitStop   string                  `json:"pitstop"`
+	Subclasse string                  `json:"subclasse"`
+	Nivel1    string                  `json:"nivel1"`
+	Depto     string                  `json:"depto"`
+	CodRMS    string                  `json:"codrms"`
+	Status    string                  `json:"status"`
+	DescMarca string                  `json:"descMarca"`
+	Ind       string                  `json:"ind"`
+	Pesavel   string                  `json:"pesavel"`
+	CodBarras []entities.CodigoBarras `json:"codBarras"`
+	Embalagem []entities.Embalagem    `json:"embalagem"`
+}
+
+// productContentHash returns the SHA-256 of the normalized product; pesavel is the
+// message level flag, folded into the product so either source changes the hash
+func productContentHash(produtoSelect entities.ProductSelectIntegration, pesavel string) string {
+	canonical := canonicalProduct{
+		Desc:      normalizeHashText(produtoSelect.Desc),
+		DescEcf:   normalizeHashText(produtoSelect.DescEcf),
+		PitStop:   normalizeHashText(produtoSelect.PitStop),
+		Subclasse: normalizeHashText(produtoSelect.Subclasse),
+		Nivel1:    normalizeHashText(produtoSelect.Nivel1),
+		Depto:     normalizeHashText(produtoSelect.Depto),
+		CodRMS:    normalizeHashText(produtoSelect.CodRMS),
+		Status:    normalizeHashText(produtoSelect.Status),
+		DescMarca: normalizeHashText(produtoSelect.DescMarca),
+		Ind:       normalizeHashText(produtoSelect.Ind),
+		Pesavel:   normalizeHashText(produtoSelect.Pesavel) + "|" + normalizeHashText(pesavel),
+	}
+
+	for _, cbarra := range produtoSelect.CodBarras {
+		canonical.CodBarras = append(canonical.CodBarras, entities.CodigoBarras{
+			CBarra: normalizeHashText(cbarra.CBarra),
+			Princ:  normalizeHashText(cbarra.Princ),
+			Tipo:   normalizeHashText(cbarra.Tipo),
+		})
+	}
+	sort.Slice(canonical.CodBarras, func(i, j int) bool {
+		return canonical.CodBarras[i].CBarra < canonical.CodBarras[j].CBarra
+	})
+
+	for _, embalagem := range produtoSelect.Embalagem {
+		canonical.Embalagem = append(canonical.Embalagem, entities.Embalagem{
+			EAN:  normalizeHashText(embalagem.EAN),
+			Qtde: normalizeHashText(embalagem.Qtde),
+		})
+	}
+	sort.Slice(canonical.Embalagem, func(i, j int) bool {
+		if canonical.Embalagem[i].EAN != canonical.Embalagem[j].EAN {
+			return canonical.Embalagem[i].EAN < canonical.Embalagem[j].EAN
+		}
+		return canonical.Embalagem[i].Qtde < canonical.Embalagem[j].Qtde
+	})
+
+	data, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeHashText trims and collapses whitespace
+func normalizeHashText(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// productContentHashes returns the content hash of every product of the message by CODIGO_RMS
+func productContentHashes(produto entities.ProductInJson) map[int]string {
+	hashes := make(map[int]string, len(produto.ProdutosSelect))
+	for _, produtoSelect := range produto.ProdutosSelect {
+		codigoRMS, err := strconv.Atoi(strings.TrimSpace(produtoSelect.CodRMS))
+		if err != nil {
+			continue
+		}
+		hashes[codigoRMS] = productContentHash(produtoSelect, produto.Pesavel)
+	}
+	return hashes
+}
+
+// isUnchangedPayload reports whether every product of the message matches its stored hash
+func (uc *ProductIntegrationUseCase) isUnchangedPayload(ctx context.Context, hashes map[int]string) bool {
+	if len(hashes) == 0 {
+		return false
+	}
+
+	codigos := make([]int, 0, len(hashes))
+	for codigo := range hashes {
+		codigos = append(codigos, codigo)
+	}
+
+	stored, err := uc.repo.GetProductContentHashes(ctx, codigos)
+	if err != nil {
+		// Without the stored hashes the payload is integrated as usual
+		log.Printf("Erro ao consultar hash de conteúdo dos produtos: %v", err)
+		return false
+	}
+
+	for codigo, hash := range hashes {
+		if stored[codigo] != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// saveContentHashes stores the hashes of a successfully integrated message through repo
+func (uc *ProductIntegrationUseCase) saveContentHashes(ctx context.Context, repo *repositories.ProductIntegrationRepository, hashes map[int]string) {
+	for codigo, hash := range hashes {
+		if err := repo.SaveProductContentHash(ctx, codigo, hash); err != nil {
+			log.Printf("Erro ao gravar hash de conteúdo do produto RMS %d: %v", codigo, err)
+		}
+	}
+}
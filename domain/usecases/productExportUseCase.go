@@ -0,0 +1,530 @@
+package usecases
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+	"github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
+)
+
+// ProductExportUseCase builds JsonProductSegment documents from PRODUTO and writes them
+// to an NDJSON file or publishes them to an exchange
+type ProductExportUseCase struct {
+	repo          *repositories.ProductIntegrationRepository
+	parameterRepo entities.ParameterRepository
+	catalog       *ProductCatalogCache
+	publisher     broker.Publisher
+
+	exportDir   string
+	exchange    string
+	destination string
+	pageSize    int
+}
+
+// NewProductExportUseCase creates a new instance of ProductExportUseCase
+func NewProductExportUseCase(
+	repo *repositories.ProductIntegrationRepository,
+	parameterRepo entities.ParameterRepository,
+	rabbitmqURL string,
+) *ProductExportUseCase {
+	return &ProductExportUseCase{
+		repo:          repo,
+		parameterRepo: parameterRepo,
+		catalog:       NewProductCatalogCache(repo, 0),
+		publisher:     rabbitmq.NewAMQPBroker(rabbitmqURL),
+		exportDir:     entities.DEFAULT_PRODUCT_EXPORT_DIR,
+		exchange:      entities.DEFAULT_PRODUCT_EXPORT_EXCHANGE,
+		destination:   entities.PRODUCT_EXPORT_DESTINATION_FILE,
+		pageSize:      entities.DEFAULT_PRODUCT_EXPORT_PAGE_SIZE,
+	}
+}
+
+// SetExportDir sets the directory where NDJSON files are written
+func (uc *ProductExportUseCase) SetExportDir(dir string) {
+	if dir != "" {
+		uc.exportDir = dir
+	}
+}
+
+// SetBroker replaces the broker segments are published to
+func (uc *ProductExportUseCase) SetBroker(publisher broker.Publisher) {
+	if publisher != nil {
+		uc.publisher = publisher
+	}
+}
+
+// SetExchange sets the exchange segments are published to
+func (uc *ProductExportUseCase) SetExchange(exchange string) {
+	if exchange != "" {
+		uc.exchange = exchange
+	}
+}
+
+// SetDestination sets the destination used when the request does not inform one
+func (uc *ProductExportUseCase) SetDestination(destination string) {
+	if destination != "" {
+		uc.destination = destination
+	}
+}
+
+// SetPageSize sets the number of products read per page
+func (uc *ProductExportUseCase) SetPageSize(pageSize int) {
+	if pageSize > 0 {
+		uc.pageSize = pageSize
+	}
+}
+
+// SetCatalogCacheTTL sets how long the marketing structure used by the export is reused
+func (uc *ProductExportUseCase) SetCatalogCacheTTL(ttl time.Duration) {
+	uc.catalog.SetTTL(ttl)
+}
+
+// InvalidateCatalogCache forces the marketing structure to be reloaded on the next export
+func (uc *ProductExportUseCase) InvalidateCatalogCache() {
+	uc.catalog.Invalidate()
+}
+
+// ExportProducts exports the products changed since the last successful unscoped run.
+// Full runs and runs restricted to products, RMS codes or dealers ignore the watermark.
+func (uc *ProductExportUseCase) ExportProducts(ctx context.Context, opts entities.ProductExportOptions) (*entities.ProductExportResult, error) {
+	result := &entities.ProductExportResult{Mode: entities.PRODUCT_EXPORT_MODE_INCREMENTAL}
+	if opts.Full || opts.IsScoped() {
+		result.Mode = entities.PRODUCT_EXPORT_MODE_FULL
+	}
+
+	result.Destino = strings.ToLower(strings.TrimSpace(opts.Destino))
+	if result.Destino == "" {
+		result.Destino = uc.destination
+	}
+
+	// Taken before reading so changes made during the run are picked up by the next one
+	runStartedAt, err := uc.repo.GetDatabaseTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter data do banco: %w", err)
+	}
+
+	filter := entities.ProductExportFilter{
+		IdProdutos:     opts.IdProdutos,
+		CodigosRMS:     opts.CodigosRMS,
+		IdRevendedores: opts.IdRevendedores,
+	}
+	if result.Mode == entities.PRODUCT_EXPORT_MODE_INCREMENTAL {
+		filter.ChangedSince, err = uc.getWatermark(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Watermark = filter.ChangedSince
+
+	tree, err := uc.catalog.MarketingStructureTree(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter estrutura mercadológica: %w", err)
+	}
+
+	sink, err := uc.openSink(result)
+	if err != nil {
+		return nil, err
+	}
+	// Only a run that reaches the end publishes what it wrote; any other exit aborts the sink
+	completed := false
+	defer func() {
+		if !completed {
+			sink.Abort()
+		}
+	}()
+
+	pageSize := uc.pageSize
+	if opts.PageSize > 0 {
+		pageSize = opts.PageSize
+	}
+
+	if filter.ChangedSince != nil {
+		log.Printf("Exportação incremental de produtos - alterados desde %s, destino %s", filter.ChangedSince.Format(time.RFC3339), result.Destino)
+	} else {
+		log.Printf("Exportação completa de produtos - destino %s", result.Destino)
+	}
+	if opts.IsScoped() {
+		log.Printf("Exportação restrita - produtos: %v, códigos RMS: %v, revendedores: %v", opts.IdProdutos, opts.CodigosRMS, opts.IdRevendedores)
+	}
+
+	lookups := newProductExportLookups(uc.repo)
+	lastID := 0
+	for {
+		refs, err := uc.repo.GetProductExportPage(ctx, lastID, pageSize, filter)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao obter produtos para exportação: %w", err)
+		}
+		if len(refs) == 0 {
+			break
+		}
+		result.PagesRead++
+
+		for _, ref := range refs {
+			segment, err := uc.buildSegment(ctx, ref, tree, lookups)
+			if err == nil {
+				err = sink.Write(*segment)
+			}
+			if err != nil {
+				log.Printf("Erro ao exportar produto %d: %v", ref.IdProduto, err)
+				result.FailedCount++
+				continue
+			}
+			result.ExportedCount++
+		}
+
+		lastID = refs[len(refs)-1].IdProduto
+		if len(refs) < pageSize {
+			break
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		return nil, fmt.Errorf("erro ao finalizar exportação: %w", err)
+	}
+	completed = true
+
+	// Products that failed keep the previous watermark so they are exported again.
+	// Scoped runs do not cover the whole table and never move it.
+	if opts.IsScoped() {
+		log.Printf("Marca d'água mantida: exportação restrita")
+	} else if result.FailedCount == 0 {
+		if err := uc.setWatermark(ctx, runStartedAt); err != nil {
+			log.Printf("Erro ao gravar marca d'água da exportação de produtos: %v", err)
+		}
+	} else {
+		log.Printf("Marca d'água mantida: %d produtos com erro serão exportados novamente", result.FailedCount)
+	}
+
+	result.Success = result.FailedCount == 0
+	result.Message = fmt.Sprintf("Exportação concluída. Total exportados: %d, Total com erro: %d", result.ExportedCount, result.FailedCount)
+	log.Printf("%s, Páginas: %d", result.Message, result.PagesRead)
+
+	return result, nil
+}
+
+// buildSegment loads a product with its packagings and resolves the descriptive names
+func (uc *ProductExportUseCase) buildSegment(ctx context.Context, ref entities.ProductExportRef, tree *MarketingStructureTree, lookups *productExportLookups) (*entities.JsonProductSegment, error) {
+	product, err := uc.repo.GetProductByID(ctx, ref.IdProduto)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, fmt.Errorf("%s", entities.MSG_IMPORT_PRODUCT_NOT_FOUND)
+	}
+
+	segment := &entities.JsonProductSegment{
+		Desc:      product.DescricaoProduto,
+		DescEcf:   product.DescricaoCupom,
+		Nivel1:    getIntValue(product.IdNivel1EstrMerc),
+		Nivel2:    getIntValue(product.IdNivel2EstrMerc),
+		Nivel3:    getIntValue(product.IdNivel3EstrMerc),
+		Nivel4:    getIntValue(product.IdEstruturaMercadologica),
+		MarkUp:    product.MarkUp,
+		Status:    entities.CONST_INATIVO_I,
+		Producao:  getIntValue(product.Producao),
+		CodBarras: []entities.JsonCodigoBarras{},
+	}
+	if product.CodigoRMS != nil {
+		segment.Cod = strconv.Itoa(*product.CodigoRMS)
+	}
+	if product.Ativo == entities.CONST_ATIVO {
+		segment.Status = entities.CONST_ATIVO_A
+	}
+	if ref.DataAtualizacao != nil {
+		segment.DtAlt = *ref.DataAtualizacao
+	}
+
+	if segment.DescMarca, err = lookups.brand(ctx, product.IdMarca); err != nil {
+		return nil, err
+	}
+
+	if product.IdUnidadeMedida != nil {
+		unit, err := lookups.unit(ctx, *product.IdUnidadeMedida)
+		if err != nil {
+			return nil, err
+		}
+		if unit != nil {
+			segment.UnidMed = unit.CodigoUnidadeMedida
+			segment.DescUnidMed = unit.DescricaoUnidadeMedida
+		}
+	}
+
+	// Department belongs to level 2 and section to level 3 of the marketing structure
+	var idDepartamento, idSecao *int
+	if node := tree.Get(segment.Nivel2); node != nil {
+		idDepartamento = node.IdDepartamento
+	}
+	if node := tree.Get(segment.Nivel3); node != nil {
+		idSecao = node.IdSecao
+	}
+	if segment.Depto, err = lookups.department(ctx, idDepartamento); err != nil {
+		return nil, err
+	}
+	if segment.NmSecao, err = lookups.section(ctx, idSecao); err != nil {
+		return nil, err
+	}
+
+	packagings, err := uc.repo.GetProductPackagingsByProductID(ctx, ref.IdProduto)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packagings {
+		segment.CodBarras = append(segment.CodBarras, entities.JsonCodigoBarras{EAN: pkg.CodigoBarras})
+		if pkg.Principal && segment.CodBarrasPrincipal == "" {
+			segment.CodBarrasPrincipal = pkg.CodigoBarras
+		}
+	}
+
+	return segment, nil
+}
+
+// getWatermark returns the start time of the last successful export, or nil when there is none
+func (uc *ProductExportUseCase) getWatermark(ctx context.Context) (*time.Time, error) {
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, entities.PARAM_PRODUTO_EXPORTACAO_ULTIMA_EXECUCAO)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter marca d'água: %w", err)
+	}
+	if param == nil || param.Valor == "" {
+		return nil, nil
+	}
+
+	watermark, err := time.Parse(time.RFC3339Nano, param.Valor)
+	if err != nil {
+		log.Printf("Marca d'água inválida '%s', executando exportação completa", param.Valor)
+		return nil, nil
+	}
+	return &watermark, nil
+}
+
+// setWatermark stores the start time of a successful export
+func (uc *ProductExportUseCase) setWatermark(ctx context.Context, runStartedAt time.Time) error {
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, entities.PARAM_PRODUTO_EXPORTACAO_ULTIMA_EXECUCAO)
+	if err != nil {
+		return err
+	}
+
+	valor := runStartedAt.Format(time.RFC3339Nano)
+	if param == nil {
+		_, err = uc.parameterRepo.Create(ctx, &entities.IParameter{
+			Ambiente:  "*",
+			Codigo:    entities.PARAM_PRODUTO_EXPORTACAO_ULTIMA_EXECUCAO,
+			Valor:     valor,
+			Descricao: "Início da última exportação de produtos concluída sem erros",
+		})
+		return err
+	}
+
+	param.Valor = valor
+	return uc.parameterRepo.Update(ctx, param)
+}
+
+// openSink opens the destination selected for the run
+func (uc *ProductExportUseCase) openSink(result *entities.ProductExportResult) (productExportSink, error) {
+	switch result.Destino {
+	case entities.PRODUCT_EXPORT_DESTINATION_FILE:
+		if err := os.MkdirAll(uc.exportDir, 0o755); err != nil {
+			return nil, fmt.Errorf("erro ao criar diretório de exportação: %w", err)
+		}
+		result.Arquivo = filepath.Join(uc.exportDir,
+			fmt.Sprintf("produtos_%s_%s.ndjson", result.Mode, time.Now().Format("20060102T150405")))
+		return newProductExportFileSink(result.Arquivo)
+
+	case entities.PRODUCT_EXPORT_DESTINATION_EXCHANGE:
+		return newProductExportExchangeSink(uc.publisher, uc.exchange, result.Mode)
+
+	default:
+		return nil, fmt.Errorf("destino de exportação desconhecido: %s", result.Destino)
+	}
+}
+
+// productExportSink receives the segments of an export run. Close publishes the run;
+// Abort discards what can still be discarded after a failure.
+type productExportSink interface {
+	Write(segment entities.JsonProductSegment) error
+	Close() error
+	Abort()
+}
+
+// productExportFileSink writes one segment per line. The file is written with a .part
+// suffix and renamed on Close so readers never see a partial export.
+type productExportFileSink struct {
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func newProductExportFileSink(path string) (*productExportFileSink, error) {
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de exportação: %w", err)
+	}
+	return &productExportFileSink{path: path, file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (s *productExportFileSink) Write(segment entities.JsonProductSegment) error {
+	line, err := json.Marshal(segment)
+	if err != nil {
+		return err
+	}
+	if _, err := s.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("erro ao gravar arquivo de exportação: %w", err)
+	}
+	return nil
+}
+
+func (s *productExportFileSink) Close() error {
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(s.path+".part", s.path)
+}
+
+// Abort removes the .part file, leaving any previous export at path untouched
+func (s *productExportFileSink) Abort() {
+	s.file.Close()
+	if err := os.Remove(s.path + ".part"); err != nil && !os.IsNotExist(err) {
+		log.Printf("Erro ao remover exportação parcial %s.part: %v", s.path, err)
+	}
+}
+
+// productExportExchangeSink publishes one persistent message per segment to a topic
+// exchange, routed by "produto.<mode>"
+type productExportExchangeSink struct {
+	publisher  broker.Publisher
+	exchange   string
+	routingKey string
+}
+
+func newProductExportExchangeSink(publisher broker.Publisher, exchange, mode string) (*productExportExchangeSink, error) {
+	if err := publisher.DeclareExchange(exchange, "topic"); err != nil {
+		return nil, fmt.Errorf("erro ao declarar exchange %s: %w", exchange, err)
+	}
+
+	return &productExportExchangeSink{publisher: publisher, exchange: exchange, routingKey: "produto." + mode}, nil
+}
+
+func (s *productExportExchangeSink) Write(segment entities.JsonProductSegment) error {
+	body, err := json.Marshal(segment)
+	if err != nil {
+		return err
+	}
+
+	err = s.publisher.Publish(context.Background(), s.exchange, s.routingKey, broker.Message{
+		ContentType: "application/json",
+		Persistent:  true,
+		Type:        "produto_exportacao",
+		Body:        body,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao publicar produto %s: %w", segment.Cod, err)
+	}
+	return nil
+}
+
+// Close is a no-op: the publisher pools its channels
+func (s *productExportExchangeSink) Close() error {
+	return nil
+}
+
+// Abort is a no-op: published segments cannot be withdrawn, and consumers apply each one
+// on its own
+func (s *productExportExchangeSink) Abort() {}
+
+// productExportLookups memoizes the name lookups of a single export run
+type productExportLookups struct {
+	repo        *repositories.ProductIntegrationRepository
+	brands      map[int]string
+	departments map[int]string
+	sections    map[int]string
+	units       map[int]*entities.UnitOfMeasurement
+}
+
+func newProductExportLookups(repo *repositories.ProductIntegrationRepository) *productExportLookups {
+	return &productExportLookups{
+		repo:        repo,
+		brands:      map[int]string{},
+		departments: map[int]string{},
+		sections:    map[int]string{},
+		units:       map[int]*entities.UnitOfMeasurement{},
+	}
+}
+
+func (l *productExportLookups) brand(ctx context.Context, id *int) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	if name, ok := l.brands[*id]; ok {
+		return name, nil
+	}
+	brands, err := l.repo.GetBrandDescByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	name := ""
+	if len(brands) > 0 {
+		name = brands[0].NomeMarca
+	}
+	l.brands[*id] = name
+	return name, nil
+}
+
+func (l *productExportLookups) department(ctx context.Context, id *int) (string, error) {
+	if id == nil {
+		return l.repo.GetDepartmentNameByID(ctx, nil)
+	}
+	if name, ok := l.departments[*id]; ok {
+		return name, nil
+	}
+	name, err := l.repo.GetDepartmentNameByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	l.departments[*id] = name
+	return name, nil
+}
+
+func (l *productExportLookups) section(ctx context.Context, id *int) (string, error) {
+	if id == nil {
+		section, err := l.repo.GetSectionNameByID(ctx, nil)
+		if err != nil {
+			return "", err
+		}
+		return section.NomeSecao, nil
+	}
+	if name, ok := l.sections[*id]; ok {
+		return name, nil
+	}
+	section, err := l.repo.GetSectionNameByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	l.sections[*id] = section.NomeSecao
+	return section.NomeSecao, nil
+}
+
+func (l *productExportLookups) unit(ctx context.Context, id int) (*entities.UnitOfMeasurement, error) {
+	if unit, ok := l.units[id]; ok {
+		return unit, nil
+	}
+	unit, err := l.repo.GetUnitOfMeasurementByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	l.units[id] = unit
+	return unit, nil
+}
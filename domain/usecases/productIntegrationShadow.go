@@ -0,0 +1,207 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/repositories"
+)
+
+// buildShadowExpectation computes what the Go pipeline would write for produto
+// without touching the database: brands and industries are only looked up.
+func (uc *ProductIntegrationUseCase) buildShadowExpectation(ctx context.Context, produto entities.ProductInJson) ([]*entities.ProductNew, error) {
+	var expected []*entities.ProductNew
+
+	for _, produtoSelect := range produto.ProdutosSelect {
+		newProduct, err := uc.createNewProductFromSelect(produtoSelect, produto.Pesavel)
+		if err != nil {
+			return nil, fmt.Errorf("error creating new product: %w", err)
+		}
+		if newProduct.CodigoRMS == nil {
+			return nil, fmt.Errorf("código RMS inválido: %q", produtoSelect.CodRMS)
+		}
+
+		if validation := uc.validateMarketingStructure(ctx, uc.repo, newProduct); !validation.Success {
+			return nil, fmt.Errorf("%s", validation.Message)
+		}
+
+		brand, err := uc.catalog.FindBrand(ctx, produtoSelect.DescMarca, produtoSelect.Ind)
+		if err != nil {
+			return nil, fmt.Errorf("error getting brand: %w", err)
+		}
+		if brand != nil {
+			newProduct.IdMarca = brand.IdMarca
+		}
+
+		uc.processBarcodesAndPackaging(newProduct, produtoSelect, produto.Pesavel)
+		expected = append(expected, newProduct)
+	}
+
+	return expected, nil
+}
+
+// runShadowComparison reads back, through repo, what the PL/SQL package wrote and appends
+// the field-level differences to the shadow report. Failures never affect the integration.
+func (uc *ProductIntegrationUseCase) runShadowComparison(ctx context.Context, repo *repositories.ProductIntegrationRepository, rms entities.IntegrRmsProductIn, expected []*entities.ProductNew, expectationErr error, plsqlSuccess bool) {
+	report := entities.ProductShadowReport{
+		IprID:        rms.IprID,
+		ComparadoEm:  time.Now(),
+		SucessoPlsql: plsqlSuccess,
+		Divergencias: []entities.ProductShadowDiscrepancy{},
+	}
+
+	if expectationErr != nil {
+		report.ErroComparacao = fmt.Sprintf("pipeline Go rejeitaria o produto: %v", expectationErr)
+	} else {
+		for _, product := range expected {
+			report.CodigoRMS = append(report.CodigoRMS, *product.CodigoRMS)
+			discrepancies, err := uc.compareShadowProduct(ctx, repo, product)
+			if err != nil {
+				report.ErroComparacao = err.Error()
+				break
+			}
+			report.Divergencias = append(report.Divergencias, discrepancies...)
+		}
+	}
+
+	if len(report.Divergencias) > 0 || report.ErroComparacao != "" {
+		log.Printf("Shadow produto IPR_ID %v: %d divergência(s) %s", formatIntPtr(rms.IprID), len(report.Divergencias), report.ErroComparacao)
+	}
+
+	if err := uc.writeShadowReport(report); err != nil {
+		log.Printf("Erro ao gravar relatório shadow de produtos: %v", err)
+	}
+}
+
+// compareShadowProduct compares one expected product against PRODUTO and EMBALAGEM_PRODUTO
+func (uc *ProductIntegrationUseCase) compareShadowProduct(ctx context.Context, repo *repositories.ProductIntegrationRepository, expected *entities.ProductNew) ([]entities.ProductShadowDiscrepancy, error) {
+	key := strconv.Itoa(*expected.CodigoRMS)
+
+	actual, err := repo.GetProductByCodeRMS(ctx, *expected.CodigoRMS)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler produto %s: %w", key, err)
+	}
+	if actual == nil {
+		return []entities.ProductShadowDiscrepancy{{
+			Tabela: "PRODUTO", Chave: key, Campo: "CODIGO_RMS",
+			Esperado: key, Atual: "<ausente>",
+		}}, nil
+	}
+
+	var diffs []entities.ProductShadowDiscrepancy
+	add := func(tabela, chave, campo, esperado, atual string) {
+		if esperado != atual {
+			diffs = append(diffs, entities.ProductShadowDiscrepancy{
+				Tabela: tabela, Chave: chave, Campo: campo, Esperado: esperado, Atual: atual,
+			})
+		}
+	}
+
+	ativo := 0
+	if expected.Ativo {
+		ativo = 1
+	}
+	add("PRODUTO", key, "DESCRICAO_PRODUTO", expected.DescricaoProduto, actual.DescricaoProduto)
+	add("PRODUTO", key, "DESCRICAO_CUPOM", expected.DescricaoCupom, actual.DescricaoCupom)
+	add("PRODUTO", key, "ATIVO", strconv.Itoa(ativo), strconv.Itoa(actual.Ativo))
+	add("PRODUTO", key, "PITSTOP", strconv.Itoa(expected.PitStop), formatIntPtr(actual.PitStop))
+	add("PRODUTO", key, "ID_ESTRUTURA_MERCADOLOGICA", formatIntPtr(expected.IdEstruturaMercadologica), formatIntPtr(actual.IdEstruturaMercadologica))
+	add("PRODUTO", key, "ID_NIVEL1_ESTR_MERC", formatIntPtr(expected.IdNivel1EstrMerc), formatIntPtr(actual.IdNivel1EstrMerc))
+	add("PRODUTO", key, "ID_NIVEL2_ESTR_MERC", formatIntPtr(expected.IdNivel2EstrMerc), formatIntPtr(actual.IdNivel2EstrMerc))
+	add("PRODUTO", key, "ID_NIVEL3_ESTR_MERC", formatIntPtr(expected.IdNivel3EstrMerc), formatIntPtr(actual.IdNivel3EstrMerc))
+	add("PRODUTO", key, "ID_UNIDADE_MEDIDA", formatIntPtr(expected.IdUnidadeMedida), formatIntPtr(actual.IdUnidadeMedida))
+	add("PRODUTO", key, "INDUSTRIA", strings.ToUpper(expected.Industria), strings.ToUpper(actual.Industria))
+
+	// A brand missing before the package ran is created by it, so compare by id when
+	// the Go lookup found one and only report the missing brand otherwise
+	if expected.IdMarca != nil {
+		add("PRODUTO", key, "ID_MARCA", formatIntPtr(expected.IdMarca), formatIntPtr(actual.IdMarca))
+	} else if actual.IdMarca == nil {
+		add("PRODUTO", key, "ID_MARCA", "<nova marca>", "<ausente>")
+	}
+
+	if actual.IdProduto == nil {
+		return diffs, nil
+	}
+
+	packagings, err := repo.GetProductPackagingsByProductID(ctx, *actual.IdProduto)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler embalagens do produto %s: %w", key, err)
+	}
+
+	actualByBarcode := make(map[string]entities.ProductPackaging, len(packagings))
+	for _, pkg := range packagings {
+		actualByBarcode[pkg.CodigoBarras] = pkg
+	}
+
+	seen := make(map[string]bool, len(expected.Embalagens))
+	for _, want := range expected.Embalagens {
+		if want.CodigoBarras == "" {
+			continue
+		}
+		seen[want.CodigoBarras] = true
+		pkgKey := key + "/" + want.CodigoBarras
+
+		got, ok := actualByBarcode[want.CodigoBarras]
+		if !ok {
+			add("EMBALAGEM_PRODUTO", pkgKey, "CODIGO_BARRAS", want.CodigoBarras, "<ausente>")
+			continue
+		}
+		add("EMBALAGEM_PRODUTO", pkgKey, "PRINCIPAL", strconv.FormatBool(want.Principal), strconv.FormatBool(got.Principal))
+		add("EMBALAGEM_PRODUTO", pkgKey, "QUANTIDADE_EMBALAGEM", strconv.Itoa(want.QuantidadeEmbalagem), strconv.Itoa(got.QuantidadeEmbalagem))
+		add("EMBALAGEM_PRODUTO", pkgKey, "ID_UNIDADE_MEDIDA", formatIntPtr(want.IdUnidadeMedida), formatIntPtr(got.IdUnidadeMedida))
+		add("EMBALAGEM_PRODUTO", pkgKey, "TIPO_CODIGO_BARRAS", want.TipoCodigoBarras, got.TipoCodigoBarras)
+	}
+
+	for _, pkg := range packagings {
+		if !seen[pkg.CodigoBarras] {
+			add("EMBALAGEM_PRODUTO", key+"/"+pkg.CodigoBarras, "CODIGO_BARRAS", "<ausente>", pkg.CodigoBarras)
+		}
+	}
+
+	return diffs, nil
+}
+
+// writeShadowReport appends report as one JSON line to the shadow report file
+func (uc *ProductIntegrationUseCase) writeShadowReport(report entities.ProductShadowReport) error {
+	line, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar relatório: %w", err)
+	}
+
+	uc.shadowMu.Lock()
+	defer uc.shadowMu.Unlock()
+
+	if dir := filepath.Dir(uc.shadowReportPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("erro ao criar diretório do relatório: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(uc.shadowReportPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir relatório %s: %w", uc.shadowReportPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("erro ao gravar relatório %s: %w", uc.shadowReportPath, err)
+	}
+	return nil
+}
+
+// formatIntPtr formats an optional ID for the shadow report, "<nulo>" when it is nil
+func formatIntPtr(value *int) string {
+	if value == nil {
+		return "<nulo>"
+	}
+	return strconv.Itoa(*value)
+}
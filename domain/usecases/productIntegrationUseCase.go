@@ -1,45 +1,160 @@
 package usecases
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/thiagohmm/integracaocron/domain/entities"
 	"github.com/thiagohmm/integracaocron/domain/repositories"
 )
 
+// productUpsertSavepoint marks the start of the GO mode changes inside the row transaction
+const productUpsertSavepoint = "PRODUTO_UPSERT"
+
 // ProductIntegrationUseCase handles product integration business logic
 type ProductIntegrationUseCase struct {
-	repo *repositories.ProductIntegrationRepository
-	db   *sql.DB
+	repo             *repositories.ProductIntegrationRepository
+	parameterRepo    entities.ParameterRepository
+	db               *sql.DB
+	shadowReportPath string
+	shadowMu         sync.Mutex
+	catalog          *ProductCatalogCache
+	outbox           *LogOutbox
 }
 
 // NewProductIntegrationUseCase creates a new instance of ProductIntegrationUseCase
-func NewProductIntegrationUseCase(repo *repositories.ProductIntegrationRepository, db *sql.DB) *ProductIntegrationUseCase {
+func NewProductIntegrationUseCase(repo *repositories.ProductIntegrationRepository, parameterRepo entities.ParameterRepository, db *sql.DB) *ProductIntegrationUseCase {
 	return &ProductIntegrationUseCase{
-		repo: repo,
-		db:   db,
+		repo:             repo,
+		parameterRepo:    parameterRepo,
+		db:               db,
+		shadowReportPath: entities.DEFAULT_PRODUCT_SHADOW_REPORT_PATH,
+		catalog:          NewProductCatalogCache(repo, 0),
+	}
+}
+
+// SetLogOutbox makes LogIntegrRMS messages be written to the outbox in the row transaction
+func (uc *ProductIntegrationUseCase) SetLogOutbox(outbox *LogOutbox) {
+	uc.outbox = outbox
+}
+
+// SetCatalogCacheTTL sets how long brands, industries and marketing structures are cached
+func (uc *ProductIntegrationUseCase) SetCatalogCacheTTL(ttl time.Duration) {
+	uc.catalog.SetTTL(ttl)
+}
+
+// InvalidateCatalogCache forces the next product to reload brands, industries and marketing structures
+func (uc *ProductIntegrationUseCase) InvalidateCatalogCache() {
+	uc.catalog.Invalidate()
+}
+
+// CatalogDuplicateReport lists brands and industries suspected to be duplicates
+func (uc *ProductIntegrationUseCase) CatalogDuplicateReport(ctx context.Context) (*entities.CatalogDuplicateReport, error) {
+	return uc.catalog.DuplicateReport(ctx)
+}
+
+// SetShadowReportPath sets the file that receives shadow mode reports; empty keeps the default
+func (uc *ProductIntegrationUseCase) SetShadowReportPath(path string) {
+	if path != "" {
+		uc.shadowReportPath = path
+	}
+}
+
+// getIntegrationMode reads PRODUTO_INTEGRACAO_MODO, falling back to the PL/SQL package
+func (uc *ProductIntegrationUseCase) getIntegrationMode(ctx context.Context) string {
+	if uc.parameterRepo == nil {
+		return entities.PRODUCT_INTEGRATION_MODE_PLSQL
+	}
+
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, entities.PARAM_PRODUTO_INTEGRACAO_MODO)
+	if err != nil {
+		log.Printf("Erro ao obter modo de integração de produtos, usando %s: %v", entities.PRODUCT_INTEGRATION_MODE_PLSQL, err)
+		return entities.PRODUCT_INTEGRATION_MODE_PLSQL
+	}
+	if param == nil {
+		return entities.PRODUCT_INTEGRATION_MODE_PLSQL
+	}
+
+	switch mode := strings.ToUpper(strings.TrimSpace(param.Valor)); mode {
+	case entities.PRODUCT_INTEGRATION_MODE_GO, entities.PRODUCT_INTEGRATION_MODE_PLSQL, entities.PRODUCT_INTEGRATION_MODE_SHADOW:
+		return mode
+	default:
+		log.Printf("Modo de integração de produtos inválido '%s', usando %s", param.Valor, entities.PRODUCT_INTEGRATION_MODE_PLSQL)
+		return entities.PRODUCT_INTEGRATION_MODE_PLSQL
 	}
 }
 
 // ImportProductIntegration is the main function that imports product integrations
-func (uc *ProductIntegrationUseCase) ImportProductIntegration() (bool, error) {
-	log.Println("Starting product integration import process")
+func (uc *ProductIntegrationUseCase) ImportProductIntegration(ctx context.Context) (bool, error) {
+	return uc.ImportProductIntegrationWithOptions(ctx, entities.ProductIntegrationOptions{})
+}
+
+// ImportProductIntegrationWithOptions imports product integrations; opts.Force disables
+// the content hash check so unchanged payloads are integrated again
+func (uc *ProductIntegrationUseCase) ImportProductIntegrationWithOptions(ctx context.Context, opts entities.ProductIntegrationOptions) (bool, error) {
+	if opts.IdExecucao == "" {
+		opts.IdExecucao = entities.NewIdExecucao()
+	}
+	log.Printf("Starting product integration import process (force: %t, run: %s)", opts.Force, opts.IdExecucao)
 
 	var success []bool
-	integrRmsProductsIn, err := uc.repo.GetIntegrRmsProductsIn()
+	integrRmsProductsIn, err := uc.repo.GetIntegrRmsProductsIn(ctx)
 	if err != nil {
 		return false, fmt.Errorf("error getting integr rms products: %w", err)
 	}
 
-	// Begin transaction
-	tx, err := uc.db.Begin()
+	mode := uc.getIntegrationMode(ctx)
+	log.Printf("Product integration mode: %s", mode)
+
+	unchanged := 0
+	for i, rms := range integrRmsProductsIn {
+		result := uc.integrateRow(ctx, rms, mode, opts)
+		if result.Adiado {
+			log.Printf("Product integration paused after %d of %d rows: %v", i, len(integrRmsProductsIn), result.Err)
+			return false, fmt.Errorf("integração de produtos adiada: %w", result.Err)
+		}
+		if result.SemAlteracao {
+			unchanged++
+		}
+		success = append(success, result.Success)
+	}
+
+	log.Printf("Product integration finished: %d rows, %d unchanged", len(integrRmsProductsIn), unchanged)
+
+	// Check if any processing failed
+	isFalse := false
+	for _, val := range success {
+		if !val {
+			isFalse = true
+			break
+		}
+	}
+
+	return !isFalse, nil
+}
+
+// integrateRow processes one INTEGR_RMS_PRODUTO_IN row in its own transaction: the product
+// changes, the removal of the row and its LogIntegrRMS message are committed together.
+// Failed rows are removed as well to avoid processing them forever.
+func (uc *ProductIntegrationUseCase) integrateRow(ctx context.Context, rms entities.IntegrRmsProductIn, mode string, opts entities.ProductIntegrationOptions) *entities.LogValidate {
+	tx, err := uc.db.BeginTx(ctx, nil)
 	if err != nil {
-		return false, fmt.Errorf("error starting transaction: %w", err)
+		result := &entities.LogValidate{
+			Success: false,
+			Message: fmt.Sprintf("Error starting transaction: %v", err),
+		}
+		if err := uc.outbox.Log(ctx, uc.newLogMessage(rms, result, opts.IdExecucao), uc.repo.SendToQueue); err != nil {
+			log.Printf("Error sending log to queue: %v", err)
+		}
+		return result
 	}
 	defer func() {
 		if p := recover(); p != nil {
@@ -48,67 +163,66 @@ func (uc *ProductIntegrationUseCase) ImportProductIntegration() (bool, error) {
 		}
 	}()
 
-	for _, rms := range integrRmsProductsIn {
-		result := uc.processProductIntegration(rms)
-
-		logErro := entities.QueueMessage{
-			Tabela: "LogIntegrRMS",
-			Fields: []string{"TRANSACAO", "TABELA", "DATARECEBIMENTO", "DATAPROCESSAMENTO", "STATUSPROCESSAMENTO", "JSON", "DESCRICAOERRO"},
-			Values: []interface{}{
-				"IN",
-				"PRODUTOS",
-				rms.DataRecebimento,
-				time.Now(),
-				uc.getStatusFromResult(result),
-				uc.marshalRMS(rms),
-				uc.getMessageFromResult(result),
-			},
-		}
-
-		// Send to queue (logging mechanism)
-		if err := uc.repo.SendToQueue(logErro); err != nil {
-			log.Printf("Error sending log to queue: %v", err)
+	repo := uc.repo.WithTx(tx)
+	changes := uc.catalog.Changes()
+	result := uc.processProductIntegration(ctx, repo, changes, rms, mode, opts.Force)
+	if result.Adiado {
+		// The row stays in INTEGR_RMS_PRODUTO_IN for the next run
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("Error rolling back product transaction: %v", rbErr)
 		}
+		return result
+	}
 
-		if result.Success {
-			success = append(success, true)
-			if err := uc.repo.RemoveProductService(rms); err != nil {
-				log.Printf("Error removing product service: %v", err)
-				success = append(success, false)
-			}
-		} else {
-			success = append(success, false)
-			// Still remove the record even if processing failed to avoid infinite loops
-			if err := uc.repo.RemoveProductService(rms); err != nil {
-				log.Printf("Error removing failed product service: %v", err)
-			}
+	if err := repo.RemoveProductService(ctx, rms); err != nil {
+		log.Printf("Error removing product service: %v", err)
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("Error rolling back product transaction: %v", rbErr)
 		}
+		return &entities.LogValidate{Success: false, Message: fmt.Sprintf("Error removing product service: %v", err)}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return false, fmt.Errorf("error committing transaction: %w", err)
+	if err := uc.outbox.CommitWithLog(ctx, tx, uc.newLogMessage(rms, result, opts.IdExecucao), uc.repo.SendToQueue); err != nil {
+		log.Printf("Error committing product integration: %v", err)
+		return &entities.LogValidate{Success: false, Message: err.Error()}
 	}
+	// Only now may other workers use the brands and industries created by the row
+	changes.Publish()
 
-	// Check if any processing failed
-	isFalse := false
-	for _, val := range success {
-		if !val {
-			isFalse = true
-			break
-		}
-	}
+	return result
+}
 
-	return !isFalse, nil
+// newLogMessage builds the log event of a processed row
+func (uc *ProductIntegrationUseCase) newLogMessage(rms entities.IntegrRmsProductIn, result *entities.LogValidate, idExecucao string) entities.LogEvent {
+	var receivedAt time.Time
+	if rms.DataRecebimento != nil {
+		receivedAt = *rms.DataRecebimento
+	}
+	return entities.NewLogEvent("IN", "PRODUTOS", receivedAt, entities.LogEventStatusOf(result.Success),
+		uc.marshalRMS(rms), uc.getMessageFromResult(result), idExecucao)
 }
 
-// processProductIntegration processes a single product integration
-func (uc *ProductIntegrationUseCase) processProductIntegration(rms entities.IntegrRmsProductIn) *entities.LogValidate {
+// processProductIntegration processes a single product integration through repo, recording
+// the brands and industries it creates in changes
+func (uc *ProductIntegrationUseCase) processProductIntegration(ctx context.Context, repo *repositories.ProductIntegrationRepository, changes *CatalogChanges, rms entities.IntegrRmsProductIn, mode string, force bool) (result *entities.LogValidate) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Panic recovered in processProductIntegration: %v", r)
+			result = &entities.LogValidate{
+				Success: false,
+				Message: fmt.Sprintf("Panic processing product integration: %v", r),
+			}
 		}
 	}()
 
+	// Validate the JSON against the product schema before anything reaches Oracle
+	if violations := ValidateProductJSON(rms.JSON); len(violations) > 0 {
+		return &entities.LogValidate{
+			Success: false,
+			Message: describeSchemaViolations(violations),
+		}
+	}
+
 	// Parse JSON
 	var produto entities.ProductInJson
 	if err := json.Unmarshal([]byte(rms.JSON), &produto); err != nil {
@@ -118,15 +232,53 @@ func (uc *ProductIntegrationUseCase) processProductIntegration(rms entities.Inte
 		}
 	}
 
+	hashes := productContentHashes(produto)
+	if !force && uc.isUnchangedPayload(ctx, hashes) {
+		log.Printf("IPR_ID %s sem alterações, integração ignorada", formatIntPtr(rms.IprID))
+		return &entities.LogValidate{
+			Success:      true,
+			Message:      entities.MSG_IMPORT_PRODUCT_NO_CHANGE,
+			SemAlteracao: true,
+		}
+	}
+
+	barcodeWarnings := describeBarcodeIssues(productBarcodeIssues(produto))
+	defer func() {
+		if result != nil {
+			result.Avisos = append(result.Avisos, barcodeWarnings...)
+			if result.Success {
+				uc.saveContentHashes(ctx, repo, hashes)
+			}
+		}
+	}()
+
+	if mode == entities.PRODUCT_INTEGRATION_MODE_GO {
+		return uc.upsertProduct(ctx, repo, changes, produto)
+	}
+
 	// Call Oracle stored procedure to handle the integration
 	if rms.IprID != nil {
-		result, err := uc.repo.DoPackageProductIntegration(*rms.IprID)
+		// Shadow mode computes the Go expectation before the package changes the tables
+		var expected []*entities.ProductNew
+		var expectationErr error
+		if mode == entities.PRODUCT_INTEGRATION_MODE_SHADOW {
+			expected, expectationErr = uc.buildShadowExpectation(ctx, produto)
+		}
+
+		result, err := repo.DoPackageProductIntegration(ctx, *rms.IprID)
+		if errors.Is(err, entities.ErrCircuitOpen) {
+			return &entities.LogValidate{Success: false, Message: err.Error(), Adiado: true, Err: err}
+		}
 		if err != nil {
-			return &entities.LogValidate{
+			result = &entities.LogValidate{
 				Success: false,
 				Message: fmt.Sprintf("Error executing Oracle procedure: %v", err),
 			}
 		}
+
+		if mode == entities.PRODUCT_INTEGRATION_MODE_SHADOW {
+			uc.runShadowComparison(ctx, repo, rms, expected, expectationErr, result.Success)
+		}
 		return result
 	}
 
@@ -136,8 +288,42 @@ func (uc *ProductIntegrationUseCase) processProductIntegration(rms entities.Inte
 	}
 }
 
-// getNewProduct processes and validates product data (commented out equivalent to TypeScript version)
-func (uc *ProductIntegrationUseCase) getNewProduct(produto entities.ProductInJson) (*entities.LogValidate, error) {
+// upsertProduct runs getNewProduct in the row transaction of repo, undoing its changes
+// unless every product of the message was integrated
+func (uc *ProductIntegrationUseCase) upsertProduct(ctx context.Context, repo *repositories.ProductIntegrationRepository, changes *CatalogChanges, produto entities.ProductInJson) *entities.LogValidate {
+	if err := repo.Savepoint(ctx, productUpsertSavepoint); err != nil {
+		return &entities.LogValidate{
+			Success: false,
+			Message: fmt.Sprintf("Error creating savepoint: %v", err),
+		}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			repo.RollbackToSavepoint(ctx, productUpsertSavepoint)
+			panic(p)
+		}
+	}()
+
+	result, err := uc.getNewProduct(ctx, repo, changes, produto)
+	if err == nil && !result.Success {
+		err = fmt.Errorf("%s", result.Message)
+	}
+	if err != nil {
+		if rbErr := repo.RollbackToSavepoint(ctx, productUpsertSavepoint); rbErr != nil {
+			log.Printf("Error rolling back product changes: %v", rbErr)
+		}
+		changes.Discard()
+		if result == nil {
+			result = &entities.LogValidate{Success: false, Message: err.Error()}
+		}
+		return result
+	}
+
+	return result
+}
+
+// getNewProduct processes, validates and upserts product data using repo
+func (uc *ProductIntegrationUseCase) getNewProduct(ctx context.Context, repo *repositories.ProductIntegrationRepository, changes *CatalogChanges, produto entities.ProductInJson) (*entities.LogValidate, error) {
 	if len(produto.ProdutosSelect) == 0 {
 		return &entities.LogValidate{
 			Message: "Produto inválido ou vazio.",
@@ -166,17 +352,17 @@ func (uc *ProductIntegrationUseCase) getNewProduct(produto entities.ProductInJso
 		uc.setProductDefaults(newProduct)
 
 		// Validate marketing structure
-		if validationResult := uc.validateMarketingStructure(newProduct); !validationResult.Success {
+		if validationResult := uc.validateMarketingStructure(ctx, repo, newProduct); !validationResult.Success {
 			return validationResult, nil
 		}
 
 		// Validate brand and industry
-		if validationResult := uc.validateBrandAndIndustry(produtoSelect); !validationResult.Success {
+		if validationResult := uc.validateBrandAndIndustry(repo, produtoSelect); !validationResult.Success {
 			return validationResult, nil
 		}
 
 		// Process brand
-		if err := uc.processBrand(newProduct, produtoSelect); err != nil {
+		if err := uc.processBrand(ctx, repo, changes, newProduct, produtoSelect); err != nil {
 			return &entities.LogValidate{
 				Message: fmt.Sprintf("Error processing brand: %v", err),
 				Success: false,
@@ -185,9 +371,15 @@ func (uc *ProductIntegrationUseCase) getNewProduct(produto entities.ProductInJso
 
 		// Process barcodes and packaging
 		uc.processBarcodesAndPackaging(newProduct, produtoSelect, produto.Pesavel)
+		if len(produtoSelect.CodBarras) > 0 && len(newProduct.Embalagens) == 0 {
+			return &entities.LogValidate{
+				Message: fmt.Sprintf("Nenhum código de barras válido para o produto RMS %s.", produtoSelect.CodRMS),
+				Success: false,
+			}, nil
+		}
 
 		// Process product (insert or update)
-		if err := uc.processProduct(newProduct); err != nil {
+		if err := uc.processProduct(ctx, repo, newProduct); err != nil {
 			return &entities.LogValidate{
 				Message: fmt.Sprintf("Error processing product: %v", err),
 				Success: false,
@@ -208,6 +400,7 @@ func (uc *ProductIntegrationUseCase) createNewProductFromSelect(produtoSelect en
 		DescricaoProduto: produtoSelect.Desc,
 		DescricaoCupom:   produtoSelect.DescEcf,
 		Notabilidade:     entities.NOTABILIDADE,
+		Industria:        produtoSelect.Ind,
 	}
 
 	// Set PitStop
@@ -270,16 +463,23 @@ func (uc *ProductIntegrationUseCase) setProductDefaults(product *entities.Produc
 	product.ConteudoEmbalagem = &conteudo
 }
 
-// validateMarketingStructure validates marketing structure
-func (uc *ProductIntegrationUseCase) validateMarketingStructure(product *entities.ProductNew) *entities.LogValidate {
+// validateMarketingStructure resolves the subclass to its complete level 1-4 path and
+// checks that it belongs to the department sent by RMS
+func (uc *ProductIntegrationUseCase) validateMarketingStructure(ctx context.Context, repo *repositories.ProductIntegrationRepository, product *entities.ProductNew) *entities.LogValidate {
 	if product.IdNivel2EstrMerc == nil {
 		return &entities.LogValidate{
 			Message: "IdNivel2EstrMerc é obrigatório",
 			Success: false,
 		}
 	}
+	if product.IdEstruturaMercadologica == nil {
+		return &entities.LogValidate{
+			Message: "IdEstruturaMercadologica (subclasse) é obrigatório",
+			Success: false,
+		}
+	}
 
-	marketingStructure, err := uc.repo.GetMarketingStructureLevel2(*product.IdNivel2EstrMerc)
+	tree, err := uc.catalog.MarketingStructureTree(ctx)
 	if err != nil {
 		return &entities.LogValidate{
 			Message: fmt.Sprintf("Erro ao obter estrutura mercadológica: %v", err),
@@ -287,35 +487,41 @@ func (uc *ProductIntegrationUseCase) validateMarketingStructure(product *entitie
 		}
 	}
 
-	validationResult := uc.repo.ValidateMarketingStructureLevel2(marketingStructure)
+	validationResult := repo.ValidateMarketingStructureLevel2(tree.Get(*product.IdNivel2EstrMerc))
 	if !validationResult.Success {
 		return validationResult
 	}
 
-	// Set parent level
-	if marketingStructure != nil && marketingStructure.IdNivelPai != nil {
-		product.IdNivel1EstrMerc = marketingStructure.IdNivelPai
+	path, err := tree.Resolve(*product.IdEstruturaMercadologica)
+	if err != nil {
+		return &entities.LogValidate{
+			Message: fmt.Sprintf("Estrutura mercadológica inválida: %v", err),
+			Success: false,
+		}
 	}
 
-	// Get level 4 structure
-	if product.IdEstruturaMercadologica != nil {
-		marketingStructure4, err := uc.repo.GetMarketingStructureLevel4(*product.IdEstruturaMercadologica)
-		if err == nil && len(marketingStructure4) > 0 && marketingStructure4[0].IdNivelPai != nil {
-			product.IdNivel3EstrMerc = marketingStructure4[0].IdNivelPai
+	if getIntValue(path.Nivel2.IdEstruturaMercadologica) != *product.IdNivel2EstrMerc {
+		return &entities.LogValidate{
+			Message: fmt.Sprintf("Subclasse %d pertence ao departamento %d, não ao departamento %d",
+				*product.IdEstruturaMercadologica, getIntValue(path.Nivel2.IdEstruturaMercadologica), *product.IdNivel2EstrMerc),
+			Success: false,
 		}
 	}
 
+	product.IdNivel1EstrMerc = path.Nivel1.IdEstruturaMercadologica
+	product.IdNivel3EstrMerc = path.Nivel3.IdEstruturaMercadologica
+
 	return &entities.LogValidate{Success: true, Message: "Marketing structure validated"}
 }
 
 // validateBrandAndIndustry validates brand and industry
-func (uc *ProductIntegrationUseCase) validateBrandAndIndustry(produtoSelect entities.ProductSelectIntegration) *entities.LogValidate {
-	vldBrandDesc := uc.repo.ValidateBrandDesc(produtoSelect.DescMarca)
+func (uc *ProductIntegrationUseCase) validateBrandAndIndustry(repo *repositories.ProductIntegrationRepository, produtoSelect entities.ProductSelectIntegration) *entities.LogValidate {
+	vldBrandDesc := repo.ValidateBrandDesc(produtoSelect.DescMarca)
 	if !vldBrandDesc.Success {
 		return vldBrandDesc
 	}
 
-	vldIndustry := uc.repo.ValidateIndustry(produtoSelect.Ind)
+	vldIndustry := repo.ValidateIndustry(produtoSelect.Ind)
 	if !vldIndustry.Success {
 		return vldIndustry
 	}
@@ -323,21 +529,21 @@ func (uc *ProductIntegrationUseCase) validateBrandAndIndustry(produtoSelect enti
 	return &entities.LogValidate{Success: true, Message: "Brand and industry validated"}
 }
 
-// processBrand processes brand information
-func (uc *ProductIntegrationUseCase) processBrand(newProduct *entities.ProductNew, produtoSelect entities.ProductSelectIntegration) error {
-	// Get existing brand
-	brands, err := uc.repo.GetBrandByIndustryName(produtoSelect.DescMarca, produtoSelect.Ind)
+// processBrand processes brand information; brands and industries it creates are recorded
+// in changes and reach the shared cache only after the row commits
+func (uc *ProductIntegrationUseCase) processBrand(ctx context.Context, repo *repositories.ProductIntegrationRepository, changes *CatalogChanges, newProduct *entities.ProductNew, produtoSelect entities.ProductSelectIntegration) error {
+	// Get existing brand (accent and punctuation insensitive)
+	brand, err := changes.FindBrand(ctx, produtoSelect.DescMarca, produtoSelect.Ind)
 	if err != nil {
 		return fmt.Errorf("error getting brand: %w", err)
 	}
 
-	if len(brands) > 0 {
+	if brand != nil {
 		// Brand exists
-		newProduct.IdMarca = brands[len(brands)-1].IdMarca
+		newProduct.IdMarca = brand.IdMarca
 	} else {
 		// Create new brand
-		status, _ := strconv.Atoi(produtoSelect.Status)
-		industry, err := uc.repo.GetIndustryByNameAndStatus(produtoSelect.Ind, status)
+		industry, err := changes.FindIndustry(ctx, produtoSelect.Ind, entities.CONST_ATIVO)
 		if err != nil {
 			return fmt.Errorf("error getting industry: %w", err)
 		}
@@ -349,10 +555,11 @@ func (uc *ProductIntegrationUseCase) processBrand(newProduct *entities.ProductNe
 				NomeIndustria:   produtoSelect.Ind,
 				StatusIndustria: 1,
 			}
-			industryResult, err = uc.repo.SaveIndustry(newIndustry)
+			industryResult, err = repo.SaveIndustry(ctx, newIndustry)
 			if err != nil {
 				return fmt.Errorf("error saving industry: %w", err)
 			}
+			changes.AddIndustry(*industryResult)
 		} else {
 			industryResult = industry
 		}
@@ -365,10 +572,11 @@ func (uc *ProductIntegrationUseCase) processBrand(newProduct *entities.ProductNe
 			NomeIndustria: industryResult.NomeIndustria,
 		}
 
-		brandResult, err := uc.repo.SaveBrand(newBrand)
+		brandResult, err := repo.SaveBrand(ctx, newBrand)
 		if err != nil {
 			return fmt.Errorf("error saving brand: %w", err)
 		}
+		changes.AddBrand(*brandResult)
 
 		newProduct.IdMarca = brandResult.IdMarca
 	}
@@ -376,43 +584,44 @@ func (uc *ProductIntegrationUseCase) processBrand(newProduct *entities.ProductNe
 	return nil
 }
 
-// processBarcodesAndPackaging processes barcodes and packaging
+// processBarcodesAndPackaging processes barcodes and packaging. Every code is
+// classified by ClassifyBarcode; invalid codes are skipped (see productBarcodeIssues).
 func (uc *ProductIntegrationUseCase) processBarcodesAndPackaging(newProduct *entities.ProductNew, produtoSelect entities.ProductSelectIntegration, pesavel string) {
-	var tipoCodigoBarras string
+	isPesavel := pesavel == entities.CONST_TRUE || produtoSelect.Pesavel == entities.CONST_TRUE
 
 	if len(produtoSelect.CodBarras) > 0 {
 		newProduct.Embalagens = []entities.ProductPackaging{}
 
 		for _, cbarra := range produtoSelect.CodBarras {
+			info := ClassifyBarcode(cbarra.CBarra, isPesavel)
+			if !info.Valid {
+				continue
+			}
+
 			unidadeMedida := entities.UNIDADE_MEDIDA_UN
 			if pesavel == entities.CONST_TRUE {
 				unidadeMedida = entities.UNIDADE_MEDIDA_KG
 			}
 
-			tipoCodigoBarra := entities.CB_INTERNO
-			if cbarra.Tipo == entities.CB_BARRA_EAN13 {
-				tipoCodigoBarra = entities.CB_BARRA_EAN
-			}
-
 			productPackaging := entities.ProductPackaging{
-				CodigoBarras:        cbarra.CBarra,
+				CodigoBarras:        info.Code,
 				Principal:           (cbarra.Princ == entities.CONST_TRUE),
 				QuantidadeEmbalagem: 1,
 				IdUnidadeMedida:     &unidadeMedida,
-				TipoCodigoBarras:    tipoCodigoBarra,
+				TipoCodigoBarras:    info.TipoCodigoBarras(),
 			}
 
 			newProduct.IdUnidadeMedida = productPackaging.IdUnidadeMedida
-
-			if tipoCodigoBarras == "" {
-				tipoCodigoBarras = productPackaging.TipoCodigoBarras
-			}
-
 			newProduct.Embalagens = append(newProduct.Embalagens, productPackaging)
 		}
 
 		// Process additional packaging
 		for _, embalagem := range produtoSelect.Embalagem {
+			info := ClassifyBarcode(embalagem.EAN, isPesavel)
+			if !info.Valid {
+				continue
+			}
+
 			qtde := 0
 			if embalagem.Qtde != "" {
 				qtde, _ = strconv.Atoi(embalagem.Qtde)
@@ -424,11 +633,11 @@ func (uc *ProductIntegrationUseCase) processBarcodesAndPackaging(newProduct *ent
 			}
 
 			emb := entities.ProductPackaging{
-				CodigoBarras:        embalagem.EAN,
+				CodigoBarras:        info.Code,
 				Principal:           false,
 				QuantidadeEmbalagem: qtde,
 				IdUnidadeMedida:     &unidadeMedida,
-				TipoCodigoBarras:    tipoCodigoBarras,
+				TipoCodigoBarras:    info.TipoCodigoBarras(),
 			}
 
 			newProduct.Embalagens = append(newProduct.Embalagens, emb)
@@ -436,14 +645,50 @@ func (uc *ProductIntegrationUseCase) processBarcodesAndPackaging(newProduct *ent
 	}
 }
 
+// productBarcodeIssues validates every barcode of the message and returns the invalid ones
+func productBarcodeIssues(produto entities.ProductInJson) []entities.BarcodeIssue {
+	var issues []entities.BarcodeIssue
+
+	for _, produtoSelect := range produto.ProdutosSelect {
+		isPesavel := produto.Pesavel == entities.CONST_TRUE || produtoSelect.Pesavel == entities.CONST_TRUE
+
+		for _, cbarra := range produtoSelect.CodBarras {
+			if info := ClassifyBarcode(cbarra.CBarra, isPesavel); !info.Valid {
+				issues = append(issues, entities.BarcodeIssue{
+					CodigoRMS: produtoSelect.CodRMS, Codigo: cbarra.CBarra, Origem: "codBarras", Motivo: info.Reason,
+				})
+			}
+		}
+		for _, embalagem := range produtoSelect.Embalagem {
+			if info := ClassifyBarcode(embalagem.EAN, isPesavel); !info.Valid {
+				issues = append(issues, entities.BarcodeIssue{
+					CodigoRMS: produtoSelect.CodRMS, Codigo: embalagem.EAN, Origem: "embalagem", Motivo: info.Reason,
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// describeBarcodeIssues formats barcode issues for the integration log
+func describeBarcodeIssues(issues []entities.BarcodeIssue) []string {
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, fmt.Sprintf("Produto RMS %s: código de barras '%s' (%s) inválido: %s",
+			issue.CodigoRMS, issue.Codigo, issue.Origem, issue.Motivo))
+	}
+	return messages
+}
+
 // processProduct processes the product (insert or update)
-func (uc *ProductIntegrationUseCase) processProduct(newProduct *entities.ProductNew) error {
+func (uc *ProductIntegrationUseCase) processProduct(ctx context.Context, repo *repositories.ProductIntegrationRepository, newProduct *entities.ProductNew) error {
 	if newProduct.CodigoRMS == nil {
 		return fmt.Errorf("código RMS é obrigatório")
 	}
 
 	// Check if product exists
-	existingProduct, err := uc.repo.GetProductByCodeRMS(*newProduct.CodigoRMS)
+	existingProduct, err := repo.GetProductByCodeRMS(ctx, *newProduct.CodigoRMS)
 	if err != nil {
 		return fmt.Errorf("error checking existing product: %w", err)
 	}
@@ -458,27 +703,45 @@ func (uc *ProductIntegrationUseCase) processProduct(newProduct *entities.Product
 
 	// If product doesn't exist, check by barcode
 	if existingProduct == nil && codigoBarrasPrinc != "" {
-		embProduct, err := uc.repo.GetProductPackagingByBarCode(codigoBarrasPrinc)
+		embProduct, err := repo.GetProductPackagingByBarCode(ctx, codigoBarrasPrinc)
 		if err != nil {
 			return fmt.Errorf("error getting product packaging by barcode: %w", err)
 		}
 
 		if embProduct != nil && embProduct.IdProduto != nil {
-			existingProduct, err = uc.repo.GetProductByCodeRMS(*embProduct.IdProduto)
+			existingProduct, err = repo.GetProductByID(ctx, *embProduct.IdProduto)
 			if err != nil {
 				return fmt.Errorf("error getting product by packaging ID: %w", err)
 			}
 		}
 	}
 
-	if newProduct.IdMarca != nil {
-		if existingProduct == nil {
-			// Insert new product - this would need actual implementation
-			log.Printf("Would insert new product with RMS code: %d", *newProduct.CodigoRMS)
-		} else {
-			// Update existing product - this would need actual implementation
-			newProduct.IdProduto = existingProduct.IdProduto
-			log.Printf("Would update existing product with ID: %d", *newProduct.IdProduto)
+	if newProduct.IdMarca == nil {
+		return fmt.Errorf("marca não encontrada para o produto RMS %d", *newProduct.CodigoRMS)
+	}
+
+	if existingProduct == nil {
+		newID, err := repo.InsertProduct(ctx, *newProduct)
+		if err != nil {
+			return err
+		}
+		newProduct.IdProduto = &newID
+		log.Printf("Produto RMS %d inserido com ID %d", *newProduct.CodigoRMS, newID)
+	} else {
+		newProduct.IdProduto = existingProduct.IdProduto
+		if err := repo.UpdateProduct(ctx, *newProduct); err != nil {
+			return err
+		}
+		log.Printf("Produto RMS %d atualizado (ID %d)", *newProduct.CodigoRMS, *newProduct.IdProduto)
+	}
+
+	for _, embalagem := range newProduct.Embalagens {
+		if embalagem.CodigoBarras == "" {
+			continue
+		}
+		embalagem.IdProduto = newProduct.IdProduto
+		if err := repo.UpsertProductPackaging(ctx, embalagem); err != nil {
+			return err
 		}
 	}
 
@@ -486,18 +749,15 @@ func (uc *ProductIntegrationUseCase) processProduct(newProduct *entities.Product
 }
 
 // Helper functions
-func (uc *ProductIntegrationUseCase) getStatusFromResult(result *entities.LogValidate) int {
-	if result.Success {
-		return 0
-	}
-	return 1
-}
-
 func (uc *ProductIntegrationUseCase) getMessageFromResult(result *entities.LogValidate) string {
-	if result.Success {
-		return "Integração de Produtos Realizada com Sucesso"
+	message := result.Message
+	if result.Success && !result.SemAlteracao {
+		message = "Integração de Produtos Realizada com Sucesso"
+	}
+	if len(result.Avisos) > 0 {
+		message += ". Avisos: " + strings.Join(result.Avisos, "; ")
 	}
-	return result.Message
+	return message
 }
 
 func (uc *ProductIntegrationUseCase) marshalRMS(rms entities.IntegrRmsProductIn) string {
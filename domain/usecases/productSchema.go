@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// schemaKind is the expected JSON type of a schema field
+type schemaKind int
+
+const (
+	schemaString        schemaKind = iota // any JSON string
+	schemaNumericString                   // JSON string made only of digits
+	schemaArray                           // JSON array of objects described by Items
+)
+
+// schemaField declares one field of a JSON object
+type schemaField struct {
+	Name     string
+	Kind     schemaKind
+	Required bool     // field must be present and, for strings, not blank
+	Enum     []string // allowed values for strings
+	MinItems int      // minimum length for arrays
+	Items    []schemaField
+}
+
+var booleanStringValues = []string{entities.CONST_TRUE, entities.CONST_FALSE}
+
+// productInJsonSchema describes ProductInJson / ProductSelectIntegration as sent by RMS
+var productInJsonSchema = []schemaField{
+	{Name: "pesavel", Kind: schemaString, Enum: booleanStringValues},
+	{Name: "produtosSelect", Kind: schemaArray, Required: true, MinItems: 1, Items: []schemaField{
+		{Name: "desc", Kind: schemaString, Required: true},
+		{Name: "descEcf", Kind: schemaString},
+		{Name: "pitstop", Kind: schemaString, Enum: booleanStringValues},
+		{Name: "subclasse", Kind: schemaNumericString, Required: true},
+		{Name: "nivel1", Kind: schemaNumericString},
+		{Name: "depto", Kind: schemaNumericString, Required: true},
+		{Name: "codrms", Kind: schemaNumericString, Required: true},
+		{Name: "status", Kind: schemaString, Required: true, Enum: entities.PRODUCT_STATUS_VALUES},
+		{Name: "descMarca", Kind: schemaString, Required: true},
+		{Name: "ind", Kind: schemaString, Required: true},
+		{Name: "pesavel", Kind: schemaString, Enum: booleanStringValues},
+		{Name: "codBarras", Kind: schemaArray, Required: true, MinItems: 1, Items: []schemaField{
+			{Name: "cBarra", Kind: schemaString, Required: true},
+			{Name: "princ", Kind: schemaString, Enum: booleanStringValues},
+			{Name: "tipo", Kind: schemaString},
+		}},
+		{Name: "embalagem", Kind: schemaArray, Items: []schemaField{
+			{Name: "ean", Kind: schemaString, Required: true},
+			{Name: "qtde", Kind: schemaNumericString},
+		}},
+	}},
+}
+
+// ValidateProductJSON checks raw INTEGR_RMS_PRODUTO_IN JSON against productInJsonSchema
+// and returns every violation with its JSON path (e.g. $.produtosSelect[0].codrms)
+func ValidateProductJSON(raw string) []entities.SchemaViolation {
+	var document interface{}
+	if err := json.Unmarshal([]byte(raw), &document); err != nil {
+		return []entities.SchemaViolation{{Path: "$", Message: fmt.Sprintf("JSON inválido: %v", err)}}
+	}
+
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return []entities.SchemaViolation{{Path: "$", Message: "deve ser um objeto"}}
+	}
+
+	return validateSchemaObject("$", object, productInJsonSchema)
+}
+
+// schemaFieldKeys returns the keys of object that encoding/json would decode into the
+// ProductInJson field name: every key equal to it under case folding, sorted
+func schemaFieldKeys(object map[string]interface{}, name string) []string {
+	var keys []string
+	for key := range object {
+		if strings.EqualFold(key, name) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateSchemaObject validates the fields of object, reporting paths under prefix
+func validateSchemaObject(prefix string, object map[string]interface{}, fields []schemaField) []entities.SchemaViolation {
+	var violations []entities.SchemaViolation
+	report := func(path, message string) {
+		violations = append(violations, entities.SchemaViolation{Path: path, Message: message})
+	}
+
+	for _, field := range fields {
+		path := prefix + "." + field.Name
+		keys := schemaFieldKeys(object, field.Name)
+		if len(keys) > 1 {
+			// encoding/json keeps the last spelling in document order, which the decoded map
+			// no longer has: the value that would be imported cannot be told
+			report(path, fmt.Sprintf("campo repetido com grafias diferentes: %s", strings.Join(keys, ", ")))
+			continue
+		}
+		var value interface{}
+		if len(keys) == 1 {
+			value = object[keys[0]]
+		}
+		if value == nil {
+			if field.Required {
+				report(path, "campo obrigatório ausente")
+			}
+			continue
+		}
+
+		switch field.Kind {
+		case schemaString, schemaNumericString:
+			text, ok := value.(string)
+			if !ok {
+				report(path, fmt.Sprintf("deve ser texto, recebido %s", jsonTypeName(value)))
+				continue
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				if field.Required {
+					report(path, "campo obrigatório vazio")
+				}
+				continue
+			}
+			if field.Kind == schemaNumericString && !isDigits(text) {
+				report(path, fmt.Sprintf("deve ser numérico, recebido '%s'", text))
+			}
+			if len(field.Enum) > 0 && !containsString(field.Enum, text) {
+				report(path, fmt.Sprintf("valor '%s' não permitido (permitidos: %s)", text, strings.Join(field.Enum, ", ")))
+			}
+
+		case schemaArray:
+			items, ok := value.([]interface{})
+			if !ok {
+				report(path, fmt.Sprintf("deve ser uma lista, recebido %s", jsonTypeName(value)))
+				continue
+			}
+			if len(items) < field.MinItems {
+				report(path, fmt.Sprintf("deve ter ao menos %d item(ns)", field.MinItems))
+			}
+			for i, item := range items {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				itemObject, ok := item.(map[string]interface{})
+				if !ok {
+					report(itemPath, fmt.Sprintf("deve ser um objeto, recebido %s", jsonTypeName(item)))
+					continue
+				}
+				violations = append(violations, validateSchemaObject(itemPath, itemObject, field.Items)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+// describeSchemaViolations formats violations for the integration log in document order
+func describeSchemaViolations(violations []entities.SchemaViolation) string {
+	parts := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		parts = append(parts, violation.Path+": "+violation.Message)
+	}
+	return fmt.Sprintf("JSON do produto inválido (%d erro(s)): %s", len(violations), strings.Join(parts, "; "))
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "texto"
+	case float64:
+		return "número"
+	case bool:
+		return "booleano"
+	case []interface{}:
+		return "lista"
+	case map[string]interface{}:
+		return "objeto"
+	default:
+		return "nulo"
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,502 @@
+package usecases
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// Rule names accepted by ParsePromotionNormalizationRules
+const (
+	RuleDropEmptyBarcode     = "drop_empty_barcode"
+	RuleDedupeBarcode        = "dedupe_barcode"
+	RuleNormalizeDescription = "normalize_description"
+	RuleRecomputeQtdeItem    = "recompute_qtde_item"
+	RulePriceOutlier         = "price_outlier"
+	RuleValidateBarcode      = "validate_barcode"
+)
+
+// Strategies accepted by DedupeBarcodeRule to choose which duplicate is kept
+const (
+	DedupeKeepFirst        = "first"
+	DedupeKeepLast         = "last"
+	DedupeKeepLowestPrice  = "lowest_price"
+	DedupeKeepHighestPrice = "highest_price"
+)
+
+// Actions accepted by ValidateBarcodeRule for items with an invalid barcode
+const (
+	InvalidBarcodeFlag = "flag"
+	InvalidBarcodeDrop = "drop"
+)
+
+// DefaultPromotionNormalizationRules reproduces the original normalization behavior:
+// items without barcode are dropped, duplicates keep the first occurrence and qtdeItem is recomputed.
+// Invalid barcodes are only reported.
+const DefaultPromotionNormalizationRules = "drop_empty_barcode,validate_barcode:flag,dedupe_barcode:first,recompute_qtde_item"
+
+// PromotionNormalizationRule is a single step of the promotion normalization pipeline
+type PromotionNormalizationRule interface {
+	Name() string
+	Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult
+}
+
+// promotionRuleEntry holds a rule and whether it is enabled in the pipeline
+type promotionRuleEntry struct {
+	rule    PromotionNormalizationRule
+	enabled bool
+}
+
+// PromotionNormalizationPipeline runs an ordered list of named normalization rules
+type PromotionNormalizationPipeline struct {
+	entries []promotionRuleEntry
+}
+
+// NewPromotionNormalizationPipeline creates a pipeline with all given rules enabled
+func NewPromotionNormalizationPipeline(rules ...PromotionNormalizationRule) *PromotionNormalizationPipeline {
+	pipeline := &PromotionNormalizationPipeline{}
+	for _, rule := range rules {
+		pipeline.entries = append(pipeline.entries, promotionRuleEntry{rule: rule, enabled: true})
+	}
+	return pipeline
+}
+
+// DefaultPromotionNormalizationPipeline returns the pipeline described by DefaultPromotionNormalizationRules
+func DefaultPromotionNormalizationPipeline() *PromotionNormalizationPipeline {
+	pipeline, err := ParsePromotionNormalizationRules(DefaultPromotionNormalizationRules)
+	if err != nil {
+		// The default specification is static, this can only happen through a programming error
+		panic(err)
+	}
+	return pipeline
+}
+
+// ParsePromotionNormalizationRules builds a pipeline from a comma separated specification.
+// Each entry is a rule name optionally followed by ":option", e.g.
+// "drop_empty_barcode,dedupe_barcode:last,normalize_description,recompute_qtde_item,price_outlier:3".
+// Entries prefixed with "-" are added to the pipeline disabled.
+func ParsePromotionNormalizationRules(spec string) (*PromotionNormalizationPipeline, error) {
+	pipeline := &PromotionNormalizationPipeline{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		enabled := true
+		if strings.HasPrefix(entry, "-") {
+			enabled = false
+			entry = strings.TrimPrefix(entry, "-")
+		}
+
+		name, option, _ := strings.Cut(entry, ":")
+		rule, err := newPromotionNormalizationRule(strings.ToLower(name), option)
+		if err != nil {
+			return nil, err
+		}
+
+		pipeline.entries = append(pipeline.entries, promotionRuleEntry{rule: rule, enabled: enabled})
+	}
+
+	if len(pipeline.entries) == 0 {
+		return nil, fmt.Errorf("nenhuma regra de normalização configurada")
+	}
+
+	return pipeline, nil
+}
+
+// newPromotionNormalizationRule creates a rule by name with an optional option
+func newPromotionNormalizationRule(name, option string) (PromotionNormalizationRule, error) {
+	switch name {
+	case RuleDropEmptyBarcode:
+		return DropEmptyBarcodeRule{}, nil
+	case RuleDedupeBarcode:
+		if option == "" {
+			option = DedupeKeepFirst
+		}
+		switch option {
+		case DedupeKeepFirst, DedupeKeepLast, DedupeKeepLowestPrice, DedupeKeepHighestPrice:
+			return DedupeBarcodeRule{Keep: option}, nil
+		}
+		return nil, fmt.Errorf("estratégia de deduplicação inválida: %s", option)
+	case RuleNormalizeDescription:
+		return NormalizeDescriptionRule{Uppercase: option != "nouppercase"}, nil
+	case RuleRecomputeQtdeItem:
+		return RecomputeQtdeItemRule{}, nil
+	case RulePriceOutlier:
+		factor := 3.0
+		if option != "" {
+			parsed, err := strconv.ParseFloat(option, 64)
+			if err != nil || parsed <= 1 {
+				return nil, fmt.Errorf("fator de preço fora da curva inválido: %s", option)
+			}
+			factor = parsed
+		}
+		return PriceOutlierRule{Factor: factor}, nil
+	case RuleValidateBarcode:
+		if option == "" {
+			option = InvalidBarcodeFlag
+		}
+		switch option {
+		case InvalidBarcodeFlag, InvalidBarcodeDrop:
+			return ValidateBarcodeRule{Action: option}, nil
+		}
+		return nil, fmt.Errorf("ação para código de barras inválido desconhecida: %s", option)
+	}
+	return nil, fmt.Errorf("regra de normalização desconhecida: %s", name)
+}
+
+// SetEnabled enables or disables a rule by name and reports whether the rule exists
+func (p *PromotionNormalizationPipeline) SetEnabled(name string, enabled bool) bool {
+	found := false
+	for i := range p.entries {
+		if p.entries[i].rule.Name() == name {
+			p.entries[i].enabled = enabled
+			found = true
+		}
+	}
+	return found
+}
+
+// EnabledRules returns the names of the enabled rules in execution order
+func (p *PromotionNormalizationPipeline) EnabledRules() []string {
+	var names []string
+	for _, entry := range p.entries {
+		if entry.enabled {
+			names = append(names, entry.rule.Name())
+		}
+	}
+	return names
+}
+
+// Apply runs every enabled rule against the promotion data and returns whether
+// the JSON changed along with the result reported by each rule
+func (p *PromotionNormalizationPipeline) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) (bool, []entities.PromotionRuleResult) {
+	hasChanges := false
+	var results []entities.PromotionRuleResult
+
+	for _, entry := range p.entries {
+		if !entry.enabled {
+			continue
+		}
+
+		result := entry.rule.Apply(record, data)
+		result.Rule = entry.rule.Name()
+		if result.HasChanges() || result.ItemsFlagged > 0 {
+			result.RecordsAffected = 1
+		}
+		if result.HasChanges() {
+			hasChanges = true
+		}
+
+		log.Printf("Regra %s - removidos: %d, modificados: %d, sinalizados: %d",
+			result.Rule, result.ItemsRemoved, result.ItemsModified, result.ItemsFlagged)
+
+		results = append(results, result)
+	}
+
+	return hasChanges, results
+}
+
+// MergePromotionRuleResults accumulates per record rule results into run totals keeping rule
+// order. At most MAX_PROMOTION_RULE_FINDINGS findings are kept per rule; the others are
+// counted in FindingsOmitted.
+func MergePromotionRuleResults(totals []entities.PromotionRuleResult, results []entities.PromotionRuleResult) []entities.PromotionRuleResult {
+	for _, result := range results {
+		index := -1
+		for i := range totals {
+			if totals[i].Rule == result.Rule {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			totals = append(totals, entities.PromotionRuleResult{Rule: result.Rule})
+			index = len(totals) - 1
+		}
+
+		total := &totals[index]
+		total.RecordsAffected += result.RecordsAffected
+		total.ItemsRemoved += result.ItemsRemoved
+		total.ItemsModified += result.ItemsModified
+		total.ItemsFlagged += result.ItemsFlagged
+		total.FindingsOmitted += result.FindingsOmitted
+
+		findings := result.Findings
+		if room := entities.MAX_PROMOTION_RULE_FINDINGS - len(total.Findings); len(findings) > room {
+			total.FindingsOmitted += len(findings) - room
+			findings = findings[:room]
+		}
+		total.Findings = append(total.Findings, findings...)
+	}
+	return totals
+}
+
+// newPromotionRuleFinding creates a finding for an item of a group
+func newPromotionRuleFinding(record *entities.PromotionNormalization, grupo entities.PromotionGroup, item entities.PromotionGroupItem, message string) entities.PromotionRuleFinding {
+	return entities.PromotionRuleFinding{
+		IdIntegracaoPromocao: getIntValue(record.IdIntegracaoPromocao),
+		Grupo:                grupo.Desc,
+		CodBarra:             item.CodBarra,
+		Desc:                 item.Desc,
+		Preco:                item.Preco,
+		Message:              message,
+	}
+}
+
+// DropEmptyBarcodeRule removes items without codBarra and reports each removed item
+type DropEmptyBarcodeRule struct{}
+
+// Name returns the rule name
+func (DropEmptyBarcodeRule) Name() string { return RuleDropEmptyBarcode }
+
+// Apply removes items whose codBarra is empty
+func (DropEmptyBarcodeRule) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult {
+	var result entities.PromotionRuleResult
+
+	for i, grupo := range data.Grupos {
+		kept := make([]entities.PromotionGroupItem, 0, len(grupo.Items))
+		for _, item := range grupo.Items {
+			if strings.TrimSpace(item.CodBarra) == "" {
+				result.ItemsRemoved++
+				result.Findings = append(result.Findings, newPromotionRuleFinding(record, grupo, item, "Item sem código de barras removido"))
+				continue
+			}
+			kept = append(kept, item)
+		}
+		if len(kept) != len(grupo.Items) {
+			data.Grupos[i].Items = kept
+		}
+	}
+
+	return result
+}
+
+// ValidateBarcodeRule checks every codBarra with ClassifyBarcode. Invalid codes are
+// reported as findings and, when Action is drop, removed from the group.
+// Empty barcodes are left to drop_empty_barcode. Promotion items carry no pesavel flag, so
+// codes are classified as non-weighable: in-store EAN-13 codes with prefix 2 are still
+// accepted when their check digit holds, but short PLUs are reported, since nothing tells a
+// real PLU from a truncated code.
+type ValidateBarcodeRule struct {
+	Action string
+}
+
+// Name returns the rule name
+func (ValidateBarcodeRule) Name() string { return RuleValidateBarcode }
+
+// Apply validates the barcode of every item
+func (r ValidateBarcodeRule) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult {
+	var result entities.PromotionRuleResult
+
+	for i, grupo := range data.Grupos {
+		kept := make([]entities.PromotionGroupItem, 0, len(grupo.Items))
+		for _, item := range grupo.Items {
+			if strings.TrimSpace(item.CodBarra) == "" {
+				kept = append(kept, item)
+				continue
+			}
+
+			info := ClassifyBarcode(item.CodBarra, false)
+			if info.Valid {
+				kept = append(kept, item)
+				continue
+			}
+
+			message := "Código de barras inválido: " + info.Reason
+			if r.Action == InvalidBarcodeDrop {
+				result.ItemsRemoved++
+				result.Findings = append(result.Findings, newPromotionRuleFinding(record, grupo, item, message+" (removido)"))
+				continue
+			}
+
+			result.ItemsFlagged++
+			result.Findings = append(result.Findings, newPromotionRuleFinding(record, grupo, item, message))
+			kept = append(kept, item)
+		}
+		if len(kept) != len(grupo.Items) {
+			data.Grupos[i].Items = kept
+		}
+	}
+
+	return result
+}
+
+// DedupeBarcodeRule removes items sharing the same codBarra within a group.
+// Keep selects which duplicate survives: first, last, lowest_price or highest_price.
+// Items without codBarra are left untouched.
+type DedupeBarcodeRule struct {
+	Keep string
+}
+
+// Name returns the rule name
+func (DedupeBarcodeRule) Name() string { return RuleDedupeBarcode }
+
+// Apply removes duplicated barcodes from every group
+func (r DedupeBarcodeRule) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult {
+	var result entities.PromotionRuleResult
+
+	for i, grupo := range data.Grupos {
+		// Index of the item kept for each barcode
+		winners := make(map[string]int)
+		for idx, item := range grupo.Items {
+			if item.CodBarra == "" {
+				continue
+			}
+			current, seen := winners[item.CodBarra]
+			if !seen || r.prefers(item, grupo.Items[current]) {
+				winners[item.CodBarra] = idx
+			}
+		}
+
+		kept := make([]entities.PromotionGroupItem, 0, len(grupo.Items))
+		for idx, item := range grupo.Items {
+			if item.CodBarra == "" || winners[item.CodBarra] == idx {
+				kept = append(kept, item)
+			}
+		}
+
+		if removed := len(grupo.Items) - len(kept); removed > 0 {
+			log.Printf("Grupo %d - removendo %d duplicados (mantendo %s)", i+1, removed, r.Keep)
+			data.Grupos[i].Items = kept
+			result.ItemsRemoved += removed
+		}
+	}
+
+	return result
+}
+
+// prefers reports whether candidate should replace the currently kept duplicate
+func (r DedupeBarcodeRule) prefers(candidate, current entities.PromotionGroupItem) bool {
+	switch r.Keep {
+	case DedupeKeepLast:
+		return true
+	case DedupeKeepLowestPrice:
+		return candidate.Preco < current.Preco
+	case DedupeKeepHighestPrice:
+		return candidate.Preco > current.Preco
+	default:
+		return false
+	}
+}
+
+// NormalizeDescriptionRule trims and collapses whitespace in group and item
+// descriptions, uppercasing them when Uppercase is set
+type NormalizeDescriptionRule struct {
+	Uppercase bool
+}
+
+// Name returns the rule name
+func (NormalizeDescriptionRule) Name() string { return RuleNormalizeDescription }
+
+// Apply normalizes group and item descriptions
+func (r NormalizeDescriptionRule) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult {
+	var result entities.PromotionRuleResult
+
+	for i := range data.Grupos {
+		if desc := r.normalize(data.Grupos[i].Desc); desc != data.Grupos[i].Desc {
+			data.Grupos[i].Desc = desc
+			result.ItemsModified++
+		}
+		for j := range data.Grupos[i].Items {
+			item := &data.Grupos[i].Items[j]
+			if desc := r.normalize(item.Desc); desc != item.Desc {
+				item.Desc = desc
+				result.ItemsModified++
+			}
+		}
+	}
+
+	return result
+}
+
+// normalize trims, collapses inner whitespace and optionally uppercases a description
+func (r NormalizeDescriptionRule) normalize(desc string) string {
+	desc = strings.Join(strings.Fields(desc), " ")
+	if r.Uppercase {
+		desc = strings.ToUpper(desc)
+	}
+	return desc
+}
+
+// RecomputeQtdeItemRule sets qtdeItem to the number of items in each group
+type RecomputeQtdeItemRule struct{}
+
+// Name returns the rule name
+func (RecomputeQtdeItemRule) Name() string { return RuleRecomputeQtdeItem }
+
+// Apply updates qtdeItem of groups whose count does not match their items
+func (RecomputeQtdeItemRule) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult {
+	var result entities.PromotionRuleResult
+
+	for i := range data.Grupos {
+		if data.Grupos[i].QtdeItem != len(data.Grupos[i].Items) {
+			data.Grupos[i].QtdeItem = len(data.Grupos[i].Items)
+			result.ItemsModified++
+		}
+	}
+
+	return result
+}
+
+// PriceOutlierRule flags items whose price is not positive or differs from the
+// group median by more than Factor times. It never changes the JSON.
+type PriceOutlierRule struct {
+	Factor float64
+}
+
+// Name returns the rule name
+func (PriceOutlierRule) Name() string { return RulePriceOutlier }
+
+// Apply reports price outliers in every group
+func (r PriceOutlierRule) Apply(record *entities.PromotionNormalization, data *entities.PromotionJsonData) entities.PromotionRuleResult {
+	var result entities.PromotionRuleResult
+
+	for _, grupo := range data.Grupos {
+		median := medianPrice(grupo.Items)
+
+		for _, item := range grupo.Items {
+			var message string
+			switch {
+			case item.Preco <= 0:
+				message = "Preço não positivo"
+			case median > 0 && item.Preco > median*r.Factor:
+				message = fmt.Sprintf("Preço %.2f acima de %.1fx a mediana do grupo (%.2f)", item.Preco, r.Factor, median)
+			case median > 0 && item.Preco < median/r.Factor:
+				message = fmt.Sprintf("Preço %.2f abaixo de 1/%.1f da mediana do grupo (%.2f)", item.Preco, r.Factor, median)
+			default:
+				continue
+			}
+
+			result.ItemsFlagged++
+			result.Findings = append(result.Findings, newPromotionRuleFinding(record, grupo, item, message))
+		}
+	}
+
+	return result
+}
+
+// medianPrice returns the median of the positive prices of the items
+func medianPrice(items []entities.PromotionGroupItem) float64 {
+	var prices []float64
+	for _, item := range items {
+		if item.Preco > 0 {
+			prices = append(prices, item.Preco)
+		}
+	}
+	if len(prices) == 0 {
+		return 0
+	}
+
+	sort.Float64s(prices)
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return (prices[mid-1] + prices[mid]) / 2
+	}
+	return prices[mid]
+}
@@ -1,37 +1,84 @@
 package usecases
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/thiagohmm/integracaocron/domain/entities"
 	"github.com/thiagohmm/integracaocron/domain/repositories"
 )
 
+// maxPromotionUpdateAttempts is the number of times a record is normalized when its update
+// keeps losing races against concurrent writers
+const maxPromotionUpdateAttempts = 3
+
 // PromotionNormalizationUseCase handles promotion normalization business logic
 type PromotionNormalizationUseCase struct {
-	repo *repositories.PromotionNormalizationRepository
-	db   *sql.DB
+	repo          *repositories.PromotionNormalizationRepository
+	parameterRepo entities.ParameterRepository
+	db            *sql.DB
+	pipeline      *PromotionNormalizationPipeline
+	pageSize      int
+	outbox        *LogOutbox
 }
 
 // NewPromotionNormalizationUseCase creates a new instance of PromotionNormalizationUseCase
-func NewPromotionNormalizationUseCase(repo *repositories.PromotionNormalizationRepository, db *sql.DB) *PromotionNormalizationUseCase {
+func NewPromotionNormalizationUseCase(
+	repo *repositories.PromotionNormalizationRepository,
+	parameterRepo entities.ParameterRepository,
+	db *sql.DB,
+) *PromotionNormalizationUseCase {
 	return &PromotionNormalizationUseCase{
-		repo: repo,
-		db:   db,
+		repo:          repo,
+		parameterRepo: parameterRepo,
+		db:            db,
+		pipeline:      DefaultPromotionNormalizationPipeline(),
+		pageSize:      entities.DEFAULT_PROMOTION_NORMALIZATION_PAGE_SIZE,
+	}
+}
+
+// SetPageSize sets the number of records read per page
+func (uc *PromotionNormalizationUseCase) SetPageSize(pageSize int) {
+	if pageSize > 0 {
+		uc.pageSize = pageSize
+	}
+}
+
+// SetPipeline replaces the normalization rules applied to each record
+func (uc *PromotionNormalizationUseCase) SetPipeline(pipeline *PromotionNormalizationPipeline) {
+	if pipeline != nil {
+		uc.pipeline = pipeline
 	}
 }
 
-// NormalizePromotions is the main function that normalizes promotion data
-func (uc *PromotionNormalizationUseCase) NormalizePromotions() (*entities.PromotionNormalizationResult, error) {
+// SetLogOutbox makes the update of a record and its log be committed together
+func (uc *PromotionNormalizationUseCase) SetLogOutbox(outbox *LogOutbox) {
+	uc.outbox = outbox
+}
+
+// NormalizePromotions is the main function that normalizes promotion data.
+// Only records changed since the last successful run are processed.
+func (uc *PromotionNormalizationUseCase) NormalizePromotions(ctx context.Context) (*entities.PromotionNormalizationResult, error) {
+	return uc.NormalizePromotionsWithOptions(ctx, entities.PromotionNormalizationOptions{})
+}
+
+// NormalizePromotionsWithOptions normalizes promotion data using the given options
+func (uc *PromotionNormalizationUseCase) NormalizePromotionsWithOptions(ctx context.Context, opts entities.PromotionNormalizationOptions) (*entities.PromotionNormalizationResult, error) {
 	log.Println(entities.MSG_START_IMPORT_PROMOTION_RMS)
 	defer log.Println(entities.MSG_END_IMPORT_PROMOTION_RMS)
 
+	if opts.IdExecucao == "" {
+		opts.IdExecucao = entities.NewIdExecucao()
+	}
+
 	// Begin transaction
-	tx, err := uc.db.Begin()
+	tx, err := uc.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
 	}
@@ -42,7 +89,7 @@ func (uc *PromotionNormalizationUseCase) NormalizePromotions() (*entities.Promot
 		}
 	}()
 
-	result, err := uc.normalizeProducts()
+	result, err := uc.normalizeProducts(ctx, opts)
 	if err != nil {
 		tx.Rollback()
 		log.Printf("Erro durante a transação: %v", err)
@@ -56,11 +103,16 @@ func (uc *PromotionNormalizationUseCase) NormalizePromotions() (*entities.Promot
 	return result, nil
 }
 
-// normalizeProducts processes all promotion records and removes duplicates
-func (uc *PromotionNormalizationUseCase) normalizeProducts() (*entities.PromotionNormalizationResult, error) {
+// normalizeProducts processes the promotion records page by page and removes duplicates
+func (uc *PromotionNormalizationUseCase) normalizeProducts(ctx context.Context, opts entities.PromotionNormalizationOptions) (*entities.PromotionNormalizationResult, error) {
 	result := &entities.PromotionNormalizationResult{
 		Success: true,
+		Mode:    entities.PROMOTION_NORMALIZATION_MODE_INCREMENTAL,
+	}
+	if opts.Full || opts.IsScoped() {
+		result.Mode = entities.PROMOTION_NORMALIZATION_MODE_FULL
 	}
+	result.DryRun = opts.DryRun
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -74,63 +126,199 @@ func (uc *PromotionNormalizationUseCase) normalizeProducts() (*entities.Promotio
 				"INTEGRACAOPROMOCAOSTAGING",
 				fmt.Sprintf("Panic during normalization: %v", r),
 				map[string]interface{}{"error": fmt.Sprintf("%v", r)},
+				opts.IdExecucao,
 			)
 			uc.repo.SendToQueue(errorMsg)
 		}
 	}()
 
-	// Get all records from the staging table
-	allRecords, err := uc.repo.GetAllRecords()
+	// Taken before reading so changes made during the run are picked up by the next one
+	runStartedAt, err := uc.repo.GetDatabaseTime(ctx)
 	if err != nil {
-		errMsg := fmt.Sprintf("Erro ao obter registros: %v", err)
-		log.Println(errMsg)
+		return nil, uc.reportReadError(err, opts.IdExecucao)
+	}
 
-		errorLog := uc.repo.CreateErrorLogMessage(
-			"UPDATE",
-			"INTEGRACAOPROMOCAOSTAGING",
-			errMsg,
-			map[string]interface{}{"error": err.Error()},
-		)
-		uc.repo.SendToQueue(errorLog)
+	filter := entities.PromotionNormalizationFilter{
+		IdRevendedores: opts.IdRevendedores,
+		IdPromocoes:    opts.IdPromocoes,
+	}
+	if result.Mode == entities.PROMOTION_NORMALIZATION_MODE_INCREMENTAL {
+		filter.ChangedSince, err = uc.getWatermark(ctx)
+		if err != nil {
+			return nil, uc.reportReadError(err, opts.IdExecucao)
+		}
+	}
+	result.Watermark = filter.ChangedSince
 
-		return nil, fmt.Errorf("erro ao obter registros: %w", err)
+	pageSize := uc.pageSize
+	if opts.PageSize > 0 {
+		pageSize = opts.PageSize
 	}
 
-	log.Printf("Total records to process: %d", len(allRecords))
+	if filter.ChangedSince != nil {
+		log.Printf("Normalização incremental - registros alterados desde %s, páginas de %d", filter.ChangedSince.Format(time.RFC3339), pageSize)
+	} else {
+		log.Printf("Normalização completa - páginas de %d", pageSize)
+	}
+	if opts.IsScoped() {
+		log.Printf("Normalização restrita - revendedores: %v, promoções: %v, codMix: %v", opts.IdRevendedores, opts.IdPromocoes, opts.CodMix)
+	}
+	if opts.DryRun {
+		log.Printf("Normalização em modo dry-run - nenhum registro será atualizado")
+	}
+	log.Printf("Regras de normalização habilitadas: %v", uc.pipeline.EnabledRules())
 
-	processedCount := 0
-	updatedCount := 0
-	totalRemovedDuplicates := 0
+	// Rows skipped while being sent must be read again by the next incremental run
+	var oldestSkipped *time.Time
+	skippedUndated := false
 
-	for _, record := range allRecords {
-		processError := uc.processRecord(&record, &processedCount, &updatedCount, &totalRemovedDuplicates)
-		if processError != nil {
-			log.Printf("Error processing record %d: %v", *record.IdIntegracaoPromocao, processError)
-			// Continue processing other records even if one fails
+	lastID := 0
+	for {
+		records, err := uc.repo.GetRecordsPage(ctx, lastID, pageSize, filter)
+		if err != nil {
+			return nil, uc.reportReadError(err, opts.IdExecucao)
+		}
+		if len(records) == 0 {
+			break
+		}
+		result.PagesRead++
+
+		for _, record := range records {
+			skippedBefore := result.SkippedSendingCount
+			processError := uc.processRecord(ctx, &record, opts, result)
+			if result.SkippedSendingCount > skippedBefore {
+				switch {
+				case record.DataAtualizacao == nil:
+					skippedUndated = true
+				case oldestSkipped == nil || record.DataAtualizacao.Before(*oldestSkipped):
+					oldestSkipped = record.DataAtualizacao
+				}
+			}
+			if processError != nil {
+				log.Printf("Error processing record %d: %v", *record.IdIntegracaoPromocao, processError)
+				result.FailedCount++
+				// Continue processing other records even if one fails
+			}
+
+			// Log progress every 100 records
+			if result.ProcessedCount%100 == 0 {
+				log.Printf("Processados %d registros, %d atualizados", result.ProcessedCount, result.UpdatedCount)
+			}
 		}
 
-		// Log progress every 100 records
-		if processedCount%100 == 0 {
-			log.Printf("Processados %d registros, %d atualizados", processedCount, updatedCount)
+		lastID = getIntValue(records[len(records)-1].IdIntegracaoPromocao)
+		if len(records) < pageSize {
+			break
 		}
 	}
 
-	log.Printf("Processamento concluído. Total processados: %d, Total atualizados: %d", processedCount, updatedCount)
+	log.Printf("Processamento concluído. Total processados: %d, Total atualizados: %d, Páginas: %d", result.ProcessedCount, result.UpdatedCount, result.PagesRead)
+
+	// Failed records keep the previous watermark so they are retried on the next run, and
+	// records skipped while being sent cap it below their DATA_ATUALIZACAO, since clearing
+	// ENVIANDO does not touch it. Scoped and dry-run executions do not cover the whole table
+	// and never move it.
+	if opts.DryRun || opts.IsScoped() {
+		log.Printf("Marca d'água mantida: execução restrita ou dry-run")
+	} else if result.FailedCount > 0 {
+		log.Printf("Marca d'água mantida: %d registros com erro serão reprocessados", result.FailedCount)
+	} else if skippedUndated {
+		log.Printf("Marca d'água mantida: registros em envio sem DATA_ATUALIZACAO serão reprocessados")
+	} else {
+		watermark := runStartedAt
+		if oldestSkipped != nil {
+			watermark = skippedWatermark(*oldestSkipped, filter.ChangedSince)
+			log.Printf("Marca d'água limitada a %s: %d registros em envio serão reprocessados",
+				watermark.Format(time.RFC3339), result.SkippedSendingCount)
+		}
+		if watermark.After(runStartedAt) {
+			watermark = runStartedAt
+		}
+		if err := uc.setWatermark(ctx, watermark); err != nil {
+			log.Printf("Erro ao gravar marca d'água da normalização: %v", err)
+		}
+	}
 
-	result.ProcessedCount = processedCount
-	result.UpdatedCount = updatedCount
-	result.TotalRemovedDuplicates = totalRemovedDuplicates
-	result.Message = fmt.Sprintf("Processamento concluído. Total processados: %d, Total atualizados: %d", processedCount, updatedCount)
+	result.Message = fmt.Sprintf("Processamento concluído. Total processados: %d, Total atualizados: %d", result.ProcessedCount, result.UpdatedCount)
 
 	return result, nil
 }
 
-// processRecord processes a single promotion record
+// reportReadError logs and sends to queue an error reading the staging table
+func (uc *PromotionNormalizationUseCase) reportReadError(err error, idExecucao string) error {
+	errMsg := fmt.Sprintf("Erro ao obter registros: %v", err)
+	log.Println(errMsg)
+
+	errorLog := uc.repo.CreateErrorLogMessage(
+		"UPDATE",
+		"INTEGRACAOPROMOCAOSTAGING",
+		errMsg,
+		map[string]interface{}{"error": err.Error()},
+		idExecucao,
+	)
+	uc.repo.SendToQueue(errorLog)
+
+	return fmt.Errorf("erro ao obter registros: %w", err)
+}
+
+// getWatermark returns the start time of the last successful run, or nil when there is none
+func (uc *PromotionNormalizationUseCase) getWatermark(ctx context.Context) (*time.Time, error) {
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, entities.PARAM_PROMOCAO_NORMALIZACAO_ULTIMA_EXECUCAO)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter marca d'água: %w", err)
+	}
+	if param == nil || param.Valor == "" {
+		return nil, nil
+	}
+
+	watermark, err := time.Parse(time.RFC3339Nano, param.Valor)
+	if err != nil {
+		log.Printf("Marca d'água inválida '%s', executando normalização completa", param.Valor)
+		return nil, nil
+	}
+	return &watermark, nil
+}
+
+// skippedWatermark returns a watermark that keeps a record changed at oldest in the next
+// incremental run: one second earlier, so the DATA_ATUALIZACAO > :watermark comparison holds
+// whatever the precision of the column, but never before the previous watermark
+func skippedWatermark(oldest time.Time, previous *time.Time) time.Time {
+	watermark := oldest.Add(-time.Second)
+	if previous != nil && watermark.Before(*previous) {
+		return *previous
+	}
+	return watermark
+}
+
+// setWatermark stores the point the next incremental run reads changes from
+func (uc *PromotionNormalizationUseCase) setWatermark(ctx context.Context, watermark time.Time) error {
+	param, err := uc.parameterRepo.ListByCodeParameter(ctx, entities.PARAM_PROMOCAO_NORMALIZACAO_ULTIMA_EXECUCAO)
+	if err != nil {
+		return err
+	}
+
+	valor := watermark.Format(time.RFC3339Nano)
+	if param == nil {
+		_, err = uc.parameterRepo.Create(ctx, &entities.IParameter{
+			Ambiente:  "*",
+			Codigo:    entities.PARAM_PROMOCAO_NORMALIZACAO_ULTIMA_EXECUCAO,
+			Valor:     valor,
+			Descricao: "Início da última normalização de promoções concluída sem erros",
+		})
+		return err
+	}
+
+	param.Valor = valor
+	return uc.parameterRepo.Update(ctx, param)
+}
+
+// processRecord processes a single promotion record. Records being sent are skipped and
+// updates that lose a race against a concurrent writer are retried with fresh data.
 func (uc *PromotionNormalizationUseCase) processRecord(
+	ctx context.Context,
 	record *entities.PromotionNormalization,
-	processedCount *int,
-	updatedCount *int,
-	totalRemovedDuplicatesGlobal *int,
+	opts entities.PromotionNormalizationOptions,
+	result *entities.PromotionNormalizationResult,
 ) error {
 	defer func() {
 		if r := recover(); r != nil {
@@ -138,20 +326,73 @@ func (uc *PromotionNormalizationUseCase) processRecord(
 		}
 	}()
 
-	*processedCount++
+	for attempt := 1; ; attempt++ {
+		if isSending(record.Enviando) {
+			log.Printf("Registro %d em envio (ENVIANDO=%s) - ignorado", getIntValue(record.IdIntegracaoPromocao), *record.Enviando)
+			result.SkippedSendingCount++
+			return nil
+		}
+
+		matched, err := uc.normalizeRecord(ctx, record, opts, result)
+		if errors.Is(err, repositories.ErrPromotionRecordConflict) {
+			result.ConflictCount++
+			if attempt >= maxPromotionUpdateAttempts {
+				result.ProcessedCount++
+				return fmt.Errorf("registro %d alterado concorrentemente em %d tentativas: %w",
+					getIntValue(record.IdIntegracaoPromocao), attempt, err)
+			}
+
+			log.Printf("Registro %d alterado concorrentemente - relendo (tentativa %d de %d)",
+				getIntValue(record.IdIntegracaoPromocao), attempt+1, maxPromotionUpdateAttempts)
+
+			fresh, err := uc.repo.GetRecordByID(ctx, getIntValue(record.IdIntegracaoPromocao))
+			if err != nil {
+				result.ProcessedCount++
+				return err
+			}
+			if fresh == nil {
+				log.Printf("Registro %d removido durante a normalização - ignorado", getIntValue(record.IdIntegracaoPromocao))
+				result.SkippedCount++
+				return nil
+			}
+			*record = *fresh
+			continue
+		}
+
+		if matched || err != nil {
+			result.ProcessedCount++
+		} else {
+			result.SkippedCount++
+		}
+		return err
+	}
+}
 
+// normalizeRecord applies the normalization rules to a record and updates it when it changed.
+// It reports false when the record is outside the requested codMix scope.
+func (uc *PromotionNormalizationUseCase) normalizeRecord(
+	ctx context.Context,
+	record *entities.PromotionNormalization,
+	opts entities.PromotionNormalizationOptions,
+	result *entities.PromotionNormalizationResult,
+) (bool, error) {
 	// Parse the JSON field
 	jsonData, err := uc.parseRecordJSON(record)
 	if err != nil {
 		log.Printf("Erro ao fazer parse do JSON para registro %d: %v", *record.IdIntegracaoPromocao, err)
-		return err
+		return true, err
+	}
+
+	if !matchesCodMix(jsonData.CodMix, opts.CodMix) {
+		return false, nil
 	}
 
 	log.Printf("Processing record: %d", *record.IdIntegracaoPromocao)
 	log.Printf("Parsed JSON - CodMix: %s, Grupos count: %d", jsonData.CodMix, len(jsonData.Grupos))
 
-	// Normalize groups (remove duplicates)
-	hasChanges, totalRemovedDuplicates := uc.repo.NormalizePromotionGroups(jsonData)
+	// Run the normalization rules
+	hasChanges, ruleResults := uc.pipeline.Apply(record, jsonData)
+	totalRemovedDuplicates := removedDuplicates(ruleResults)
 
 	// If changes were made, update the record
 	if hasChanges {
@@ -160,25 +401,34 @@ func (uc *PromotionNormalizationUseCase) processRecord(
 		updatedJSON, err := json.Marshal(jsonData)
 		if err != nil {
 			log.Printf("Error marshaling updated JSON: %v", err)
-			return err
+			return true, err
 		}
 
 		log.Printf("updatedJson: %s", string(updatedJSON))
 
-		// Update DataAtualizacao
-		now := time.Now()
-		record.DataAtualizacao = &now
-
-		// Update the record with the corrected JSON
-		err = uc.repo.UpdateRecord(*record, string(updatedJSON))
-		if err != nil {
-			log.Printf("Error updating record: %v", err)
-			return err
+		if opts.DryRun {
+			result.UpdatedCount++
+			result.TotalRemovedDuplicates += totalRemovedDuplicates
+			result.RuleResults = MergePromotionRuleResults(result.RuleResults, ruleResults)
+			if len(result.Diffs) >= entities.MAX_PROMOTION_NORMALIZATION_DIFFS {
+				result.DiffsOmitted++
+				log.Println("Dry-run - record not updated")
+				return true, nil
+			}
+			result.Diffs = append(result.Diffs, entities.PromotionNormalizationDiff{
+				IdIntegracaoPromocao: getIntValue(record.IdIntegracaoPromocao),
+				IdRevendedor:         getIntValue(record.IdRevendedor),
+				IdPromocao:           getIntValue(record.IdPromocao),
+				CodMix:               jsonData.CodMix,
+				RemovedDuplicates:    totalRemovedDuplicates,
+				Before:               rawJSON(record.JSON),
+				After:                updatedJSON,
+				RuleResults:          ruleResults,
+			})
+			log.Println("Dry-run - record not updated")
+			return true, nil
 		}
 
-		*updatedCount++
-		*totalRemovedDuplicatesGlobal += totalRemovedDuplicates
-
 		// Log the update
 		logData := entities.PromotionNormalizationLog{
 			IdIntegracaoPromocao: getIntValue(record.IdIntegracaoPromocao),
@@ -186,20 +436,67 @@ func (uc *PromotionNormalizationUseCase) processRecord(
 			IdRevendedor:         getIntValue(record.IdRevendedor),
 			CodMix:               jsonData.CodMix,
 			RemovedDuplicates:    totalRemovedDuplicates,
+			RuleResults:          ruleResults,
 		}
 
 		logSucesso := uc.repo.CreateLogMessage(
 			"UPDATE",
 			"INTEGRACAOPROMOCAOSTAGING",
-			fmt.Sprintf("Itens duplicados removidos dos grupos. Total removidos: %d", totalRemovedDuplicates),
+			fmt.Sprintf("Promoção normalizada. Duplicados removidos: %d. Regras: %s", totalRemovedDuplicates, describeRuleResults(ruleResults)),
 			logData,
+			opts.IdExecucao,
 		)
-		uc.repo.SendToQueue(logSucesso)
+
+		// Update the record with the corrected JSON, only if nobody changed it since it was read
+		err = uc.updateRecord(ctx, *record, string(updatedJSON), logSucesso)
+		if err != nil {
+			log.Printf("Error updating record: %v", err)
+			return true, err
+		}
+
+		result.UpdatedCount++
+		result.TotalRemovedDuplicates += totalRemovedDuplicates
+		result.RuleResults = MergePromotionRuleResults(result.RuleResults, ruleResults)
 	} else {
+		result.RuleResults = MergePromotionRuleResults(result.RuleResults, ruleResults)
 		log.Println("No changes detected - record not updated")
 	}
 
-	return nil
+	return true, nil
+}
+
+// updateRecord writes the normalized JSON and records logMessage. With an outbox both are
+// committed in the same transaction; otherwise the log is published after the update.
+func (uc *PromotionNormalizationUseCase) updateRecord(ctx context.Context, record entities.PromotionNormalization, updatedJSON string, logMessage entities.LogEvent) error {
+	if uc.outbox == nil {
+		if err := uc.repo.UpdateRecord(ctx, record, updatedJSON, time.Now()); err != nil {
+			return err
+		}
+		uc.repo.SendToQueue(logMessage)
+		return nil
+	}
+
+	tx, err := uc.outbox.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	if err := uc.repo.WithTx(tx).UpdateRecord(ctx, record, updatedJSON, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return uc.outbox.CommitWithLog(ctx, tx, logMessage, uc.repo.SendToQueue)
+}
+
+// isSending reports whether the ENVIANDO flag marks the record as being sent to the stores
+func isSending(enviando *string) bool {
+	if enviando == nil {
+		return false
+	}
+	switch strings.ToUpper(strings.TrimSpace(*enviando)) {
+	case "", "0", "N":
+		return false
+	}
+	return true
 }
 
 // parseRecordJSON parses the JSON field from a record
@@ -221,6 +518,54 @@ func (uc *PromotionNormalizationUseCase) parseRecordJSON(record *entities.Promot
 	return jsonData, nil
 }
 
+// matchesCodMix reports whether codMix is one of the requested values, or no value was requested
+func matchesCodMix(codMix string, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, value := range wanted {
+		if strings.TrimSpace(value) == strings.TrimSpace(codMix) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawJSON returns the stored JSON as a raw message, quoting it when it is not valid JSON
+func rawJSON(value string) json.RawMessage {
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	quoted, _ := json.Marshal(value)
+	return quoted
+}
+
+// removedDuplicates returns the number of items removed by the barcode dedupe rule
+func removedDuplicates(results []entities.PromotionRuleResult) int {
+	for _, result := range results {
+		if result.Rule == RuleDedupeBarcode {
+			return result.ItemsRemoved
+		}
+	}
+	return 0
+}
+
+// describeRuleResults summarizes the rules that changed or flagged the record
+func describeRuleResults(results []entities.PromotionRuleResult) string {
+	var parts []string
+	for _, result := range results {
+		if result.RecordsAffected == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s(removidos=%d, modificados=%d, sinalizados=%d)",
+			result.Rule, result.ItemsRemoved, result.ItemsModified, result.ItemsFlagged))
+	}
+	if len(parts) == 0 {
+		return "nenhuma alteração"
+	}
+	return strings.Join(parts, ", ")
+}
+
 // getIntValue safely gets int value from pointer
 func getIntValue(val *int) int {
 	if val == nil {
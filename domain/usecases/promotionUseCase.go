@@ -1,14 +1,14 @@
 package usecases
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
-	"github.com/streadway/amqp"
 	"github.com/thiagohmm/integracaocron/domain/entities"
-	"github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
 )
 
 // PromotionUseCase handles promotion integration business logic
@@ -16,13 +16,8 @@ type PromotionUseCase struct {
 	promotionRepo    entities.PromotionRepository
 	rabbitmqURL      string
 	integrationJobUC *IntegrationJobUseCase
-}
-
-// LogIntegrRMS represents the log structure for integration messages
-type LogIntegrRMS struct {
-	Tabela string        `json:"tabela"`
-	Fields []string      `json:"fields"`
-	Values []interface{} `json:"values"`
+	logPublisher     entities.LogPublisher
+	outbox           *LogOutbox
 }
 
 // NewPromotionUseCase creates a new instance of PromotionUseCase
@@ -31,29 +26,44 @@ func NewPromotionUseCase(promotionRepo entities.PromotionRepository, rabbitmqURL
 		promotionRepo:    promotionRepo,
 		rabbitmqURL:      rabbitmqURL,
 		integrationJobUC: integrationJobUC,
+		logPublisher:     NewLogPublisher(rabbitmqURL, nil),
+	}
+}
+
+// SetLogPublisher replaces the publisher used for LogIntegrRMS messages
+func (uc *PromotionUseCase) SetLogPublisher(publisher entities.LogPublisher) {
+	if publisher != nil {
+		uc.logPublisher = publisher
 	}
 }
 
+// SetLogOutbox makes promotion logs be written to the outbox in the procedure transaction
+func (uc *PromotionUseCase) SetLogOutbox(outbox *LogOutbox) {
+	uc.outbox = outbox
+}
+
 // ProcessarPromocao processes promotion data from RabbitMQ message
 // This method can be called from the listener
-func (uc *PromotionUseCase) ProcessarPromocao(dados entities.Promotion) error {
+func (uc *PromotionUseCase) ProcessarPromocao(ctx context.Context, dados entities.Promotion) error {
 	log.Printf("Iniciando processamento de promoção com dados: %+v", dados)
 
 	// Call the main integration processing
-	return uc.ProcessIntegrationPromotions(dados)
+	return uc.ProcessIntegrationPromotions(ctx, dados)
 }
 
 // ProcessIntegrationPromotions processes all pending promotion integrations
 // This is the Go equivalent of the TypeScript function you provided
-func (uc *PromotionUseCase) ProcessIntegrationPromotions(dados entities.Promotion) error {
+func (uc *PromotionUseCase) ProcessIntegrationPromotions(ctx context.Context, dados entities.Promotion) error {
 	// Process the individual promotion
-	uc.processIndividualPromotion(dados)
+	if err := uc.processIndividualPromotion(ctx, dados); err != nil {
+		return err
+	}
 
 	// Call the integration job at the end (equivalent to productNetworkMain)
 	if uc.integrationJobUC != nil {
 		log.Println("Chamando job de integração no final do processamento de promoção...")
 		dataCorte := time.Now()
-		if err := uc.integrationJobUC.ProductNetworkMain(dataCorte); err != nil {
+		if err := uc.integrationJobUC.ProductNetworkMain(ctx, dataCorte); err != nil {
 			log.Printf("Erro ao executar job de integração: %v", err)
 			// Don't return error here to avoid failing the main promotion processing
 			// The integration job error will be logged but won't affect the promotion result
@@ -63,159 +73,131 @@ func (uc *PromotionUseCase) ProcessIntegrationPromotions(dados entities.Promotio
 	return nil
 }
 
-// processIndividualPromotion processes a single promotion with error handling
-func (uc *PromotionUseCase) processIndividualPromotion(promo entities.Promotion) {
+// processIndividualPromotion processes a single promotion with error handling. It only
+// returns an error when the promotion was left untouched to be processed later.
+func (uc *PromotionUseCase) processIndividualPromotion(ctx context.Context, promo entities.Promotion) error {
+	idExecucao := entities.NewIdExecucao()
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Recovered from panic while processing promotion %d: %v", promo.IPMD_ID, r)
-			uc.handlePromotionError(promo, fmt.Errorf("panic: %v", r))
+			uc.handlePromotionError(ctx, promo, fmt.Errorf("panic: %v", r), idExecucao)
 		}
 	}()
 
-	// Call the dopkg_promotion function (equivalent to the TypeScript version)
-	promocao, err := uc.promotionRepo.Dopkg_promotion(promo.IPMD_ID)
-	if err != nil {
-		log.Printf("Erro ao processar promoção %d: %v", promo.IPMD_ID, err)
-		uc.handlePromotionError(promo, err)
-		return
-	}
-
-	log.Printf("promocao: %+v", promocao)
-
-	// Delete the processed promotion (equivalent to deletePorObjeto)
-	err = uc.deletePorObjeto(promo.IPMD_ID)
-	if err != nil {
-		log.Printf("Erro ao deletar promoção %d: %v", promo.IPMD_ID, err)
-		// Continue processing and log the success/failure of the main operation
-	}
+	return uc.runWithLog(ctx, func(repo entities.PromotionRepository) (entities.LogEvent, error) {
+		// Call the dopkg_promotion function (equivalent to the TypeScript version)
+		promocao, err := repo.Dopkg_promotion(ctx, promo.IPMD_ID)
+		if errors.Is(err, entities.ErrCircuitOpen) {
+			log.Printf("Promoção %d adiada: %v", promo.IPMD_ID, err)
+			return entities.LogEvent{}, err
+		}
+		if err != nil {
+			log.Printf("Erro ao processar promoção %d: %v", promo.IPMD_ID, err)
+			return uc.promotionErrorLog(ctx, repo, promo, err, idExecucao), nil
+		}
 
-	// Create success/failure log
-	var statusProcessamento int
-	var descricaoErro string
+		log.Printf("promocao: %+v", promocao)
 
-	if promocao.Success {
-		statusProcessamento = 0
-		descricaoErro = "Processamento realizado com sucesso."
-	} else {
-		statusProcessamento = 1
-		descricaoErro = promocao.Message
-	}
+		// Delete the processed promotion (equivalent to deletePorObjeto)
+		err = repo.DeletePorObjeto(ctx, promo.IPMD_ID)
+		if err != nil {
+			log.Printf("Erro ao deletar promoção %d: %v", promo.IPMD_ID, err)
+			// Continue processing and log the success/failure of the main operation
+		}
 
-	// Convert promotion to JSON string
-	promoJSON, _ := json.Marshal(promo)
+		// Create success/failure log
+		descricaoErro := promocao.Message
+		if promocao.Success {
+			descricaoErro = "Processamento realizado com sucesso."
+		}
 
-	logSucesso := LogIntegrRMS{
-		Tabela: "LogIntegrRMS",
-		Fields: []string{"TRANSACAO", "TABELA", "DATARECEBIMENTO", "DATAPROCESSAMENTO", "STATUSPROCESSAMENTO", "JSON", "DESCRICAOERRO"},
-		Values: []interface{}{
-			"IN",
-			"PROMOCAO",
-			promo.DATARECEBIMENTO,
-			time.Now().Format("2006-01-02 15:04:05"),
-			statusProcessamento,
-			string(promoJSON),
-			descricaoErro,
-		},
-	}
+		// Convert promotion to JSON string
+		promoJSON, _ := json.Marshal(promo)
 
-	uc.sendToQueue(logSucesso)
+		return entities.NewLogEvent("IN", "PROMOCAO", promotionReceivedAt(promo), entities.LogEventStatusOf(promocao.Success),
+			string(promoJSON), descricaoErro, idExecucao), nil
+	})
 }
 
 // handlePromotionError handles errors that occur during promotion processing
-func (uc *PromotionUseCase) handlePromotionError(promo entities.Promotion, err error) {
+func (uc *PromotionUseCase) handlePromotionError(ctx context.Context, promo entities.Promotion, err error, idExecucao string) {
+	uc.runWithLog(ctx, func(repo entities.PromotionRepository) (entities.LogEvent, error) {
+		return uc.promotionErrorLog(ctx, repo, promo, err, idExecucao), nil
+	})
+}
+
+// promotionErrorLog deletes the problematic promotion and returns its error log
+func (uc *PromotionUseCase) promotionErrorLog(ctx context.Context, repo entities.PromotionRepository, promo entities.Promotion, err error, idExecucao string) entities.LogEvent {
 	log.Printf("Erro ao processar promoção: %v", err)
 
 	// Delete the problematic promotion
-	deleteErr := uc.deletePorObjeto(promo.IPMD_ID)
+	deleteErr := repo.DeletePorObjeto(ctx, promo.IPMD_ID)
 	if deleteErr != nil {
 		log.Printf("Erro ao deletar promoção com erro %d: %v", promo.IPMD_ID, deleteErr)
 	}
 
-	// Convert prom otion to JSON string
+	// Convert promotion to JSON string
 	promoJSON, _ := json.Marshal(promo)
 
-	// Create error log
-	dataRecebimento := promo.DATARECEBIMENTO
-	if dataRecebimento == "" {
-		dataRecebimento = time.Now().Format("2006-01-02 15:04:05")
-	}
-
-	logErro := LogIntegrRMS{
-		Tabela: "LogIntegrRMS",
-		Fields: []string{"TRANSACAO", "TABELA", "DATARECEBIMENTO", "DATAPROCESSAMENTO", "STATUSPROCESSAMENTO", "JSON", "DESCRICAOERRO"},
-		Values: []interface{}{
-			"IN",
-			"PROMOCAO",
-			dataRecebimento,
-			time.Now().Format("2006-01-02 15:04:05"),
-			1,
-			string(promoJSON),
-			fmt.Sprintf("%v", err),
-		},
-	}
+	logErro := entities.NewLogEvent("IN", "PROMOCAO", promotionReceivedAt(promo), entities.LOG_EVENT_STATUS_ERROR,
+		string(promoJSON), fmt.Sprintf("%v", err), idExecucao)
 
-	log.Printf("Log de erro sendo enviado para a fila: %+v", logErro)
-	uc.sendToQueue(logErro)
+	log.Printf("Log de erro sendo registrado: %+v", logErro)
+	return logErro
 }
 
-// deletePorObjeto deletes a promotion record by IPMD_ID
-func (uc *PromotionUseCase) deletePorObjeto(ipmID int) error {
-	return uc.promotionRepo.DeletePorObjeto(ipmID)
+// promotionReceivedAt parses DATARECEBIMENTO, returning the zero time when it is empty or invalid
+func promotionReceivedAt(promo entities.Promotion) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339Nano} {
+		if t, err := time.ParseInLocation(layout, promo.DATARECEBIMENTO, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
 }
 
-// sendToQueue sends a log message to RabbitMQ queue
-func (uc *PromotionUseCase) sendToQueue(logData LogIntegrRMS) {
-	// Get RabbitMQ connection
-	conn, err := rabbitmq.GetRabbitMQConnection(uc.rabbitmqURL)
-	if err != nil {
-		log.Printf("Erro ao conectar ao RabbitMQ: %v", err)
-		return
+// runWithLog runs fn and records the log it returns. With an outbox fn runs on a repository
+// bound to a transaction that also receives the log, so the procedure, the deletion and the
+// log are committed together; otherwise the log is published once fn returns. When fn
+// returns an error nothing is logged and the transaction is rolled back.
+func (uc *PromotionUseCase) runWithLog(ctx context.Context, fn func(repo entities.PromotionRepository) (entities.LogEvent, error)) error {
+	if uc.outbox == nil {
+		event, err := fn(uc.promotionRepo)
+		if err != nil {
+			return err
+		}
+		uc.sendToQueue(event)
+		return nil
 	}
 
-	// Create channel
-	ch, err := conn.Channel()
+	tx, err := uc.outbox.Begin(ctx)
 	if err != nil {
-		log.Printf("Erro ao criar canal RabbitMQ: %v", err)
-		return
+		log.Printf("Erro ao iniciar transação da promoção: %v", err)
+		return nil
 	}
-	defer ch.Close()
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
 
-	// Convert log data to JSON
-	body, err := json.Marshal(logData)
+	event, err := fn(uc.promotionRepo.WithTx(tx))
 	if err != nil {
-		log.Printf("Erro ao converter log para JSON: %v", err)
-		return
+		tx.Rollback()
+		return err
 	}
-
-	// Declare queue (ensure it exists)
-	queueName := "log"
-	_, err = ch.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
-		log.Printf("Erro ao declarar fila: %v", err)
-		return
+	if err := uc.outbox.CommitWithLog(ctx, tx, event, uc.logPublisher.Publish); err != nil {
+		log.Printf("Erro ao confirmar processamento da promoção: %v", err)
 	}
+	return nil
+}
 
-	// Publish message
-	err = ch.Publish(
-		"",        // exchange
-		queueName, // routing key
-		false,     // mandatory
-		false,     // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		})
-
-	if err != nil {
-		log.Printf("Erro ao enviar mensagem para fila: %v", err)
-	} else {
-		log.Printf("Log enviado para fila com sucesso: %s", string(body))
+// sendToQueue publishes a log event through the log publisher
+func (uc *PromotionUseCase) sendToQueue(event entities.LogEvent) {
+	if err := uc.logPublisher.Publish(event); err != nil {
+		log.Printf("Erro ao enviar log da promoção: %v", err)
+		return
 	}
+	log.Printf("Log da promoção enviado com sucesso")
 }
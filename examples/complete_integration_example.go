@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
@@ -54,7 +55,7 @@ func RunCompleteIntegrationExample() {
 
 	// Process promotion (this will call the integration job at the end)
 	log.Println("Iniciando processamento de promoção...")
-	err = promotionUC.ProcessIntegrationPromotions(testPromotion)
+	err = promotionUC.ProcessIntegrationPromotions(context.Background(), testPromotion)
 	if err != nil {
 		log.Fatalf("Erro no processamento de promoção: %v", err)
 	}
@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
@@ -27,12 +28,12 @@ func RunProductIntegrationService() {
 
 	// Initialize repositories
 	productIntegrationRepo := repositories.NewProductIntegrationRepository(db)
+	parameterRepo := repositories.NewParameterRepository(db)
 
 	// Initialize use cases
-	productIntegrationUC := usecases.NewProductIntegrationUseCase(productIntegrationRepo, db)
+	productIntegrationUC := usecases.NewProductIntegrationUseCase(productIntegrationRepo, parameterRepo, db)
 
 	// For complete setup, you would also initialize:
-	// parameterRepo := repositories.NewParameterRepository(db)
 	// integrationRepo := repositories.NewIntegrationRepository(db)
 	// networkRepo := repositories.NewNetworkRepository(db)
 	// promotionRepo := repositories.NewPromotionRepository(db)
@@ -80,10 +81,11 @@ func testProductIntegration() {
 
 	// Initialize repository and use case
 	productIntegrationRepo := repositories.NewProductIntegrationRepository(db)
-	productIntegrationUC := usecases.NewProductIntegrationUseCase(productIntegrationRepo, db)
+	parameterRepo := repositories.NewParameterRepository(db)
+	productIntegrationUC := usecases.NewProductIntegrationUseCase(productIntegrationRepo, parameterRepo, db)
 
 	// Run product integration
-	success, err := productIntegrationUC.ImportProductIntegration()
+	success, err := productIntegrationUC.ImportProductIntegration(context.Background())
 	if err != nil {
 		log.Printf("Error during product integration: %v", err)
 		return
@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -56,7 +57,7 @@ func RunPromotionExample() {
 
 	// Process promotion
 	log.Println("Iniciando processamento de promoção...")
-	err = promotionUC.ProcessIntegrationPromotions(testPromotion)
+	err = promotionUC.ProcessIntegrationPromotions(context.Background(), testPromotion)
 	if err != nil {
 		log.Fatalf("Erro no processamento de promoção: %v", err)
 	}
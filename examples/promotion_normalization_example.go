@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"os"
@@ -29,7 +30,8 @@ func RunPromotionNormalizationService() {
 	promotionNormalizationRepo := repositories.NewPromotionNormalizationRepository(db)
 
 	// Initialize use cases
-	promotionNormalizationUC := usecases.NewPromotionNormalizationUseCase(promotionNormalizationRepo, db)
+	parameterRepo := repositories.NewParameterRepository(db)
+	promotionNormalizationUC := usecases.NewPromotionNormalizationUseCase(promotionNormalizationRepo, parameterRepo, db)
 
 	// For complete setup, you would also initialize:
 	// Other use cases as needed
@@ -76,10 +78,11 @@ func testPromotionNormalization() {
 
 	// Initialize repository and use case
 	promotionNormalizationRepo := repositories.NewPromotionNormalizationRepository(db)
-	promotionNormalizationUC := usecases.NewPromotionNormalizationUseCase(promotionNormalizationRepo, db)
+	parameterRepo := repositories.NewParameterRepository(db)
+	promotionNormalizationUC := usecases.NewPromotionNormalizationUseCase(promotionNormalizationRepo, parameterRepo, db)
 
 	// Run promotion normalization
-	result, err := promotionNormalizationUC.NormalizePromotions()
+	result, err := promotionNormalizationUC.NormalizePromotions(context.Background())
 	if err != nil {
 		log.Printf("Error during promotion normalization: %v", err)
 		return
@@ -100,7 +103,7 @@ Message Format for Promotion Normalization:
 {
   "tipoIntegracao": "PromocaoNormalizacao",
   "dados": {
-    // any additional filtering parameters if needed
+    // optional: "modo": "full", "IdRevendedor": [1, 2], "IdPromocao": 10, "codMix": "MIX01", "dryRun": true
   }
 }
 
@@ -108,7 +111,8 @@ The actual promotion data is read from the INTEGRACAO_PROMOCAO table,
 not from the RabbitMQ message itself. The message just triggers the normalization process.
 
 What the normalization does:
-1. Reads all records from INTEGRACAO_PROMOCAO table
+1. Reads INTEGRACAO_PROMOCAO page by page, only records changed since the last successful run
+   unless "dados" contains {"modo": "full"}
 2. For each record, parses the JSON field containing promotion data
 3. For each group (grupos) in the promotion:
    - Removes duplicate items based on codBarra (barcode)
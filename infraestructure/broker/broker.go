@@ -0,0 +1,87 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrUnavailable is returned, wrapped, when the broker cannot be reached
+var ErrUnavailable = errors.New("broker indisponível")
+
+// Message is a broker-neutral message
+type Message struct {
+	Body          []byte
+	ContentType   string
+	Type          string
+	CorrelationId string
+	ReplyTo       string
+	Headers       map[string]interface{}
+	Persistent    bool
+}
+
+// Acknowledger settles a delivery
+type Acknowledger interface {
+	Ack() error
+	Nack(requeue bool) error
+}
+
+// Delivery is a message received from a queue; it must be settled with Ack or Nack
+type Delivery struct {
+	Message
+	Queue       string
+	Redelivered bool
+
+	acknowledger Acknowledger
+}
+
+// NewDelivery builds a delivery settled through acknowledger, for Consumer implementations
+func NewDelivery(queue string, msg Message, redelivered bool, acknowledger Acknowledger) Delivery {
+	return Delivery{Message: msg, Queue: queue, Redelivered: redelivered, acknowledger: acknowledger}
+}
+
+// Ack confirms the delivery, removing it from the queue
+func (d Delivery) Ack() error {
+	return d.acknowledger.Ack()
+}
+
+// Nack rejects the delivery: requeued it goes back to the queue, otherwise it is
+// dead-lettered (or discarded when the queue has no dead-letter exchange)
+func (d Delivery) Nack(requeue bool) error {
+	return d.acknowledger.Nack(requeue)
+}
+
+// Consumer reads messages from a queue
+type Consumer interface {
+	// Consume declares the durable queue and delivers its messages, at most prefetch
+	// unsettled at a time. The channel is closed when ctx is done or the connection drops;
+	// unsettled deliveries then go back to the queue and can no longer be settled.
+	Consume(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error)
+}
+
+// Publisher sends messages and declares the topology they are routed through
+type Publisher interface {
+	// Publish routes msg through exchange ("" delivers straight to the queue named routingKey)
+	Publish(ctx context.Context, exchange, routingKey string, msg Message) error
+	// DeclareQueue declares a durable queue
+	DeclareQueue(name string) error
+	// DeclareExchange declares a durable exchange of kind "direct", "topic" or "fanout"
+	DeclareExchange(name, kind string) error
+	// BindQueue routes messages published to exchange with routingKey to queue
+	BindQueue(queue, routingKey, exchange string) error
+}
+
+// ReconnectNotifier is implemented by publishers whose connection can be replaced, losing
+// what was declared through it on a server that was restarted
+type ReconnectNotifier interface {
+	// OnReconnect runs fn after the connection is re-established; the returned function
+	// removes the subscription
+	OnReconnect(fn func()) (unsubscribe func())
+}
+
+// DelayedPublisher is implemented by publishers that can hold a message back before it
+// reaches a queue, so a retry does not run again straight away
+type DelayedPublisher interface {
+	// PublishDelayed delivers msg to queue after delay
+	PublishDelayed(ctx context.Context, queue string, msg Message, delay time.Duration) error
+}
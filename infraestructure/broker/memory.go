@@ -0,0 +1,380 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errDeliverySettled is returned when a delivery is settled twice or after its channel closed
+var errDeliverySettled = errors.New("entrega já confirmada ou canal fechado")
+
+// MemoryBroker is an in-process Consumer and Publisher with the RabbitMQ semantics the
+// application relies on: default, direct, topic and fanout exchanges, prefetch, ack, nack
+// with or without requeue, redelivery of unsettled messages when a consumer stops and the
+// "x-delivery-count" header of quorum queues. Messages nacked without requeue are kept in
+// DeadLetters. It lets tests and local runs drive the pipeline without a RabbitMQ server.
+type MemoryBroker struct {
+	mu        sync.Mutex
+	queues    map[string]*memoryQueue
+	exchanges map[string]*memoryExchange
+	nextTag   uint64
+}
+
+type memoryQueue struct {
+	ready       []memoryMessage
+	unacked     int // delivered and not yet settled, across subscriptions
+	deadLetters []Message
+	changed     chan struct{} // closed and replaced on every change, waking the consumers
+}
+
+type memoryMessage struct {
+	msg        Message
+	deliveries int
+}
+
+type memoryExchange struct {
+	kind     string
+	bindings []memoryBinding
+}
+
+type memoryBinding struct {
+	queue      string
+	routingKey string
+}
+
+// memorySubscription is one Consume call and its unsettled deliveries
+type memorySubscription struct {
+	queue    string
+	prefetch int
+	unacked  map[uint64]memoryMessage
+	closed   bool
+}
+
+// NewMemoryBroker creates an empty broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		queues:    map[string]*memoryQueue{},
+		exchanges: map[string]*memoryExchange{},
+	}
+}
+
+// DeclareQueue declares the queue if it does not exist
+func (b *MemoryBroker) DeclareQueue(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queue(name)
+	return nil
+}
+
+// DeclareExchange declares the exchange, failing if it exists with another kind
+func (b *MemoryBroker) DeclareExchange(name, kind string) error {
+	switch kind {
+	case "direct", "topic", "fanout":
+	default:
+		return fmt.Errorf("tipo de exchange não suportado: %s", kind)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if exchange, ok := b.exchanges[name]; ok {
+		if exchange.kind != kind {
+			return fmt.Errorf("exchange %s já declarada como %s", name, exchange.kind)
+		}
+		return nil
+	}
+	b.exchanges[name] = &memoryExchange{kind: kind}
+	return nil
+}
+
+// BindQueue binds the queue to the exchange; both must have been declared
+func (b *MemoryBroker) BindQueue(queue, routingKey, exchange string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ex, ok := b.exchanges[exchange]
+	if !ok {
+		return fmt.Errorf("exchange %s não declarada", exchange)
+	}
+	if _, ok := b.queues[queue]; !ok {
+		return fmt.Errorf("fila %s não declarada", queue)
+	}
+	for _, binding := range ex.bindings {
+		if binding.queue == queue && binding.routingKey == routingKey {
+			return nil
+		}
+	}
+	ex.bindings = append(ex.bindings, memoryBinding{queue: queue, routingKey: routingKey})
+	return nil
+}
+
+// Publish routes a copy of msg to the matching queues. As in RabbitMQ, a message that
+// matches no queue is dropped and an undeclared exchange is an error.
+func (b *MemoryBroker) Publish(ctx context.Context, exchange, routingKey string, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var targets []string
+	if exchange == "" {
+		if _, ok := b.queues[routingKey]; ok {
+			targets = append(targets, routingKey)
+		}
+	} else {
+		ex, ok := b.exchanges[exchange]
+		if !ok {
+			return fmt.Errorf("exchange %s não declarada", exchange)
+		}
+		for _, binding := range ex.bindings {
+			if ex.matches(binding.routingKey, routingKey) {
+				targets = append(targets, binding.queue)
+			}
+		}
+	}
+
+	for _, name := range targets {
+		q := b.queue(name)
+		q.ready = append(q.ready, memoryMessage{msg: copyMessage(msg)})
+		q.notify()
+	}
+	return nil
+}
+
+// PublishDelayed delivers msg to queue after delay. The message waits in memory, outside
+// the queue, so Len does not count it.
+func (b *MemoryBroker) PublishDelayed(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg = copyMessage(msg)
+	time.AfterFunc(delay, func() {
+		b.Publish(context.Background(), "", queue, msg)
+	})
+	return nil
+}
+
+// Consume delivers the messages of queue until ctx is done
+func (b *MemoryBroker) Consume(ctx context.Context, queue string, prefetch int) (<-chan Delivery, error) {
+	b.mu.Lock()
+	b.queue(queue)
+	b.mu.Unlock()
+
+	sub := &memorySubscription{queue: queue, prefetch: prefetch, unacked: map[uint64]memoryMessage{}}
+	out := make(chan Delivery)
+	go b.deliver(ctx, sub, out)
+	return out, nil
+}
+
+// Len returns how many messages of queue are waiting to be delivered
+func (b *MemoryBroker) Len(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queue]; ok {
+		return len(q.ready)
+	}
+	return 0
+}
+
+// Unacked returns how many messages of queue were delivered and not yet settled
+func (b *MemoryBroker) Unacked(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queue]; ok {
+		return q.unacked
+	}
+	return 0
+}
+
+// DeadLetters returns the messages of queue nacked without requeue
+func (b *MemoryBroker) DeadLetters(queue string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queue]; ok {
+		return append([]Message(nil), q.deadLetters...)
+	}
+	return nil
+}
+
+// deliver feeds out while the subscription has room under its prefetch
+func (b *MemoryBroker) deliver(ctx context.Context, sub *memorySubscription, out chan<- Delivery) {
+	defer close(out)
+	defer b.closeSubscription(sub)
+
+	for {
+		delivery, changed := b.next(sub)
+		if changed != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+			continue
+		}
+
+		select {
+		case out <- delivery:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// next takes the next message for sub, or returns the channel to wait on when there is none
+// or the prefetch is full
+func (b *MemoryBroker) next(sub *memorySubscription) (Delivery, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queues[sub.queue]
+	if len(q.ready) == 0 || (sub.prefetch > 0 && len(sub.unacked) >= sub.prefetch) {
+		return Delivery{}, q.changed
+	}
+
+	m := q.ready[0]
+	q.ready = q.ready[1:]
+
+	b.nextTag++
+	tag := b.nextTag
+	sub.unacked[tag] = m
+	q.unacked++
+
+	msg := copyMessage(m.msg)
+	if m.deliveries > 0 {
+		if msg.Headers == nil {
+			msg.Headers = map[string]interface{}{}
+		}
+		msg.Headers["x-delivery-count"] = int64(m.deliveries)
+	}
+	return NewDelivery(sub.queue, msg, m.deliveries > 0, &memoryAcknowledger{broker: b, sub: sub, tag: tag}), nil
+}
+
+// closeSubscription returns the unsettled deliveries of sub to the front of the queue
+func (b *MemoryBroker) closeSubscription(sub *memorySubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub.closed = true
+	tags := make([]uint64, 0, len(sub.unacked))
+	for tag := range sub.unacked {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	q := b.queues[sub.queue]
+	requeued := make([]memoryMessage, 0, len(tags))
+	for _, tag := range tags {
+		m := sub.unacked[tag]
+		m.deliveries++
+		requeued = append(requeued, m)
+		delete(sub.unacked, tag)
+	}
+	q.unacked -= len(requeued)
+	q.ready = append(requeued, q.ready...)
+	q.notify()
+}
+
+// settle removes a delivery from its subscription; requeue puts it back at the front of the
+// queue, otherwise a nack moves it to the dead letters
+func (b *MemoryBroker) settle(sub *memorySubscription, tag uint64, ack, requeue bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := sub.unacked[tag]
+	if sub.closed || !ok {
+		return errDeliverySettled
+	}
+	delete(sub.unacked, tag)
+
+	q := b.queues[sub.queue]
+	q.unacked--
+	switch {
+	case ack:
+	case requeue:
+		m.deliveries++
+		q.ready = append([]memoryMessage{m}, q.ready...)
+	default:
+		q.deadLetters = append(q.deadLetters, m.msg)
+	}
+	q.notify()
+	return nil
+}
+
+// queue returns the named queue, creating it; b.mu must be held
+func (b *MemoryBroker) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{changed: make(chan struct{})}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// notify wakes the consumers waiting on the queue; the broker lock must be held
+func (q *memoryQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// matches reports whether a message published with routingKey follows a binding with key
+func (e *memoryExchange) matches(key, routingKey string) bool {
+	switch e.kind {
+	case "fanout":
+		return true
+	case "topic":
+		return topicMatches(strings.Split(key, "."), strings.Split(routingKey, "."))
+	}
+	return key == routingKey
+}
+
+// topicMatches applies the topic exchange wildcards: "*" is one word, "#" zero or more
+func topicMatches(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if topicMatches(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && topicMatches(pattern[1:], words[1:])
+	}
+	return len(words) > 0 && pattern[0] == words[0] && topicMatches(pattern[1:], words[1:])
+}
+
+// copyMessage copies the body and headers so publishers and consumers do not share them
+func copyMessage(msg Message) Message {
+	msg.Body = append([]byte(nil), msg.Body...)
+	if msg.Headers != nil {
+		headers := make(map[string]interface{}, len(msg.Headers))
+		for key, value := range msg.Headers {
+			headers[key] = value
+		}
+		msg.Headers = headers
+	}
+	return msg
+}
+
+type memoryAcknowledger struct {
+	broker *MemoryBroker
+	sub    *memorySubscription
+	tag    uint64
+}
+
+func (a *memoryAcknowledger) Ack() error {
+	return a.broker.settle(a.sub, a.tag, true, false)
+}
+
+func (a *memoryAcknowledger) Nack(requeue bool) error {
+	return a.broker.settle(a.sub, a.tag, false, requeue)
+}
@@ -0,0 +1,310 @@
+package broker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// consume subscribes to queue and returns the deliveries channel and a function that stops
+// the subscription and waits for the channel to close
+func consume(t *testing.T, b *MemoryBroker, queue string, prefetch int) (<-chan Delivery, func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs, err := b.Consume(ctx, queue, prefetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msgs, func() {
+		cancel()
+		for range msgs {
+		}
+	}
+}
+
+// receive waits for the next delivery
+func receive(t *testing.T, msgs <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case msg, ok := <-msgs:
+		if !ok {
+			t.Fatal("deliveries channel closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a delivery")
+	}
+	return Delivery{}
+}
+
+// expectNone fails if a delivery arrives shortly
+func expectNone(t *testing.T, msgs <-chan Delivery) {
+	t.Helper()
+	select {
+	case msg := <-msgs:
+		t.Fatalf("unexpected delivery %s", msg.Body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// send publishes body to the default exchange with routingKey
+func send(t *testing.T, b *MemoryBroker, exchange, routingKey, body string) {
+	t.Helper()
+	if err := b.Publish(context.Background(), exchange, routingKey, Message{Body: []byte(body)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryBrokerAckRemovesTheMessage(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+	send(t, b, "", "q", "a")
+
+	msgs, stop := consume(t, b, "q", 1)
+	msg := receive(t, msgs)
+	if string(msg.Body) != "a" || msg.Redelivered {
+		t.Fatalf("delivery = %s (redelivered %v), want a first delivery of a", msg.Body, msg.Redelivered)
+	}
+	if got := b.Unacked("q"); got != 1 {
+		t.Errorf("Unacked = %d before the ack, want 1", got)
+	}
+	if err := msg.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.Ack(); err == nil {
+		t.Error("second Ack succeeded, want an error")
+	}
+	stop()
+
+	if got := b.Unacked("q"); got != 0 {
+		t.Errorf("Unacked = %d, want 0", got)
+	}
+	if got := b.Len("q"); got != 0 {
+		t.Errorf("Len = %d after stopping, want 0: an acked message must not come back", got)
+	}
+}
+
+func TestMemoryBrokerNackWithRequeueRedelivers(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+	send(t, b, "", "q", "a")
+	send(t, b, "", "q", "b")
+
+	msgs, stop := consume(t, b, "q", 1)
+	defer stop()
+
+	first := receive(t, msgs)
+	if err := first.Nack(true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requeued at the front, ahead of b, as RabbitMQ does
+	again := receive(t, msgs)
+	if string(again.Body) != "a" || !again.Redelivered {
+		t.Fatalf("delivery = %s (redelivered %v), want a redelivered", again.Body, again.Redelivered)
+	}
+	if got := again.Headers["x-delivery-count"]; got != int64(1) {
+		t.Errorf("x-delivery-count = %v, want 1", got)
+	}
+	again.Ack()
+
+	if next := receive(t, msgs); string(next.Body) != "b" {
+		t.Errorf("delivery = %s, want b", next.Body)
+	}
+	if got := len(b.DeadLetters("q")); got != 0 {
+		t.Errorf("%d dead letters, want 0", got)
+	}
+}
+
+func TestMemoryBrokerNackWithoutRequeueDeadLetters(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+	send(t, b, "", "q", "a")
+
+	msgs, stop := consume(t, b, "q", 1)
+	if err := receive(t, msgs).Nack(false); err != nil {
+		t.Fatal(err)
+	}
+	expectNone(t, msgs)
+	stop()
+
+	deadLetters := b.DeadLetters("q")
+	if len(deadLetters) != 1 || string(deadLetters[0].Body) != "a" {
+		t.Fatalf("dead letters = %v, want a", deadLetters)
+	}
+	if got := b.Len("q"); got != 0 {
+		t.Errorf("Len = %d, want 0", got)
+	}
+}
+
+func TestMemoryBrokerRequeuesUnsettledDeliveriesWhenConsumerStops(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+	send(t, b, "", "q", "a")
+	send(t, b, "", "q", "b")
+	send(t, b, "", "q", "c")
+
+	msgs, stop := consume(t, b, "q", 2)
+	a := receive(t, msgs)
+	receive(t, msgs)
+	stop()
+
+	if err := a.Ack(); err == nil {
+		t.Error("Ack after the consumer stopped succeeded, want an error")
+	}
+	if got := b.Unacked("q"); got != 0 {
+		t.Errorf("Unacked = %d after stopping, want 0", got)
+	}
+
+	msgs, stop = consume(t, b, "q", 3)
+	defer stop()
+
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		msg := receive(t, msgs)
+		bodies = append(bodies, string(msg.Body))
+		if redelivered := string(msg.Body) != "c"; msg.Redelivered != redelivered {
+			t.Errorf("%s redelivered = %v, want %v", msg.Body, msg.Redelivered, redelivered)
+		}
+		msg.Ack()
+	}
+	if got := bodies[0] + bodies[1] + bodies[2]; got != "abc" {
+		t.Errorf("delivery order = %s, want abc", got)
+	}
+}
+
+func TestMemoryBrokerPrefetchLimitsUnsettledDeliveries(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+	for _, body := range []string{"a", "b", "c"} {
+		send(t, b, "", "q", body)
+	}
+
+	msgs, stop := consume(t, b, "q", 2)
+	defer stop()
+
+	a := receive(t, msgs)
+	receive(t, msgs)
+	expectNone(t, msgs)
+	if got := b.Unacked("q"); got != 2 {
+		t.Errorf("Unacked = %d, want the prefetch of 2", got)
+	}
+
+	a.Ack()
+	if msg := receive(t, msgs); string(msg.Body) != "c" {
+		t.Errorf("delivery = %s after the ack, want c", msg.Body)
+	}
+}
+
+func TestMemoryBrokerTopicRouting(t *testing.T) {
+	b := NewMemoryBroker()
+	if err := b.DeclareExchange("produtos", "topic"); err != nil {
+		t.Fatal(err)
+	}
+	bindings := map[string]string{"todos": "produto.#", "full": "produto.full", "um": "*.incremental"}
+	for queue, key := range bindings {
+		b.DeclareQueue(queue)
+		if err := b.BindQueue(queue, key, "produtos"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(t, b, "produtos", "produto.full", "1")
+	send(t, b, "produtos", "produto.incremental", "2")
+	send(t, b, "produtos", "produto", "3")
+	send(t, b, "produtos", "outro.full", "4")
+
+	want := map[string]int{"todos": 3, "full": 1, "um": 1}
+	for queue, count := range want {
+		if got := b.Len(queue); got != count {
+			t.Errorf("%s (%s) has %d messages, want %d", queue, bindings[queue], got, count)
+		}
+	}
+}
+
+func TestMemoryBrokerFanoutRouting(t *testing.T) {
+	b := NewMemoryBroker()
+	if err := b.DeclareExchange("logs", "fanout"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DeclareExchange("logs", "topic"); err == nil {
+		t.Error("redeclaring the exchange with another kind succeeded, want an error")
+	}
+	for _, queue := range []string{"a", "b"} {
+		b.DeclareQueue(queue)
+		if err := b.BindQueue(queue, "ignorada", "logs"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(t, b, "logs", "qualquer", "x")
+
+	for _, queue := range []string{"a", "b"} {
+		if got := b.Len(queue); got != 1 {
+			t.Errorf("%s has %d messages, want 1", queue, got)
+		}
+	}
+}
+
+func TestMemoryBrokerPublishCopiesTheMessage(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+
+	msg := Message{Body: []byte("a"), Headers: map[string]interface{}{"h": "1"}}
+	if err := b.Publish(context.Background(), "", "q", msg); err != nil {
+		t.Fatal(err)
+	}
+	msg.Body[0] = 'z'
+	msg.Headers["h"] = "2"
+
+	msgs, stop := consume(t, b, "q", 1)
+	defer stop()
+	got := receive(t, msgs)
+	if string(got.Body) != "a" || got.Headers["h"] != "1" {
+		t.Errorf("delivered %s %v, want the message as published", got.Body, got.Headers)
+	}
+}
+
+func TestMemoryBrokerPublishDelayed(t *testing.T) {
+	b := NewMemoryBroker()
+	b.DeclareQueue("q")
+
+	const delay = 50 * time.Millisecond
+	sent := time.Now()
+	if err := b.PublishDelayed(context.Background(), "q", Message{Body: []byte("a")}, delay); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Len("q"); got != 0 {
+		t.Errorf("Len = %d right after publishing, want 0", got)
+	}
+
+	msgs, stop := consume(t, b, "q", 1)
+	defer stop()
+	receive(t, msgs)
+	if waited := time.Since(sent); waited < delay {
+		t.Errorf("delivered after %s, want at least %s", waited, delay)
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"produto.*", "produto.full", true},
+		{"produto.*", "produto", false},
+		{"produto.*", "produto.full.x", false},
+		{"produto.#", "produto", true},
+		{"#", "qualquer.coisa", true},
+		{"#.full", "produto.full", true},
+		{"*.full", "full", false},
+	}
+	for _, c := range cases {
+		got := topicMatches(strings.Split(c.pattern, "."), strings.Split(c.key, "."))
+		if got != c.want {
+			t.Errorf("topicMatches(%q, %q) = %v, want %v", c.pattern, c.key, got, c.want)
+		}
+	}
+}
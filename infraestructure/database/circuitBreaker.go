@@ -0,0 +1,243 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "CLOSED"    // calls go through
+	CircuitOpen     CircuitState = "OPEN"      // calls are rejected until the open period ends
+	CircuitHalfOpen CircuitState = "HALF_OPEN" // one trial call at a time decides whether it closes
+)
+
+var (
+	circuitFailureThreshold atomic.Int64
+	circuitOpenDuration     atomic.Int64
+
+	circuitsMu sync.Mutex
+	circuits   = map[string]*CircuitBreaker{}
+)
+
+func init() {
+	circuitFailureThreshold.Store(entities.DEFAULT_CIRCUIT_BREAKER_FAILURES)
+	circuitOpenDuration.Store(int64(entities.DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS * time.Second))
+}
+
+// SetCircuitBreakerSettings sets how many consecutive failures open a breaker
+// (CIRCUIT_BREAKER_FAILURES) and how long it stays open (CIRCUIT_BREAKER_OPEN_SECONDS);
+// non-positive values keep the current setting
+func SetCircuitBreakerSettings(failures int, openFor time.Duration) {
+	if failures > 0 {
+		circuitFailureThreshold.Store(int64(failures))
+	}
+	if openFor > 0 {
+		circuitOpenDuration.Store(int64(openFor))
+	}
+}
+
+// CircuitOpenError is returned by CircuitBreaker.Execute when the call was rejected
+type CircuitOpenError struct {
+	Name    string
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s, nova tentativa após %s", entities.ErrCircuitOpen, e.Name, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, entities.ErrCircuitOpen) match
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == entities.ErrCircuitOpen
+}
+
+// CircuitSnapshot is the state of a breaker as shown by the health endpoint
+type CircuitSnapshot struct {
+	Name                string       `json:"name"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	OpenedAt            *time.Time   `json:"openedAt,omitempty"`
+	RetryAt             *time.Time   `json:"retryAt,omitempty"`
+	LastError           string       `json:"lastError,omitempty"`
+}
+
+// CircuitBreaker stops calling a stored procedure after consecutive transient failures
+// (deadlock, lost connection, timeout...). Constraint and business errors mean Oracle
+// answered, so they count as successes.
+type CircuitBreaker struct {
+	name string
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial call is in flight
+	lastErr  string
+}
+
+// Circuit returns the breaker of name, creating it closed on first use
+func Circuit(name string) *CircuitBreaker {
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+
+	cb, ok := circuits[name]
+	if !ok {
+		cb = &CircuitBreaker{name: name, state: CircuitClosed}
+		circuits[name] = cb
+	}
+	return cb
+}
+
+// CircuitSnapshots returns the state of every breaker used so far, sorted by name
+func CircuitSnapshots() []CircuitSnapshot {
+	circuitsMu.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(circuits))
+	for _, cb := range circuits {
+		breakers = append(breakers, cb)
+	}
+	circuitsMu.Unlock()
+
+	snapshots := make([]CircuitSnapshot, 0, len(breakers))
+	for _, cb := range breakers {
+		snapshots = append(snapshots, cb.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots
+}
+
+// Execute calls fn unless the breaker is open, in which case it returns a *CircuitOpenError
+// without calling it
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	if retryAt, ok := cb.allow(); !ok {
+		return &CircuitOpenError{Name: cb.name, RetryAt: retryAt}
+	}
+
+	recorded := false
+	defer func() {
+		if !recorded {
+			// fn panicked: release the trial, or the breaker would stay half-open forever
+			cb.releaseTrial()
+		}
+	}()
+
+	err := fn()
+	recorded = true
+	cb.record(err)
+	return err
+}
+
+// releaseTrial lets the next call be the half-open trial
+func (cb *CircuitBreaker) releaseTrial() {
+	cb.mu.Lock()
+	cb.trial = false
+	cb.mu.Unlock()
+}
+
+// OpenUntil returns when the breaker lets a trial call through, and false if it does now
+func (cb *CircuitBreaker) OpenUntil() (time.Time, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != CircuitOpen {
+		return time.Time{}, false
+	}
+	retryAt := cb.openedAt.Add(time.Duration(circuitOpenDuration.Load()))
+	return retryAt, time.Now().Before(retryAt)
+}
+
+// Snapshot returns the current state of the breaker
+func (cb *CircuitBreaker) Snapshot() CircuitSnapshot {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	snapshot := CircuitSnapshot{
+		Name:                cb.name,
+		State:               cb.state,
+		ConsecutiveFailures: cb.failures,
+		LastError:           cb.lastErr,
+	}
+	if cb.state != CircuitClosed {
+		openedAt := cb.openedAt
+		retryAt := openedAt.Add(time.Duration(circuitOpenDuration.Load()))
+		snapshot.OpenedAt = &openedAt
+		snapshot.RetryAt = &retryAt
+	}
+	return snapshot
+}
+
+// allow reports whether a call may go through, moving an open breaker to half-open once
+// its open period is over
+func (cb *CircuitBreaker) allow() (time.Time, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	retryAt := cb.openedAt.Add(time.Duration(circuitOpenDuration.Load()))
+	switch cb.state {
+	case CircuitOpen:
+		if time.Now().Before(retryAt) {
+			return retryAt, false
+		}
+		log.Printf("Circuit breaker %s meio-aberto, executando chamada de teste", cb.name)
+		cb.state = CircuitHalfOpen
+		cb.trial = true
+		return time.Time{}, true
+	case CircuitHalfOpen:
+		if cb.trial {
+			return retryAt, false
+		}
+		cb.trial = true
+	}
+	return time.Time{}, true
+}
+
+// record updates the breaker with the outcome of a call
+func (cb *CircuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	halfOpen := cb.state == CircuitHalfOpen
+	cb.trial = false
+
+	if err == nil {
+		cb.close()
+		return
+	}
+
+	classified := ClassifyError(err)
+	switch {
+	case classified.Kind == ErrorKindCanceled:
+		// Shutdown or an aborted job says nothing about the procedure
+		return
+	case !classified.Retryable() && classified.Kind != ErrorKindTimeout:
+		cb.close()
+		return
+	}
+
+	cb.failures++
+	cb.lastErr = fmt.Sprintf("%s: %v", classified.Describe(), err)
+	if halfOpen || cb.failures >= int(circuitFailureThreshold.Load()) {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+		log.Printf("Circuit breaker %s aberto após %d falhas consecutivas por %s: %s",
+			cb.name, cb.failures, time.Duration(circuitOpenDuration.Load()), cb.lastErr)
+	}
+}
+
+// close resets the breaker after a call Oracle answered
+func (cb *CircuitBreaker) close() {
+	if cb.state != CircuitClosed {
+		log.Printf("Circuit breaker %s fechado, chamadas normalizadas", cb.name)
+	}
+	cb.state = CircuitClosed
+	cb.failures = 0
+	cb.lastErr = ""
+}
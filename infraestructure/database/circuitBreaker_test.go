@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerReleasesTrialWhenCallPanics(t *testing.T) {
+	cb := &CircuitBreaker{name: t.Name(), state: CircuitOpen, openedAt: time.Now().Add(-time.Hour)}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Execute did not propagate the panic")
+			}
+		}()
+		cb.Execute(func() error { panic("trial call failed") })
+	}()
+
+	called := false
+	err := cb.Execute(func() error {
+		called = true
+		return nil
+	})
+	if err != nil || !called {
+		t.Fatalf("call after the panicking trial: called = %v, err = %v; want a new trial", called, err)
+	}
+	if state := cb.Snapshot().State; state != CircuitClosed {
+		t.Errorf("state = %s, want %s after a successful trial", state, CircuitClosed)
+	}
+}
+
+func TestCircuitBreakerRejectsCallsWhileTrialRuns(t *testing.T) {
+	cb := &CircuitBreaker{name: t.Name(), state: CircuitOpen, openedAt: time.Now().Add(-time.Hour)}
+
+	cb.Execute(func() error {
+		err := cb.Execute(func() error { return nil })
+		var open *CircuitOpenError
+		if !errors.As(err, &open) {
+			t.Errorf("call during the trial: err = %v, want *CircuitOpenError", err)
+		}
+		return nil
+	})
+}
@@ -2,9 +2,16 @@ package database
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	config "github.com/thiagohmm/integracaocron/configuration"
@@ -12,48 +19,250 @@ import (
 	go_ora "github.com/sijms/go-ora/v2"
 )
 
+// Pool defaults used when the configuration leaves them at zero
+const (
+	defaultWorkers         = 20
+	poolHeadroom           = 5 // connections beyond the workers for jobs, relays and health checks
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+	defaultConnectTimeout  = 10
+)
+
+// Startup connection attempts
+const (
+	defaultStartupMaxWait = 120 * time.Second
+	startupInitialBackoff = time.Second
+	startupMaxBackoff     = 30 * time.Second
+)
+
+// ErrConexaoFatal marks connection errors that retrying cannot fix, such as invalid credentials
+var ErrConexaoFatal = errors.New("erro fatal de conexão com o Oracle")
+
+// fatalConnectErrors are the ORA codes that stop the startup attempts
+var fatalConnectErrors = map[int]string{
+	1005:  "senha nula",
+	1017:  "usuário ou senha inválidos",
+	1045:  "usuário sem privilégio CREATE SESSION",
+	28000: "conta bloqueada",
+	28001: "senha expirada",
+}
+
+// ConectarBanco connects to Oracle, retrying for at most DB_STARTUP_MAX_WAIT
 func ConectarBanco(cfg *config.Conf) (*sql.DB, error) {
-	// Configurar as opções de URL
-	urlOptions := map[string]string{
-		"CONNECTION TIMEOUT": "10",
-		"ssl":                "true",  // ou habilite o SSL
-		"ssl verify":         "false", // desabilita a verificação de certificado SSL
-		// "wallet":           "./wallet", // descomente se estiver usando wallet
+	return ConectarBancoContext(context.Background(), cfg)
+}
+
+// ConectarBancoContext connects to Oracle with exponential backoff and jitter until the
+// connection succeeds, ctx is done or DB_STARTUP_MAX_WAIT elapses. Errors that retrying
+// cannot fix, such as ORA-01017, return immediately wrapping ErrConexaoFatal.
+func ConectarBancoContext(ctx context.Context, cfg *config.Conf) (*sql.DB, error) {
+	connector, err := newConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConexaoFatal, err)
 	}
 
-	// Construir a string de conexão
-	connStr := go_ora.BuildUrl(cfg.Host, cfg.Port, cfg.ServiceName, cfg.DBUser, cfg.DBPassword, urlOptions)
+	db := sql.OpenDB(connector)
+	configurePool(db, cfg)
+
+	maxWait := time.Duration(cfg.DBStartupMaxWait) * time.Second
+	if maxWait <= 0 {
+		maxWait = defaultStartupMaxWait
+	}
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
 
-	var db *sql.DB
-	var err error
+	pingTimeout := time.Duration(connectTimeout(cfg))*time.Second + 5*time.Second
+	backoff := startupInitialBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, pingCancel := context.WithTimeout(ctx, pingTimeout)
+		err = db.PingContext(pingCtx)
+		pingCancel()
+
+		if err == nil {
+			log.Printf("Conexão estabelecida com sucesso com o banco Oracle (tentativa %d)", attempt)
+			return db, nil
+		}
 
-	// Tenta reconectar automaticamente até conseguir
-	for {
-		db, err = sql.Open(cfg.DBDriver, connStr)
+		if reason, fatal := fatalConnectError(err); fatal {
+			db.Close()
+			return nil, fmt.Errorf("%w (%s): %v", ErrConexaoFatal, reason, err)
+		}
+
+		wait := withJitter(backoff)
+		log.Printf("Tentativa %d de conexão com o Oracle falhou: %v. Nova tentativa em %s", attempt, err, wait.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, fmt.Errorf("não foi possível conectar ao Oracle após %d tentativas (espera máxima %s): %w; último erro: %v",
+				attempt, maxWait, ctx.Err(), err)
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > startupMaxBackoff {
+			backoff = startupMaxBackoff
+		}
+	}
+}
+
+// withJitter returns a random wait between half and all of backoff
+func withJitter(backoff time.Duration) time.Duration {
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// fatalConnectError reports whether err is an ORA error retrying cannot fix, with its reason
+func fatalConnectError(err error) (string, bool) {
+	code := OracleErrorCode(err)
+	reason, fatal := fatalConnectErrors[code]
+	if fatal {
+		reason = fmt.Sprintf("ORA-%05d %s", code, reason)
+	}
+	return reason, fatal
+}
+
+// connectTimeout returns DB_CONNECT_TIMEOUT in seconds or its default
+func connectTimeout(cfg *config.Conf) int {
+	if cfg.DBConnectTimeout > 0 {
+		return cfg.DBConnectTimeout
+	}
+	return defaultConnectTimeout
+}
+
+// newConnector builds the go-ora connector with the URL options and the custom CA, if any
+func newConnector(cfg *config.Conf) (*go_ora.OracleConnector, error) {
+	urlOptions, err := connectionOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connector := go_ora.NewConnector(buildConnectionURL(cfg, urlOptions)).(*go_ora.OracleConnector)
+
+	if cfg.DBSSLCA != "" {
+		tlsConfig, err := caTLSConfig(cfg.DBSSLCA, cfg.DBSSLVerify)
 		if err != nil {
-			log.Printf("Erro ao abrir a conexão: %v. Tentando novamente...", err)
-			time.Sleep(2 * time.Second)
-			continue
+			return nil, err
 		}
+		connector.WithTLSConfig(tlsConfig)
+	}
 
-		// Usa um contexto com timeout para a operação de ping
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		err = db.PingContext(ctx)
-		cancel()
+	return connector, nil
+}
+
+// buildConnectionURL maps the DB_CONNECTSTRING form to go-ora: TNS descriptors and aliases go
+// as connStr, so go-ora handles their ADDRESS_LIST failover; host forms use the SID or the
+// service name and instance
+func buildConnectionURL(cfg *config.Conf, urlOptions map[string]string) string {
+	if cfg.ConnectDescriptor != "" {
+		log.Printf("Conectando ao Oracle pelo descritor TNS (primeiro endereço %s:%d)", cfg.Host, cfg.Port)
+		return go_ora.BuildJDBC(cfg.DBUser, cfg.DBPassword, cfg.ConnectDescriptor, urlOptions)
+	}
 
+	service := cfg.ServiceName
+	if service == "" && cfg.SID != "" {
+		urlOptions["SID"] = cfg.SID
+	}
+	if cfg.InstanceName != "" {
+		urlOptions["INSTANCE NAME"] = cfg.InstanceName
+	}
+	log.Printf("Conectando ao Oracle em %s:%d (service %q, SID %q)", cfg.Host, cfg.Port, service, cfg.SID)
+	return go_ora.BuildUrl(cfg.Host, cfg.Port, service, cfg.DBUser, cfg.DBPassword, urlOptions)
+}
+
+// connectionOptions maps the timeout, TLS and wallet settings to go-ora URL options
+func connectionOptions(cfg *config.Conf) (map[string]string, error) {
+	ssl := true
+	if strings.TrimSpace(cfg.DBSSL) != "" {
+		parsed, err := strconv.ParseBool(strings.TrimSpace(cfg.DBSSL))
 		if err != nil {
-			log.Printf("Erro ao verificar a conexão: %v. Tentando novamente...", err)
-			time.Sleep(2 * time.Second)
-			continue
+			return nil, fmt.Errorf("DB_SSL inválido: %q", cfg.DBSSL)
 		}
+		ssl = parsed
+	}
+
+	urlOptions := map[string]string{
+		"CONNECTION TIMEOUT": strconv.Itoa(connectTimeout(cfg)),
+		"SSL":                strconv.FormatBool(ssl),
+		"SSL VERIFY":         strconv.FormatBool(cfg.DBSSLVerify),
+	}
+	if ssl && !cfg.DBSSLVerify {
+		log.Println("Aviso: TLS do Oracle sem verificação de certificado (DB_SSL_VERIFY=false)")
+	}
+
+	if cfg.DBWallet != "" {
+		if _, err := os.Stat(cfg.DBWallet); err != nil {
+			return nil, fmt.Errorf("wallet do Oracle inacessível em %s: %w", cfg.DBWallet, err)
+		}
+		urlOptions["WALLET"] = cfg.DBWallet
+		if cfg.DBWalletPassword != "" {
+			urlOptions["WALLET PASSWORD"] = cfg.DBWalletPassword
+		}
+	}
+
+	return urlOptions, nil
+}
 
-		log.Println("Conexão estabelecida com sucesso com o banco Oracle")
-		break
+// caTLSConfig trusts the certificates of the PEM file at caPath
+func caTLSConfig(caPath string, verify bool) (*tls.Config, error) {
+	pem, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler CA do Oracle %s: %w", caPath, err)
 	}
 
-	// Retorna a conexão ou um erro (nunca chegará aqui com erro se a conexão foi eventualmente bem-sucedida)
-	if db == nil {
-		return nil, fmt.Errorf("não foi possível conectar ao banco Oracle")
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("nenhum certificado PEM válido em %s", caPath)
+	}
+	if !verify {
+		log.Printf("Aviso: DB_SSL_CA definido mas DB_SSL_VERIFY=false, o certificado do servidor não será verificado")
 	}
-	return db, nil
+
+	return &tls.Config{
+		RootCAs:            roots,
+		InsecureSkipVerify: !verify,
+		MinVersion:         tls.VersionTLS12,
+	}, nil
+}
+
+// configurePool sizes the pool from the configuration, defaulting to the number of workers
+// plus some headroom so concurrent messages cannot exhaust the database sessions
+func configurePool(db *sql.DB, cfg *config.Conf) {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	maxOpen := cfg.DBMaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = workers + poolHeadroom
+	}
+
+	maxIdle := cfg.DBMaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = workers / 2
+		if maxIdle < 2 {
+			maxIdle = 2
+		}
+	}
+	if maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+
+	lifetime := time.Duration(cfg.DBConnMaxLifetime) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultConnMaxLifetime
+	}
+	idleTime := time.Duration(cfg.DBConnMaxIdleTime) * time.Second
+	if idleTime <= 0 {
+		idleTime = defaultConnMaxIdleTime
+	}
+
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(lifetime)
+	db.SetConnMaxIdleTime(idleTime)
+
+	log.Printf("Pool Oracle: %d conexões abertas, %d ociosas, vida máxima %s, ociosidade máxima %s",
+		maxOpen, maxIdle, lifetime, idleTime)
 }
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"syscall"
+
+	"github.com/sijms/go-ora/v2/network"
+	"github.com/thiagohmm/integracaocron/domain/entities"
+)
+
+// ErrorKind groups Oracle errors by what the caller can do about them
+type ErrorKind string
+
+const (
+	ErrorKindDeadlock       ErrorKind = "DEADLOCK"        // ORA-00060, ORA-08177
+	ErrorKindResourceBusy   ErrorKind = "RESOURCE_BUSY"   // ORA-00054, ORA-30006, ORA-04021
+	ErrorKindConnectionLost ErrorKind = "CONNECTION_LOST" // ORA-03113, ORA-03114, broken sockets
+	ErrorKindUnavailable    ErrorKind = "UNAVAILABLE"     // ORA-12541, listener or instance down
+	ErrorKindTimeout        ErrorKind = "TIMEOUT"         // ORA-01013, context deadline
+	ErrorKindCanceled       ErrorKind = "CANCELED"        // context cancelled, e.g. shutdown
+	ErrorKindConstraint     ErrorKind = "CONSTRAINT"      // ORA-00001, ORA-02291...
+	ErrorKindInvalidData    ErrorKind = "INVALID_DATA"    // ORA-12899, ORA-01722...
+	ErrorKindBusiness       ErrorKind = "BUSINESS"        // ORA-20000 to ORA-20999 raised by PL/SQL
+	ErrorKindAuth           ErrorKind = "AUTH"            // ORA-01017, ORA-28000...
+	ErrorKindCircuitOpen    ErrorKind = "CIRCUIT_OPEN"    // call rejected by an open circuit breaker
+	ErrorKindOther          ErrorKind = "OTHER"
+)
+
+// oracleErrorKinds maps the ORA codes with a known kind; the PL/SQL range is checked apart
+var oracleErrorKinds = map[int]ErrorKind{
+	60:   ErrorKindDeadlock,
+	8177: ErrorKindDeadlock, // can't serialize access for this transaction
+
+	54:    ErrorKindResourceBusy,
+	4021:  ErrorKindResourceBusy, // timeout waiting to lock object
+	30006: ErrorKindResourceBusy, // resource busy; acquire with WAIT timeout expired
+
+	28:    ErrorKindConnectionLost, // session killed
+	1012:  ErrorKindConnectionLost, // not logged on
+	3113:  ErrorKindConnectionLost,
+	3114:  ErrorKindConnectionLost,
+	3135:  ErrorKindConnectionLost,
+	12152: ErrorKindConnectionLost,
+	12537: ErrorKindConnectionLost,
+	12547: ErrorKindConnectionLost,
+	25408: ErrorKindConnectionLost, // can not safely replay call
+
+	1033:  ErrorKindUnavailable, // initialization or shutdown in progress
+	1034:  ErrorKindUnavailable,
+	1089:  ErrorKindUnavailable,
+	12170: ErrorKindUnavailable,
+	12505: ErrorKindUnavailable,
+	12514: ErrorKindUnavailable,
+	12516: ErrorKindUnavailable,
+	12518: ErrorKindUnavailable,
+	12519: ErrorKindUnavailable,
+	12520: ErrorKindUnavailable,
+	12528: ErrorKindUnavailable,
+	12541: ErrorKindUnavailable,
+
+	1013: ErrorKindTimeout, // user requested cancel of current operation
+
+	1:    ErrorKindConstraint,
+	1400: ErrorKindConstraint,
+	1407: ErrorKindConstraint,
+	2290: ErrorKindConstraint,
+	2291: ErrorKindConstraint,
+	2292: ErrorKindConstraint,
+
+	1438:  ErrorKindInvalidData,
+	1722:  ErrorKindInvalidData,
+	1830:  ErrorKindInvalidData,
+	1843:  ErrorKindInvalidData,
+	1861:  ErrorKindInvalidData,
+	6502:  ErrorKindInvalidData,
+	12899: ErrorKindInvalidData,
+
+	1005:  ErrorKindAuth,
+	1017:  ErrorKindAuth,
+	1045:  ErrorKindAuth,
+	28000: ErrorKindAuth,
+	28001: ErrorKindAuth,
+}
+
+// oraCodePattern finds the ORA code of errors that are not *network.OracleError
+var oraCodePattern = regexp.MustCompile(`ORA-(\d{5})`)
+
+// OracleError is a database error with its ORA code (0 when there is none) and kind
+type OracleError struct {
+	Code int
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *OracleError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OracleError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether running the same statement again may succeed right away
+func (e *OracleError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindDeadlock, ErrorKindResourceBusy, ErrorKindConnectionLost, ErrorKindUnavailable:
+		return true
+	}
+	return false
+}
+
+// Ambiguous reports whether the statement may have run, and even committed, before the
+// error: a connection lost mid-call gives no answer. Only idempotent statements are
+// retried after such an error.
+func (e *OracleError) Ambiguous() bool {
+	return e.Kind == ErrorKindConnectionLost
+}
+
+// Transient reports whether processing the whole message again later may succeed
+func (e *OracleError) Transient() bool {
+	switch e.Kind {
+	case ErrorKindTimeout, ErrorKindCanceled, ErrorKindCircuitOpen:
+		return true
+	}
+	return e.Retryable()
+}
+
+// Describe returns the kind and ORA code for logs, e.g. "DEADLOCK ORA-00060"
+func (e *OracleError) Describe() string {
+	if e.Code == 0 {
+		return string(e.Kind)
+	}
+	return fmt.Sprintf("%s ORA-%05d", e.Kind, e.Code)
+}
+
+// ClassifyError returns err as an *OracleError, parsing its ORA code when err is not one
+// already. It returns nil for a nil error.
+func ClassifyError(err error) *OracleError {
+	if err == nil {
+		return nil
+	}
+	var classified *OracleError
+	if errors.As(err, &classified) {
+		return classified
+	}
+
+	code := OracleErrorCode(err)
+	return &OracleError{Code: code, Kind: errorKind(code, err), Err: err}
+}
+
+// IsRetryable reports whether err is a transient Oracle error worth an immediate retry
+func IsRetryable(err error) bool {
+	return err != nil && ClassifyError(err).Retryable()
+}
+
+// IsTransient reports whether the operation that failed with err may succeed later
+func IsTransient(err error) bool {
+	return err != nil && ClassifyError(err).Transient()
+}
+
+// OracleErrorCode returns the ORA code of err, or 0 when it has none
+func OracleErrorCode(err error) int {
+	var oraErr *network.OracleError
+	if errors.As(err, &oraErr) {
+		return oraErr.ErrCode
+	}
+	if match := oraCodePattern.FindStringSubmatch(err.Error()); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		return code
+	}
+	return 0
+}
+
+// errorKind classifies an ORA code, falling back to the Go error for driver and network failures
+func errorKind(code int, err error) ErrorKind {
+	if kind, ok := oracleErrorKinds[code]; ok {
+		return kind
+	}
+	if code >= 20000 && code <= 20999 {
+		return ErrorKindBusiness
+	}
+	if code != 0 {
+		return ErrorKindOther
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, entities.ErrCircuitOpen):
+		return ErrorKindCircuitOpen
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorKindTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrorKindConnectionLost
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorKindUnavailable
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ErrorKindTimeout
+		}
+		return ErrorKindConnectionLost
+	}
+	return ErrorKindOther
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Retries of statements that failed with a retryable Oracle error
+const (
+	DefaultRetryAttempts = 3
+	retryInitialBackoff  = 100 * time.Millisecond
+	retryMaxBackoff      = 2 * time.Second
+)
+
+var retryAttempts atomic.Int64
+
+func init() {
+	retryAttempts.Store(DefaultRetryAttempts)
+}
+
+// SetRetryAttempts sets how many times Retry runs an operation (DB_RETRY_ATTEMPTS); 1
+// disables the retries and non-positive values keep the current setting
+func SetRetryAttempts(attempts int) {
+	if attempts > 0 {
+		retryAttempts.Store(int64(attempts))
+	}
+}
+
+// Retry runs fn until it succeeds, fails with an error that is not retryable (deadlock,
+// resource busy, unavailable listener), the attempts run out or ctx is done. A lost
+// connection is not retried, since fn may already have committed; see RetryIdempotent.
+// Waits double from 100ms up to 2s, with jitter. The returned error is an *OracleError.
+func Retry(ctx context.Context, operation string, fn func() error) error {
+	return retry(ctx, operation, false, fn)
+}
+
+// RetryIdempotent is Retry for operations that have the same effect when run twice, such as
+// queries and MERGE statements, which are retried after a lost connection as well
+func RetryIdempotent(ctx context.Context, operation string, fn func() error) error {
+	return retry(ctx, operation, true, fn)
+}
+
+func retry(ctx context.Context, operation string, idempotent bool, fn func() error) error {
+	attempts := int(retryAttempts.Load())
+	backoff := retryInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		classified := ClassifyError(err)
+		if !classified.Retryable() || (classified.Ambiguous() && !idempotent) || attempt >= attempts {
+			return classified
+		}
+
+		wait := withJitter(backoff)
+		log.Printf("%s falhou com erro transitório %s (tentativa %d de %d), nova tentativa em %s: %v",
+			operation, classified.Describe(), attempt, attempts, wait.Round(time.Millisecond), err)
+
+		select {
+		case <-ctx.Done():
+			return classified
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+		if backoff > retryMaxBackoff {
+			backoff = retryMaxBackoff
+		}
+	}
+}
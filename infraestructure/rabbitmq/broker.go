@@ -0,0 +1,282 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+)
+
+// AMQPBroker implements broker.Consumer and broker.Publisher on RabbitMQ through a
+// ConnectionManager: consumers get their own channel on the consuming connection, while
+// publishing and declarations borrow pooled channels of the publishing connection.
+type AMQPBroker struct {
+	url string
+
+	once    sync.Once
+	manager *ConnectionManager
+	err     error
+}
+
+// NewAMQPBroker creates a broker on the shared manager of url, connected on first use
+func NewAMQPBroker(url string) *AMQPBroker {
+	return &AMQPBroker{url: url}
+}
+
+// NewAMQPBrokerWithManager creates a broker on manager
+func NewAMQPBrokerWithManager(manager *ConnectionManager) *AMQPBroker {
+	b := &AMQPBroker{manager: manager}
+	b.once.Do(func() {})
+	return b
+}
+
+// connectionManager returns the manager, creating the shared one of url on first use
+func (b *AMQPBroker) connectionManager() (*ConnectionManager, error) {
+	b.once.Do(func() {
+		b.manager, b.err = SharedConnectionManager(b.url)
+	})
+	if b.err != nil {
+		return nil, fmt.Errorf("%w: %v", broker.ErrUnavailable, b.err)
+	}
+	return b.manager, nil
+}
+
+// OnReconnect runs fn whenever the publishing connection is re-established, so publishers
+// can declare their topology again; it implements broker.ReconnectNotifier
+func (b *AMQPBroker) OnReconnect(fn func()) func() {
+	manager, err := b.connectionManager()
+	if err != nil {
+		return func() {}
+	}
+	return manager.OnReconnect(func(event ReconnectEvent) {
+		if event.Role == RolePublish {
+			fn()
+		}
+	})
+}
+
+// withChannel runs fn on a pooled publishing channel; a failed fn discards it, since AMQP
+// errors such as a mismatched declaration also close the channel on the server
+func (b *AMQPBroker) withChannel(ctx context.Context, fn func(ch *amqp.Channel) error) error {
+	manager, err := b.connectionManager()
+	if err != nil {
+		return err
+	}
+
+	ch, release, err := manager.AcquireChannel(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ch)
+	release(err != nil)
+	return err
+}
+
+// Publish sends msg through exchange with routingKey
+func (b *AMQPBroker) Publish(ctx context.Context, exchange, routingKey string, msg broker.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	publishing := toPublishing(msg)
+	return b.withChannel(ctx, func(ch *amqp.Channel) error {
+		return ch.Publish(
+			exchange,   // exchange
+			routingKey, // routing key
+			false,      // mandatory
+			false,      // immediate
+			publishing)
+	})
+}
+
+// PublishDelayed delivers msg to queue after delay through a delay queue
+// "<queue>.delay.<ms>": its messages expire after delay and are dead-lettered to queue by
+// RabbitMQ, so a waiting message survives a restart of the application. One queue per delay
+// keeps every message of a queue expiring in order; unused delay queues are deleted by the
+// server after x-expires.
+func (b *AMQPBroker) PublishDelayed(ctx context.Context, queue string, msg broker.Message, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ttl := delay.Milliseconds()
+	if ttl < 1 {
+		ttl = 1
+	}
+	delayQueue := fmt.Sprintf("%s.delay.%d", queue, ttl)
+	publishing := toPublishing(msg)
+
+	return b.withChannel(ctx, func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(
+			delayQueue, // name
+			true,       // durable
+			false,      // delete when unused
+			false,      // exclusive
+			false,      // no-wait
+			amqp.Table{
+				"x-message-ttl":             ttl,
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queue,
+				"x-expires":                 ttl + time.Hour.Milliseconds(),
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("erro declarando fila de atraso %s: %w", delayQueue, err)
+		}
+		return ch.Publish("", delayQueue, false, false, publishing)
+	})
+}
+
+// toPublishing converts a broker message
+func toPublishing(msg broker.Message) amqp.Publishing {
+	publishing := amqp.Publishing{
+		ContentType:   msg.ContentType,
+		Type:          msg.Type,
+		CorrelationId: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		Headers:       amqp.Table(msg.Headers),
+		Body:          msg.Body,
+	}
+	if msg.Persistent {
+		publishing.DeliveryMode = amqp.Persistent
+	}
+	return publishing
+}
+
+// DeclareQueue declares a durable queue
+func (b *AMQPBroker) DeclareQueue(name string) error {
+	return b.withChannel(context.Background(), func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(
+			name,  // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		)
+		return err
+	})
+}
+
+// DeclareExchange declares a durable exchange
+func (b *AMQPBroker) DeclareExchange(name, kind string) error {
+	return b.withChannel(context.Background(), func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclare(
+			name,  // name
+			kind,  // type
+			true,  // durable
+			false, // auto-deleted
+			false, // internal
+			false, // no-wait
+			nil,   // arguments
+		)
+	})
+}
+
+// BindQueue binds queue to exchange with routingKey
+func (b *AMQPBroker) BindQueue(queue, routingKey, exchange string) error {
+	return b.withChannel(context.Background(), func(ch *amqp.Channel) error {
+		return ch.QueueBind(queue, routingKey, exchange, false, nil)
+	})
+}
+
+// Consume declares the queue and delivers its messages on a dedicated channel of the
+// consuming connection with the given prefetch. The channel is closed when ctx is done, the
+// connection drops or the server cancels the consumer (e.g. the queue was deleted), and the
+// broker then requeues the unsettled deliveries.
+func (b *AMQPBroker) Consume(ctx context.Context, queue string, prefetch int) (<-chan broker.Delivery, error) {
+	manager, err := b.connectionManager()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := manager.Connection(ctx, RoleConsume)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open RabbitMQ channel: %v", broker.ErrUnavailable, err)
+	}
+
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("erro configurando QoS: %w", err)
+	}
+
+	_, err = ch.QueueDeclare(
+		queue, // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("erro declarando fila %s: %w", queue, err)
+	}
+
+	msgs, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("erro consumindo fila %s: %w", queue, err)
+	}
+
+	cancelled := ch.NotifyCancel(make(chan string, 1))
+
+	out := make(chan broker.Delivery)
+	go func() {
+		defer close(out)
+		defer ch.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case tag := <-cancelled:
+				log.Printf("Consumer %s of queue %s cancelled by RabbitMQ", tag, queue)
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- toDelivery(queue, msg):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// toDelivery converts an AMQP delivery
+func toDelivery(queue string, msg amqp.Delivery) broker.Delivery {
+	return broker.NewDelivery(queue, broker.Message{
+		Body:          msg.Body,
+		ContentType:   msg.ContentType,
+		Type:          msg.Type,
+		CorrelationId: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		Headers:       map[string]interface{}(msg.Headers),
+		Persistent:    msg.DeliveryMode == amqp.Persistent,
+	}, msg.Redelivered, amqpAcknowledger{msg})
+}
+
+type amqpAcknowledger struct {
+	delivery amqp.Delivery
+}
+
+func (a amqpAcknowledger) Ack() error {
+	return a.delivery.Ack(false)
+}
+
+func (a amqpAcknowledger) Nack(requeue bool) error {
+	return a.delivery.Nack(false, requeue)
+}
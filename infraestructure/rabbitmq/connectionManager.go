@@ -0,0 +1,413 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+)
+
+// ConnectionRole separates the consuming connection from the publishing one, so a flood of
+// publishes (or a blocked publisher under a broker memory alarm) never stalls consumption
+type ConnectionRole string
+
+const (
+	RoleConsume ConnectionRole = "consume"
+	RolePublish ConnectionRole = "publish"
+)
+
+const (
+	// connectWait is how long callers wait for a connection being (re)established before
+	// the broker is reported unavailable
+	connectWait = 5 * time.Second
+
+	reconnectInitialBackoff = 1 * time.Second
+	reconnectMaxBackoff     = 30 * time.Second
+)
+
+var (
+	publishChannels atomic.Int64
+
+	managersMu sync.Mutex
+	managers   = map[string]*ConnectionManager{}
+)
+
+func init() {
+	publishChannels.Store(entities.DEFAULT_RABBITMQ_PUBLISH_CHANNELS)
+}
+
+// SetPublishChannels sets how many publishing channels each manager keeps open at most
+// (RABBITMQ_PUBLISH_CHANNELS); it applies to managers created afterwards
+func SetPublishChannels(n int) {
+	if n > 0 {
+		publishChannels.Store(int64(n))
+	}
+}
+
+// ReconnectEvent tells subscribers that a connection was re-established
+type ReconnectEvent struct {
+	Role ConnectionRole
+	At   time.Time
+}
+
+// ConnectionManager keeps one consuming and one publishing connection to RabbitMQ. A single
+// supervisory goroutine dials them, waits for NotifyClose and redials with exponential
+// backoff, then tells the OnReconnect subscribers. Publishing channels come from a pool that
+// is discarded whenever the publishing connection is replaced.
+type ConnectionManager struct {
+	url  string
+	done chan struct{}
+
+	mu          sync.Mutex
+	conns       map[ConnectionRole]*amqp.Connection
+	ready       map[ConnectionRole]chan struct{} // closed while the role is connected
+	generation  uint64                           // incremented on each publishing connection
+	subscribers map[int]func(ReconnectEvent)
+	nextSubID   int
+
+	idle  chan pooledChannel
+	slots chan struct{}
+
+	closeOnce sync.Once
+}
+
+// pooledChannel is a publishing channel and the publishing connection it belongs to
+type pooledChannel struct {
+	ch         *amqp.Channel
+	closed     chan *amqp.Error
+	generation uint64
+}
+
+// SharedConnectionManager returns the manager of url, starting it on first use
+func SharedConnectionManager(url string) (*ConnectionManager, error) {
+	if url == "" {
+		return nil, fmt.Errorf("RABBITMQ_URL is not defined")
+	}
+
+	managersMu.Lock()
+	defer managersMu.Unlock()
+
+	m, ok := managers[url]
+	if !ok {
+		m = NewConnectionManager(url)
+		managers[url] = m
+	}
+	return m, nil
+}
+
+// CloseConnections closes every shared manager
+func CloseConnections() {
+	managersMu.Lock()
+	defer managersMu.Unlock()
+
+	for url, m := range managers {
+		m.Close()
+		delete(managers, url)
+	}
+}
+
+// NewConnectionManager creates a manager for url and starts its supervisory goroutine
+func NewConnectionManager(url string) *ConnectionManager {
+	size := int(publishChannels.Load())
+	m := &ConnectionManager{
+		url:         url,
+		done:        make(chan struct{}),
+		conns:       map[ConnectionRole]*amqp.Connection{},
+		ready:       map[ConnectionRole]chan struct{}{RoleConsume: make(chan struct{}), RolePublish: make(chan struct{})},
+		subscribers: map[int]func(ReconnectEvent){},
+		idle:        make(chan pooledChannel, size),
+		slots:       make(chan struct{}, size),
+	}
+	go m.supervise()
+	return m
+}
+
+// Close stops the supervisor and closes both connections
+func (m *ConnectionManager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+// IsConnected reports whether both connections are up
+func (m *ConnectionManager) IsConnected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.conns[RoleConsume] != nil && m.conns[RolePublish] != nil
+}
+
+// OnReconnect registers fn to run after a connection is re-established, before the pool
+// hands out channels of the new publishing connection. fn runs on the supervisory goroutine
+// and must not block. The returned function removes the subscription.
+func (m *ConnectionManager) OnReconnect(fn func(ReconnectEvent)) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextSubID
+	m.nextSubID++
+	m.subscribers[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.subscribers, id)
+	}
+}
+
+// Connection returns the connection of role, waiting up to connectWait while it is being
+// (re)established
+func (m *ConnectionManager) Connection(ctx context.Context, role ConnectionRole) (*amqp.Connection, error) {
+	conn, _, err := m.connection(ctx, role)
+	return conn, err
+}
+
+func (m *ConnectionManager) connection(ctx context.Context, role ConnectionRole) (*amqp.Connection, uint64, error) {
+	timer := time.NewTimer(connectWait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return nil, 0, fmt.Errorf("%w: connection manager closed", broker.ErrUnavailable)
+		default:
+		}
+
+		m.mu.Lock()
+		conn, generation, ready := m.conns[role], m.generation, m.ready[role]
+		m.mu.Unlock()
+		if conn != nil {
+			return conn, generation, nil
+		}
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-m.done:
+			return nil, 0, fmt.Errorf("%w: connection manager closed", broker.ErrUnavailable)
+		case <-timer.C:
+			return nil, 0, fmt.Errorf("%w: RabbitMQ %s connection is down", broker.ErrUnavailable, role)
+		}
+	}
+}
+
+// AcquireChannel returns a publishing channel from the pool, opening one when none is idle.
+// At most RABBITMQ_PUBLISH_CHANNELS are in use; release must be called once, with broken
+// set when an operation failed, since AMQP errors close the channel on the server.
+func (m *ConnectionManager) AcquireChannel(ctx context.Context) (*amqp.Channel, func(broken bool), error) {
+	select {
+	case m.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	pooled, err := m.pooledChannel(ctx)
+	if err != nil {
+		<-m.slots
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	release := func(broken bool) {
+		once.Do(func() {
+			m.releaseChannel(pooled, broken)
+			<-m.slots
+		})
+	}
+	return pooled.ch, release, nil
+}
+
+// pooledChannel takes an idle channel of the current publishing connection or opens one
+func (m *ConnectionManager) pooledChannel(ctx context.Context) (pooledChannel, error) {
+	conn, generation, err := m.connection(ctx, RolePublish)
+	if err != nil {
+		return pooledChannel{}, err
+	}
+
+	for {
+		var pooled pooledChannel
+		select {
+		case pooled = <-m.idle:
+		default:
+			return m.openChannel(conn, generation)
+		}
+		if pooled.generation == generation && !pooled.isClosed() {
+			return pooled, nil
+		}
+		pooled.ch.Close()
+	}
+}
+
+// openChannel opens a publishing channel on conn
+func (m *ConnectionManager) openChannel(conn *amqp.Connection, generation uint64) (pooledChannel, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return pooledChannel{}, fmt.Errorf("%w: failed to open RabbitMQ channel: %v", broker.ErrUnavailable, err)
+	}
+	return pooledChannel{ch: ch, closed: ch.NotifyClose(make(chan *amqp.Error, 1)), generation: generation}, nil
+}
+
+// releaseChannel returns a healthy channel of the current connection to the pool
+func (m *ConnectionManager) releaseChannel(pooled pooledChannel, broken bool) {
+	m.mu.Lock()
+	current := pooled.generation == m.generation && m.conns[RolePublish] != nil
+	m.mu.Unlock()
+
+	if broken || !current || pooled.isClosed() {
+		pooled.ch.Close()
+		return
+	}
+	select {
+	case m.idle <- pooled:
+	default:
+		pooled.ch.Close()
+	}
+}
+
+func (p pooledChannel) isClosed() bool {
+	select {
+	case <-p.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// supervise is the only goroutine that dials: it connects the missing roles with backoff and
+// waits for either connection to close
+func (m *ConnectionManager) supervise() {
+	closed := map[ConnectionRole]chan *amqp.Error{}
+	connectedBefore := map[ConnectionRole]bool{}
+	backoff := reconnectInitialBackoff
+
+	for {
+		for _, role := range []ConnectionRole{RoleConsume, RolePublish} {
+			if closed[role] != nil {
+				continue
+			}
+			conn, err := amqp.Dial(m.url)
+			if err != nil {
+				log.Printf("Failed to connect to RabbitMQ (%s): %v", role, err)
+				continue
+			}
+			closed[role] = conn.NotifyClose(make(chan *amqp.Error, 1))
+			m.connected(role, conn, connectedBefore[role])
+			connectedBefore[role] = true
+		}
+
+		if closed[RoleConsume] == nil || closed[RolePublish] == nil {
+			wait := withJitter(backoff)
+			log.Printf("Retrying RabbitMQ connection in %s", wait.Round(time.Millisecond))
+			select {
+			case <-m.done:
+				m.closeAll()
+				return
+			case <-time.After(wait):
+			}
+			backoff *= 2
+			if backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+			continue
+		}
+		backoff = reconnectInitialBackoff
+
+		select {
+		case <-m.done:
+			m.closeAll()
+			return
+		case err := <-closed[RoleConsume]:
+			m.lost(RoleConsume, err)
+			closed[RoleConsume] = nil
+		case err := <-closed[RolePublish]:
+			m.lost(RolePublish, err)
+			closed[RolePublish] = nil
+		}
+	}
+}
+
+// connected publishes the new connection of role and notifies the subscribers on reconnection
+func (m *ConnectionManager) connected(role ConnectionRole, conn *amqp.Connection, reconnect bool) {
+	m.mu.Lock()
+	m.conns[role] = conn
+	if role == RolePublish {
+		m.generation++
+	}
+	subscribers := make([]func(ReconnectEvent), 0, len(m.subscribers))
+	for _, fn := range m.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	m.mu.Unlock()
+
+	if reconnect {
+		log.Printf("Successfully reconnected to RabbitMQ (%s)", role)
+		event := ReconnectEvent{Role: role, At: time.Now()}
+		for _, fn := range subscribers {
+			fn(event)
+		}
+	} else {
+		log.Printf("Successfully connected to RabbitMQ (%s)", role)
+	}
+
+	m.mu.Lock()
+	close(m.ready[role])
+	m.mu.Unlock()
+}
+
+// lost forgets the closed connection of role and, for publishing, its idle channels
+func (m *ConnectionManager) lost(role ConnectionRole, err *amqp.Error) {
+	if err != nil {
+		log.Printf("RabbitMQ %s connection closed: %v. Reconnecting...", role, err)
+	} else {
+		log.Printf("RabbitMQ %s connection closed. Reconnecting...", role)
+	}
+
+	m.mu.Lock()
+	m.conns[role] = nil
+	m.ready[role] = make(chan struct{})
+	m.mu.Unlock()
+
+	if role == RolePublish {
+		m.drainIdle()
+	}
+}
+
+// closeAll closes both connections when the manager is closed
+func (m *ConnectionManager) closeAll() {
+	m.mu.Lock()
+	conns := m.conns
+	m.conns = map[ConnectionRole]*amqp.Connection{}
+	m.ready = map[ConnectionRole]chan struct{}{RoleConsume: make(chan struct{}), RolePublish: make(chan struct{})}
+	m.mu.Unlock()
+
+	m.drainIdle()
+	for _, conn := range conns {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
+
+// drainIdle closes the idle publishing channels
+func (m *ConnectionManager) drainIdle() {
+	for {
+		select {
+		case pooled := <-m.idle:
+			pooled.ch.Close()
+		default:
+			return
+		}
+	}
+}
+
+// withJitter spreads reconnections of several instances by ±20%
+func withJitter(d time.Duration) time.Duration {
+	jitter := time.Duration(rand.Int63n(int64(d)/5*2+1)) - d/5
+	return d + jitter
+}
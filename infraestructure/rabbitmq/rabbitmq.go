@@ -1,21 +1,12 @@
 package rabbitmq
 
 import (
-	"fmt"
 	"log"
 	"os"
-	"sync"
-	"time"
 
-	"github.com/streadway/amqp"
 	"github.com/thiagohmm/integracaocron/configuration"
 )
 
-var (
-	globalConn *amqp.Connection
-	connMux    sync.Mutex
-)
-
 // RabbitMQConsumer define a estrutura para consumir mensagens via RabbitMQ.
 type RabbitMQConsumer struct {
 	URL string
@@ -27,64 +18,12 @@ type HealthChecker interface {
 	IsHealthy() bool
 }
 
-// GetRabbitMQConnection retorna a conexão ativa com o RabbitMQ, reutilizando-a se ela já estiver estabelecida.
-func GetRabbitMQConnection(rabbitmqURL string) (*amqp.Connection, error) {
+// IsHealthy retorna true se as conexões de consumo e de publicação estiverem ativas.
+func (c RabbitMQConsumer) IsHealthy() bool {
+	rabbitmqURL := c.URL
 	if rabbitmqURL == "" {
-		return nil, fmt.Errorf("RABBITMQ_URL is not defined")
-	}
-
-	connMux.Lock()
-	defer connMux.Unlock()
-
-	// Se já existe uma conexão ativa, retorna-a:
-	if globalConn != nil && !globalConn.IsClosed() {
-		return globalConn, nil
-	}
-
-	// Tentativa de conexão em loop até obter sucesso.
-	for {
-		conn, err := amqp.Dial(rabbitmqURL)
-		if err == nil {
-			globalConn = conn
-			log.Println("Successfully connected to RabbitMQ")
-			// Inicia o monitoramento da conexão em background.
-			go monitorConnection(rabbitmqURL)
-			return globalConn, nil
-		}
-		log.Printf("Failed to connect to RabbitMQ: %v. Retrying in 5 seconds...", err)
-		time.Sleep(5 * time.Second)
-	}
-}
-
-// monitorConnection monitora a conexão e reconecta caso a conexão seja fechada.
-func monitorConnection(rabbitmqURL string) {
-	for {
-		time.Sleep(1 * time.Second)
-		connMux.Lock()
-		if globalConn == nil || globalConn.IsClosed() {
-			connMux.Unlock()
-			// Reproduz o mesmo loop de conexão até obter sucesso.
-			for {
-				conn, err := amqp.Dial(rabbitmqURL)
-				if err == nil {
-					connMux.Lock()
-					globalConn = conn
-					connMux.Unlock()
-					log.Println("Successfully reconnected to RabbitMQ")
-					break
-				}
-				log.Printf("Failed to reconnect to RabbitMQ: %v. Retrying in 5 seconds...", err)
-				time.Sleep(5 * time.Second)
-			}
-		} else {
-			connMux.Unlock()
-		}
+		rabbitmqURL = os.Getenv("ENV_RABBITMQ")
 	}
-}
-
-// IsHealthy tenta reutilizar a conexão e retorna true se ela estiver ativa.
-func (c RabbitMQConsumer) IsHealthy() bool {
-	rabbitmqURL := os.Getenv("ENV_RABBITMQ")
 	if rabbitmqURL == "" {
 		cfg, err := loadConfig()
 		if err != nil {
@@ -93,13 +32,12 @@ func (c RabbitMQConsumer) IsHealthy() bool {
 		}
 		rabbitmqURL = cfg.ENV_RABBITMQ
 	}
-	conn, err := GetRabbitMQConnection(rabbitmqURL)
+	manager, err := SharedConnectionManager(rabbitmqURL)
 	if err != nil {
 		log.Printf("RabbitMQ connection failed: %v", err)
 		return false
 	}
-	// A conexão é reutilizada. Não fechar aqui.
-	return conn != nil && !conn.IsClosed()
+	return manager.IsConnected()
 }
 
 func loadConfig() (*configuration.Conf, error) {
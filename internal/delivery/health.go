@@ -0,0 +1,66 @@
+package rabbitmq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
+)
+
+// HealthResponse é o corpo de GET /health
+type HealthResponse struct {
+	Status   string                     `json:"status"` // UP, DEGRADED (circuit breaker aberto) ou DOWN (banco fora)
+	Database string                     `json:"database"`
+	Circuits []database.CircuitSnapshot `json:"circuits"`
+}
+
+// HealthServer expõe GET /health com o estado do banco e dos circuit breakers
+type HealthServer struct {
+	Addr string
+	DB   *sql.DB
+}
+
+// Start atende em Addr em segundo plano; erros do servidor são apenas logados
+func (h *HealthServer) Start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", h.handleHealth)
+
+	server := &http.Server{Addr: h.Addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		log.Printf("Health check disponível em %s/health", h.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Erro no servidor de health check: %v", err)
+		}
+	}()
+}
+
+func (h *HealthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: "UP", Database: "UP", Circuits: database.CircuitSnapshots()}
+
+	for _, circuit := range response.Circuits {
+		if circuit.State != database.CircuitClosed {
+			response.Status = "DEGRADED"
+		}
+	}
+
+	status := http.StatusOK
+	if h.DB != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		if err := h.DB.PingContext(ctx); err != nil {
+			response.Status, response.Database = "DOWN", "DOWN"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Erro ao escrever resposta do health check: %v", err)
+	}
+}
@@ -1,17 +1,23 @@
 package rabbitmq
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/streadway/amqp"
 	"github.com/thiagohmm/integracaocron/domain/entities"
 	"github.com/thiagohmm/integracaocron/domain/usecases"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
 	infraestructure "github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
 )
 
@@ -20,100 +26,82 @@ type Listener struct {
 	IntegrationUc            *usecases.IntegrationJobUseCase
 	ProductIntegrationUC     *usecases.ProductIntegrationUseCase
 	PromotionNormalizationUC *usecases.PromotionNormalizationUseCase
+	MarketingStructureUC     *usecases.MarketingStructureIntegrationUseCase
+	ProductExportUC          *usecases.ProductExportUseCase
 
-	//EstruturaMercadologica *usecases.EstruturaMercadologicaUseCase --- IGNORE ---
 	//Produtos               *usecases.ProdutosUseCase --- IGNORE ---
 
 	Workers int // número de workers concorrentes
-}
 
-func (l *Listener) getConnectionWithWait(rabbitmqurl string) (*amqp.Connection, error) {
-	log.Printf("Iniciando tentativa de conexão com RabbitMQ...")
+	// MessageTimeout limita o processamento de cada mensagem (0 = sem limite). O header
+	// "x-deadline" (RFC3339) da mensagem pode antecipar o prazo.
+	MessageTimeout time.Duration
 
-	for {
-		conn, err := infraestructure.GetRabbitMQConnection(rabbitmqurl)
-		if err == nil {
-			log.Printf("Conexão com RabbitMQ estabelecida com sucesso")
-			return conn, nil
-		}
-		log.Printf("Erro conectando ao RabbitMQ: %v. Tentando novamente em 5 segundos...", err)
-		time.Sleep(5 * time.Second)
-	}
+	// MaxRequeues limita quantas vezes uma mensagem com erro transitório é republicada
+	// antes de ir para a dead-letter (0 = DEFAULT_MESSAGE_MAX_REQUEUES)
+	MaxRequeues int
+
+	// RetryDelay é a espera antes da primeira republicação, dobrando a cada nova tentativa
+	// até MAX_MESSAGE_RETRY_DELAY_SECONDS (0 = DEFAULT_MESSAGE_RETRY_DELAY_SECONDS)
+	RetryDelay time.Duration
+
+	// Consumer lê a fila e Publisher envia as respostas em ReplyTo; sem eles o listener usa
+	// o RabbitMQ da URL passada a ListenToQueue. broker.MemoryBroker roda sem servidor.
+	Consumer  broker.Consumer
+	Publisher broker.Publisher
+
+	ctx context.Context // cancelado no shutdown, interrompe as mensagens em andamento
+
+	// process trata cada mensagem; nil usa processMessage (os testes o substituem)
+	process func(ctx context.Context, msg broker.Delivery) (error, string)
 }
 
+// retryCountHeader conta as novas tentativas de uma mensagem que falhou com erro
+// transitório. Filas clássicas não informam quantas vezes a mensagem foi entregue, então o
+// listener confirma a original e a republica com o contador incrementado.
+const retryCountHeader = "x-retry-count"
+
 func (l *Listener) ListenToQueue(rabbitmqurl string) error {
-	if rabbitmqurl == "" {
+	return l.ListenToQueueContext(context.Background(), rabbitmqurl)
+}
+
+// ListenToQueueContext consome a fila até ctx ser cancelado. O contexto de cada mensagem
+// deriva de ctx, então o cancelamento interrompe as operações de banco em andamento.
+func (l *Listener) ListenToQueueContext(ctx context.Context, rabbitmqurl string) error {
+	if rabbitmqurl == "" && (l.Consumer == nil || l.Publisher == nil) {
 		return fmt.Errorf("rabbitmq URL cannot be empty")
 	}
+	if l.Consumer == nil || l.Publisher == nil {
+		amqpBroker := infraestructure.NewAMQPBroker(rabbitmqurl)
+		if l.Consumer == nil {
+			l.Consumer = amqpBroker
+		}
+		if l.Publisher == nil {
+			l.Publisher = amqpBroker
+		}
+	}
 
 	if l.Workers <= 0 {
 		l.Workers = 20 // default to 20 workers if not set
 	}
 
-	log.Printf("Iniciando listener RabbitMQ com %d workers - Container sempre ativo", l.Workers)
-
-	// Loop infinito para manter a aplicação sempre ativa
-	for {
-		log.Printf("Tentando conectar ao RabbitMQ...")
-
-		conn, err := l.getConnectionWithWait(rabbitmqurl)
-		if err != nil {
-			log.Printf("Erro conectando ao RabbitMQ: %v. Tentando novamente em 5 segundos...", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		log.Printf("Conectado ao RabbitMQ com sucesso")
+	l.ctx = ctx
 
-		ch, err := conn.Channel()
-		if err != nil {
-			log.Printf("Erro criando canal RabbitMQ: %v. Tentando reconectar...", err)
-			conn.Close()
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		// Configurar prefetch count para controlar quantas mensagens cada worker recebe
-		err = ch.Qos(
-			l.Workers, // prefetch count
-			0,         // prefetch size
-			false,     // global
-		)
-		if err != nil {
-			log.Printf("Erro configurando QoS: %v. Tentando reconectar...", err)
-			ch.Close()
-			conn.Close()
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		queue := "integracaoCron"
+	log.Printf("Iniciando listener RabbitMQ com %d workers - Container sempre ativo", l.Workers)
 
-		// Declare queue to ensure it exists
-		_, err = ch.QueueDeclare(
-			queue, // name
-			true,  // durable
-			false, // delete when unused
-			false, // exclusive
-			false, // no-wait
-			nil,   // arguments
-		)
-		if err != nil {
-			log.Printf("Erro declarando fila %s: %v. Tentando reconectar...", queue, err)
-			ch.Close()
-			conn.Close()
-			time.Sleep(5 * time.Second)
-			continue
-		}
+	queue := "integracaoCron"
 
-		log.Printf("Fila '%s' declarada com sucesso", queue)
+	// Loop infinito para manter a aplicação sempre ativa
+	for ctx.Err() == nil {
+		log.Printf("Tentando consumir a fila %s...", queue)
 
-		msgs, err := ch.Consume(queue, "", false, false, false, false, nil)
+		msgs, err := l.Consumer.Consume(ctx, queue, l.Workers)
 		if err != nil {
-			log.Printf("Erro consumindo mensagens: %v. Tentando reconectar...", err)
-			ch.Close()
-			conn.Close()
-			time.Sleep(5 * time.Second)
+			log.Printf("Erro consumindo fila %s: %v. Tentando novamente em 5 segundos...", queue, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
 			continue
 		}
 
@@ -122,47 +110,30 @@ func (l *Listener) ListenToQueue(rabbitmqurl string) error {
 		// WaitGroup para controlar os workers
 		var wg sync.WaitGroup
 
-		// Canal para sinalizar shutdown dos workers
-		//workerShutdown := make(chan struct{})
-
-		// Iniciar workers
+		// Iniciar workers; eles terminam quando o canal de mensagens fecha, seja pela queda
+		// da conexão ou pelo cancelamento do listener
 		for i := 0; i < l.Workers; i++ {
 			wg.Add(1)
-			//go l.worker(i, msgs, &wg, workerShutdown)
-			go l.worker(i, msgs, &wg, nil) // Passando nil para workerShutdown, pois não estamos usando shutdown neste exemplo
+			go l.worker(i, msgs, &wg)
 		}
 
 		log.Printf("Listener iniciado com %d workers - Aguardando mensagens...", l.Workers)
 
-		// Monitorar a conexão para detectar quando ela fecha
-		connClosed := make(chan *amqp.Error, 1)
-		conn.NotifyClose(connClosed)
-
-		// Aguardar até que a conexão seja fechada
-		closeErr := <-connClosed
-		if closeErr != nil {
-			log.Printf("Conexão RabbitMQ fechada com erro: %v. Reiniciando workers...", closeErr)
-		} else {
-			log.Printf("Conexão RabbitMQ fechada normalmente. Reiniciando workers...")
-		}
-
-		// Sinalizar para os workers pararem
-		//close(workerShutdown)
-
-		// Fechar canal de mensagens para parar os workers
-		ch.Close()
-
-		// Aguardar todos os workers terminarem
-		log.Printf("Aguardando workers terminarem...")
 		wg.Wait()
-		log.Printf("Todos os workers finalizados. Reconectando em 5 segundos...")
-
-		// Fechar conexão
-		conn.Close()
+		if ctx.Err() != nil {
+			log.Printf("Listener cancelado: %v. Workers finalizados", ctx.Err())
+			break
+		}
+		log.Printf("Canal de mensagens fechado. Todos os workers finalizados. Reconectando em 5 segundos...")
 
 		// Pequena pausa antes de tentar reconectar
-		time.Sleep(5 * time.Second)
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
 	}
+
+	return ctx.Err()
 }
 
 func restartApplication() {
@@ -176,7 +147,7 @@ func restartApplication() {
 	}
 }
 
-func (l *Listener) worker(id int, msgs <-chan amqp.Delivery, wg *sync.WaitGroup, workerShutdown <-chan struct{}) {
+func (l *Listener) worker(id int, msgs <-chan broker.Delivery, wg *sync.WaitGroup) {
 	defer wg.Done()
 	defer func() {
 		if r := recover(); r != nil {
@@ -199,7 +170,26 @@ func (l *Listener) worker(id int, msgs <-chan amqp.Delivery, wg *sync.WaitGroup,
 		messageCount++
 		log.Printf("Worker %d processando mensagem #%d", id, messageCount)
 
-		if err, _ := l.processMessage(msg); err != nil {
+		// Prazo do remetente já vencido: processar só terminaria em timeout
+		deadline, hasDeadline := headerDeadline(msg)
+		if hasDeadline && !time.Now().Before(deadline) {
+			log.Printf("Worker %d - Mensagem #%d com x-deadline %s vencido, enviando para dead-letter",
+				id, messageCount, deadline.Format(time.RFC3339))
+			if nackErr := msg.Nack(false); nackErr != nil {
+				log.Printf("Worker %d - Erro ao enviar Nack para a mensagem #%d: %v", id, messageCount, nackErr)
+			}
+			idleTime = time.Now()
+			continue
+		}
+
+		process := l.process
+		if process == nil {
+			process = l.processMessage
+		}
+		ctx, cancel := l.messageContext(deadline)
+		err, response := process(ctx, msg)
+		cancel()
+		if err != nil {
 			// Criar span para rastreamento de erro
 
 			log.Printf("Worker %d - Erro processando mensagem #%d: %v", id, messageCount, err)
@@ -208,10 +198,66 @@ func (l *Listener) worker(id int, msgs <-chan amqp.Delivery, wg *sync.WaitGroup,
 			log.Printf("Worker %d - Mensagem #%d processada com sucesso", id, messageCount)
 		}
 
-		if err := msg.Ack(false); err != nil {
+		// Mensagem interrompida pelo shutdown volta para a fila para outra instância processar
+		if l.ctx != nil && l.ctx.Err() != nil {
+			log.Printf("Worker %d - Mensagem #%d interrompida pelo shutdown, devolvendo à fila", id, messageCount)
+			if nackErr := msg.Nack(true); nackErr != nil {
+				log.Printf("Worker %d - Erro ao devolver mensagem #%d: %v", id, messageCount, nackErr)
+			}
+			continue
+		}
+
+		// Erro transitório (deadlock, conexão perdida, timeout...) volta para a fila após
+		// RetryDelay, até MaxRequeues novas tentativas e enquanto o x-deadline permitir; os
+		// demais vão para a dead-letter exchange da fila. Rejeitada por circuito aberto, a
+		// mensagem espera no broker até a chamada de teste, sem contar como tentativa.
+		if err != nil && database.IsTransient(err) {
+			retries := retryCount(msg)
+			next := retries + 1
+			delay := l.retryDelay(next)
+			var circuitErr *database.CircuitOpenError
+			circuitOpen := errors.As(err, &circuitErr)
+			if circuitOpen {
+				next = retries
+				delay = l.retryDelay(1)
+				if wait := time.Until(circuitErr.RetryAt); wait > delay {
+					delay = wait
+				}
+			}
+			switch {
+			case !circuitOpen && retries >= l.maxRequeues():
+			case hasDeadline && !time.Now().Add(delay).Before(deadline):
+				log.Printf("Worker %d - x-deadline %s da mensagem #%d vence antes da nova tentativa",
+					id, deadline.Format(time.RFC3339), messageCount)
+			default:
+				if circuitOpen {
+					log.Printf("Worker %d - Mensagem #%d adiada %s pelo circuit breaker %s", id, messageCount, delay, circuitErr.Name)
+				} else {
+					log.Printf("Worker %d - Erro transitório %s na mensagem #%d (nova tentativa %d de %d em %s), devolvendo à fila",
+						id, database.ClassifyError(err).Describe(), messageCount, next, l.maxRequeues(), delay)
+				}
+				if requeueErr := l.requeue(msg, next, delay); requeueErr != nil {
+					log.Printf("Worker %d - Erro ao devolver mensagem #%d: %v", id, messageCount, requeueErr)
+				}
+				idleTime = time.Now()
+				continue
+			}
+		}
+
+		if response != "" && msg.ReplyTo != "" {
+			l.reply(msg, response)
+		}
+
+		if err != nil {
+			log.Printf("Worker %d - Erro permanente %s na mensagem #%d, enviando para dead-letter",
+				id, database.ClassifyError(err).Describe(), messageCount)
+			if nackErr := msg.Nack(false); nackErr != nil {
+				log.Printf("Worker %d - Erro ao enviar Nack para a mensagem #%d: %v", id, messageCount, nackErr)
+			}
+		} else if err := msg.Ack(); err != nil {
 			log.Printf("Worker %d - Erro ao confirmar mensagem #%d: %v. Tentando enviar Nack...", id, messageCount, err)
 			// Se falhar o ack, enviar nack sem requeue para não tentar processar novamente
-			if nackErr := msg.Nack(false, false); nackErr != nil {
+			if nackErr := msg.Nack(false); nackErr != nil {
 				log.Printf("Worker %d - Erro ao enviar Nack para a mensagem #%d: %v", id, messageCount, nackErr)
 			} else {
 				log.Printf("Worker %d - Nack enviado com sucesso para a mensagem #%d", id, messageCount)
@@ -225,7 +271,156 @@ func (l *Listener) worker(id int, msgs <-chan amqp.Delivery, wg *sync.WaitGroup,
 
 }
 
-func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
+// jobCircuits lista os circuit breakers das procedures de cada tipo de mensagem
+var jobCircuits = map[string][]string{
+	"promocao": {entities.PROC_PROMOCAO_INTEGRA_HERMES},
+	"produto":  {entities.PROC_PRODUTO_INTEGRA_HERMES},
+	"mover": {
+		entities.PROC_MOVER_STAGING_ESTRUTURA,
+		entities.PROC_MOVER_STAGING_PRODUTO,
+		entities.PROC_MOVER_STAGING_EMBALAGEM,
+		entities.PROC_MOVER_STAGING_COMBO,
+		entities.PROC_MOVER_STAGING_PROMOCAO,
+	},
+}
+
+// openCircuit retorna um *database.CircuitOpenError se algum circuit breaker do tipo estiver
+// aberto, com a chamada de teste mais distante, para a mensagem ser adiada sem ocupar o worker
+func openCircuit(tipoIntegracao string) error {
+	var open *database.CircuitOpenError
+	for _, name := range jobCircuits[circuitJobType(tipoIntegracao)] {
+		if retryAt, ok := database.Circuit(name).OpenUntil(); ok && (open == nil || retryAt.After(open.RetryAt)) {
+			open = &database.CircuitOpenError{Name: name, RetryAt: retryAt}
+		}
+	}
+	if open == nil {
+		return nil
+	}
+	return open
+}
+
+// circuitJobType normaliza os aliases de tipoIntegracao usados em jobCircuits
+func circuitJobType(tipoIntegracao string) string {
+	switch tipoIntegracao {
+	case "promocao", "Promocao":
+		return "promocao"
+	case "produto", "Produto":
+		return "produto"
+	case "mover", "productNetworkMain", "product_network_main":
+		return "mover"
+	}
+	return tipoIntegracao
+}
+
+// maxRequeues retorna MaxRequeues ou o padrão quando não configurado
+func (l *Listener) maxRequeues() int {
+	if l.MaxRequeues <= 0 {
+		return entities.DEFAULT_MESSAGE_MAX_REQUEUES
+	}
+	return l.MaxRequeues
+}
+
+// retryDelay retorna a espera antes da nova tentativa attempt (1 = primeira): RetryDelay
+// dobrando a cada tentativa, limitada a MAX_MESSAGE_RETRY_DELAY_SECONDS
+func (l *Listener) retryDelay(attempt int) time.Duration {
+	delay := l.RetryDelay
+	if delay <= 0 {
+		delay = entities.DEFAULT_MESSAGE_RETRY_DELAY_SECONDS * time.Second
+	}
+	limit := entities.MAX_MESSAGE_RETRY_DELAY_SECONDS * time.Second
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// retryCount retorna quantas novas tentativas a mensagem já teve, pelo header x-retry-count
+func retryCount(msg broker.Delivery) int {
+	switch value := msg.Headers[retryCountHeader].(type) {
+	case int64:
+		return int(value)
+	case int32:
+		return int(value)
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return 0
+}
+
+// requeue republica msg na sua fila com x-retry-count = retries, entregue após delay, e
+// confirma a original. A espera fica no broker (broker.DelayedPublisher), então o worker
+// segue livre; sem suporte a atraso a mensagem vai direto para o fim da fila. Se a
+// publicação falhar, a original volta para a fila com Nack, sem contar a tentativa, para
+// não ser perdida.
+func (l *Listener) requeue(msg broker.Delivery, retries int, delay time.Duration) error {
+	retry := msg.Message
+	retry.Headers = make(map[string]interface{}, len(msg.Headers)+1)
+	for key, value := range msg.Headers {
+		retry.Headers[key] = value
+	}
+	retry.Headers[retryCountHeader] = int64(retries)
+
+	var err error
+	if delayed, ok := l.Publisher.(broker.DelayedPublisher); ok && delay > 0 {
+		err = delayed.PublishDelayed(context.Background(), msg.Queue, retry, delay)
+	} else {
+		err = l.Publisher.Publish(context.Background(), "", msg.Queue, retry)
+	}
+	if err != nil {
+		if nackErr := msg.Nack(true); nackErr != nil {
+			return fmt.Errorf("erro ao republicar (%v) e ao devolver a mensagem: %w", err, nackErr)
+		}
+		return fmt.Errorf("erro ao republicar a mensagem, devolvida com Nack: %w", err)
+	}
+	if err := msg.Ack(); err != nil {
+		return fmt.Errorf("mensagem republicada, mas erro ao confirmar a original: %w", err)
+	}
+	return nil
+}
+
+// messageContext retorna o contexto de processamento de uma mensagem, limitado por
+// MessageTimeout e pelo prazo do header "x-deadline" (zero se ausente), o que vencer primeiro
+func (l *Listener) messageContext(header time.Time) (context.Context, context.CancelFunc) {
+	parent := l.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	var deadline time.Time
+	if l.MessageTimeout > 0 {
+		deadline = time.Now().Add(l.MessageTimeout)
+	}
+	if !header.IsZero() && (deadline.IsZero() || header.Before(deadline)) {
+		deadline = header
+	}
+
+	if deadline.IsZero() {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, deadline)
+}
+
+// headerDeadline retorna o prazo do header "x-deadline" (RFC3339); valores inválidos são
+// ignorados
+func headerDeadline(msg broker.Delivery) (time.Time, bool) {
+	value, ok := msg.Headers["x-deadline"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	deadline, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		log.Printf("Header x-deadline inválido '%s' ignorado: %v", value, err)
+		return time.Time{}, false
+	}
+	return deadline, true
+}
+
+func (l *Listener) processMessage(ctx context.Context, msg broker.Delivery) (error, string) {
 	log.Printf("Iniciando processamento de mensagem...")
 	log.Printf("Mensagem recebida (raw): %s", string(msg.Body))
 
@@ -287,6 +482,10 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 
 	log.Printf("Tipo de integração detectado: %s", tipoIntegracao)
 
+	if err := openCircuit(tipoIntegracao); err != nil {
+		return fmt.Errorf("processamento de %s adiado pelo circuit breaker: %w", tipoIntegracao, err), ""
+	}
+
 	switch tipoIntegracao {
 
 	case "promocao", "Promocao":
@@ -301,12 +500,12 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 			log.Printf("Erro ao desserializar dados para entities.Promotion: %v", err)
 			return fmt.Errorf("erro ao desserializar dados para entities.Promotion: %w", err), ""
 		}
-		err = l.PromocaoUC.ProcessarPromocao(promocao)
+		err = l.PromocaoUC.ProcessarPromocao(ctx, promocao)
 		if err != nil {
 			log.Printf("Erro ao processar promoção: %v", err)
 			return fmt.Errorf("erro ao processar promoção: %w", err), ""
 		}
-		err = l.IntegrationUc.IntegrationJob()
+		err = l.IntegrationUc.IntegrationJob(ctx)
 		if err != nil {
 			log.Printf("Erro ao processar integração: %v", err)
 			return fmt.Errorf("erro ao processar integração: %w", err), ""
@@ -322,7 +521,11 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 			return fmt.Errorf("ProductIntegrationUC não foi inicializado"), ""
 		}
 
-		success, err := l.ProductIntegrationUC.ImportProductIntegration()
+		if invalidar, ok := firstPayloadValue(dados, "invalidarCache", "invalidar_cache").(bool); ok && invalidar {
+			l.ProductIntegrationUC.InvalidateCatalogCache()
+		}
+
+		success, err := l.ProductIntegrationUC.ImportProductIntegrationWithOptions(ctx, parseProductIntegrationOptions(dados))
 		if err != nil {
 			log.Printf("Erro ao processar integração de produtos: %v", err)
 			return fmt.Errorf("erro ao processar integração de produtos: %w", err), ""
@@ -348,7 +551,12 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 			return fmt.Errorf("IntegrationUc não foi inicializado"), ""
 		}
 
-		result, err := l.PromotionNormalizationUC.NormalizePromotions()
+		opts, err := parsePromotionNormalizationOptions(dados)
+		if err != nil {
+			log.Printf("Mensagem de normalização de promoções inválida: %v", err)
+			return fmt.Errorf("mensagem de normalização de promoções inválida: %w", err), ""
+		}
+		result, err := l.PromotionNormalizationUC.NormalizePromotionsWithOptions(ctx, opts)
 		if err != nil {
 			log.Printf("Erro ao processar normalização de promoções: %v", err)
 			return fmt.Errorf("erro ao processar normalização de promoções: %w", err), ""
@@ -359,8 +567,106 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 			return fmt.Errorf("normalização de promoções concluída com alguns erros: %s", result.Message), ""
 		}
 
-		log.Printf("Normalização de promoções concluída com sucesso. Processados: %d, Atualizados: %d, Duplicatas removidas: %d",
-			result.ProcessedCount, result.UpdatedCount, result.TotalRemovedDuplicates)
+		log.Printf("Normalização de promoções (%s) concluída com sucesso. Processados: %d, Atualizados: %d, Duplicatas removidas: %d, Páginas: %d",
+			result.Mode, result.ProcessedCount, result.UpdatedCount, result.TotalRemovedDuplicates, result.PagesRead)
+
+		if opts.DryRun {
+			report, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("erro ao serializar resultado do dry-run: %w", err), ""
+			}
+			log.Printf("Resultado do dry-run da normalização: %s", string(report))
+			return nil, string(report)
+		}
+
+	case "estrutura_mercadologica", "EstruturaMercadologica":
+		log.Printf("Iniciando integração de estrutura mercadológica")
+
+		if l.MarketingStructureUC == nil {
+			log.Printf("MarketingStructureUC não foi inicializado")
+			return fmt.Errorf("MarketingStructureUC não foi inicializado"), ""
+		}
+
+		payload, err := json.Marshal(dados)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar dados da estrutura mercadológica: %w", err), ""
+		}
+
+		result := l.MarketingStructureUC.ImportMarketingStructure(ctx, string(payload))
+		if !result.Success {
+			log.Printf("Erro na integração de estrutura mercadológica: %s", result.Message)
+			return fmt.Errorf("erro na integração de estrutura mercadológica: %s", result.Message), ""
+		}
+
+		// Product import and export must see the new hierarchy
+		if l.ProductIntegrationUC != nil {
+			l.ProductIntegrationUC.InvalidateCatalogCache()
+		}
+		if l.ProductExportUC != nil {
+			l.ProductExportUC.InvalidateCatalogCache()
+		}
+
+		log.Printf("Integração de estrutura mercadológica concluída com sucesso")
+
+	case "produto_duplicados", "ProdutoDuplicados":
+		log.Printf("Gerando relatório de marcas e indústrias duplicadas")
+
+		if l.ProductIntegrationUC == nil {
+			log.Printf("ProductIntegrationUC não foi inicializado")
+			return fmt.Errorf("ProductIntegrationUC não foi inicializado"), ""
+		}
+
+		if invalidar, ok := firstPayloadValue(dados, "invalidarCache", "invalidar_cache").(bool); ok && invalidar {
+			l.ProductIntegrationUC.InvalidateCatalogCache()
+		}
+
+		report, err := l.ProductIntegrationUC.CatalogDuplicateReport(ctx)
+		if err != nil {
+			log.Printf("Erro ao gerar relatório de duplicados: %v", err)
+			return fmt.Errorf("erro ao gerar relatório de duplicados: %w", err), ""
+		}
+
+		reportJSON, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar relatório de duplicados: %w", err), ""
+		}
+
+		log.Printf("Relatório de duplicados: %d grupo(s) de marcas, %d grupo(s) de indústrias",
+			len(report.Marcas), len(report.Industrias))
+		return nil, string(reportJSON)
+
+	case "produto_exportacao", "ProdutoExportacao":
+		log.Printf("Iniciando exportação de produtos")
+
+		if l.ProductExportUC == nil {
+			log.Printf("ProductExportUC não foi inicializado")
+			return fmt.Errorf("ProductExportUC não foi inicializado"), ""
+		}
+
+		exportOpts, err := parseProductExportOptions(dados)
+		if err != nil {
+			log.Printf("Mensagem de exportação de produtos inválida: %v", err)
+			return fmt.Errorf("mensagem de exportação de produtos inválida: %w", err), ""
+		}
+		result, err := l.ProductExportUC.ExportProducts(ctx, exportOpts)
+		if err != nil {
+			log.Printf("Erro ao exportar produtos: %v", err)
+			return fmt.Errorf("erro ao exportar produtos: %w", err), ""
+		}
+
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar resultado da exportação: %w", err), ""
+		}
+
+		if !result.Success {
+			log.Printf("Exportação de produtos concluída com alguns erros: %s", result.Message)
+			return fmt.Errorf("exportação de produtos concluída com alguns erros: %s", result.Message), ""
+		}
+
+		log.Printf("Exportação de produtos (%s) concluída com sucesso. Exportados: %d, Destino: %s",
+			result.Mode, result.ExportedCount, result.Destino)
+		return nil, string(resultJSON)
 
 	case "mover", "productNetworkMain", "product_network_main":
 		log.Printf("Iniciando processo ProductNetworkMain")
@@ -373,7 +679,7 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 		// Usar time.Now() como dataCorte
 		dataCorte := time.Now()
 
-		err := l.productNetworkMain(dataCorte)
+		err := l.productNetworkMain(ctx, dataCorte)
 		if err != nil {
 			log.Printf("Erro ao executar ProductNetworkMain: %v", err)
 			return fmt.Errorf("erro ao executar ProductNetworkMain: %w", err), ""
@@ -389,13 +695,230 @@ func (l *Listener) processMessage(msg amqp.Delivery) (error, string) {
 	return nil, ""
 }
 
+// reply publica a resposta de uma mensagem na fila indicada em ReplyTo
+func (l *Listener) reply(msg broker.Delivery, response string) {
+	err := l.Publisher.Publish(context.Background(), "", msg.ReplyTo, broker.Message{
+		ContentType:   "application/json",
+		CorrelationId: msg.CorrelationId,
+		Body:          []byte(response),
+	})
+	if err != nil {
+		log.Printf("Erro ao responder em %s: %v", msg.ReplyTo, err)
+		return
+	}
+
+	log.Printf("Resposta enviada para %s", msg.ReplyTo)
+}
+
+// parsePromotionNormalizationOptions extrai as opções de normalização do campo "dados" da mensagem.
+// Aceita {"modo": "full"} ou {"full": true}, {"page_size": 200}, {"dryRun": true} e o escopo
+// {"IdRevendedor": [1, 2], "IdPromocao": 10, "codMix": ["ABC"]}. Um escopo presente mas
+// inválido é erro: ignorá-lo normalizaria a tabela inteira.
+func parsePromotionNormalizationOptions(dados map[string]interface{}) (entities.PromotionNormalizationOptions, error) {
+	var opts entities.PromotionNormalizationOptions
+
+	if modo, ok := dados["modo"].(string); ok && strings.EqualFold(modo, entities.PROMOTION_NORMALIZATION_MODE_FULL) {
+		opts.Full = true
+	}
+	if full, ok := dados["full"].(bool); ok {
+		opts.Full = full
+	}
+	if pageSize, ok := dados["page_size"].(float64); ok && pageSize > 0 {
+		opts.PageSize = int(pageSize)
+	}
+
+	var err error
+	if opts.IdRevendedores, err = payloadInts(dados, "IdRevendedor", "idRevendedor", "id_revendedor"); err != nil {
+		return opts, err
+	}
+	if opts.IdPromocoes, err = payloadInts(dados, "IdPromocao", "idPromocao", "id_promocao"); err != nil {
+		return opts, err
+	}
+	if opts.CodMix, err = payloadStrings(dados, "codMix", "CodMix", "cod_mix"); err != nil {
+		return opts, err
+	}
+
+	// Um dryRun que não é lido gravaria a tabela, então valores inválidos são erro
+	switch dryRun := firstPayloadValue(dados, "dryRun", "dry_run").(type) {
+	case nil:
+	case bool:
+		opts.DryRun = dryRun
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(dryRun))
+		if err != nil {
+			return opts, fmt.Errorf("dryRun inválido: %q", dryRun)
+		}
+		opts.DryRun = parsed
+	default:
+		return opts, fmt.Errorf("dryRun inválido: %v", dryRun)
+	}
+	if idExecucao, ok := firstPayloadValue(dados, "idExecucao", "id_execucao").(string); ok {
+		opts.IdExecucao = strings.TrimSpace(idExecucao)
+	}
+
+	return opts, nil
+}
+
+// parseProductIntegrationOptions lê as opções da importação de produtos do campo "dados"
+func parseProductIntegrationOptions(dados map[string]interface{}) entities.ProductIntegrationOptions {
+	var opts entities.ProductIntegrationOptions
+
+	switch force := firstPayloadValue(dados, "force", "forcar").(type) {
+	case bool:
+		opts.Force = force
+	case string:
+		opts.Force, _ = strconv.ParseBool(strings.TrimSpace(force))
+	}
+	if idExecucao, ok := firstPayloadValue(dados, "idExecucao", "id_execucao").(string); ok {
+		opts.IdExecucao = strings.TrimSpace(idExecucao)
+	}
+
+	return opts
+}
+
+// parseProductExportOptions lê as opções da exportação de produtos do campo "dados".
+// Aceita {"modo": "full"} ou {"full": true}, {"destino": "exchange"}, {"page_size": 200}
+// e o escopo {"IdProduto": [1, 2], "codigoRms": 123, "IdRevendedor": 10}. Um escopo presente
+// mas inválido é erro, como na normalização de promoções.
+func parseProductExportOptions(dados map[string]interface{}) (entities.ProductExportOptions, error) {
+	var opts entities.ProductExportOptions
+
+	if modo, ok := dados["modo"].(string); ok && strings.EqualFold(modo, entities.PRODUCT_EXPORT_MODE_FULL) {
+		opts.Full = true
+	}
+	if full, ok := dados["full"].(bool); ok {
+		opts.Full = full
+	}
+	if pageSize, ok := dados["page_size"].(float64); ok && pageSize > 0 {
+		opts.PageSize = int(pageSize)
+	}
+	if destino, ok := dados["destino"].(string); ok {
+		opts.Destino = destino
+	}
+
+	var err error
+	if opts.IdProdutos, err = payloadInts(dados, "IdProduto", "idProduto", "id_produto"); err != nil {
+		return opts, err
+	}
+	if opts.CodigosRMS, err = payloadInts(dados, "codigoRms", "CodigoRms", "codigo_rms"); err != nil {
+		return opts, err
+	}
+	if opts.IdRevendedores, err = payloadInts(dados, "IdRevendedor", "idRevendedor", "id_revendedor"); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// firstPayloadValue retorna o valor da primeira chave presente em dados
+func firstPayloadValue(dados map[string]interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		if value, ok := dados[key]; ok {
+			return value
+		}
+	}
+	return nil
+}
+
+// payloadInts lê o escopo numérico da primeira chave presente em dados; nil quando nenhuma está
+func payloadInts(dados map[string]interface{}, keys ...string) ([]int, error) {
+	for _, key := range keys {
+		if value, ok := dados[key]; ok {
+			ints, err := intsFromPayload(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s inválido: %w", key, err)
+			}
+			return ints, nil
+		}
+	}
+	return nil, nil
+}
+
+// payloadStrings lê o escopo textual da primeira chave presente em dados; nil quando nenhuma está
+func payloadStrings(dados map[string]interface{}, keys ...string) ([]string, error) {
+	for _, key := range keys {
+		if value, ok := dados[key]; ok {
+			values, err := stringsFromPayload(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s inválido: %w", key, err)
+			}
+			return values, nil
+		}
+	}
+	return nil, nil
+}
+
+// intsFromPayload converte um número inteiro, string numérica ou lista não vazia deles em []int
+func intsFromPayload(value interface{}) ([]int, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("%v não é um número inteiro", v)
+		}
+		return []int{int(v)}, nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%q não é um número inteiro", v)
+		}
+		return []int{n}, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("lista vazia")
+		}
+		var result []int
+		for _, item := range v {
+			if _, nested := item.([]interface{}); nested {
+				return nil, fmt.Errorf("lista aninhada")
+			}
+			ints, err := intsFromPayload(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, ints...)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("valor %v não é número nem lista de números", value)
+}
+
+// stringsFromPayload converte uma string não vazia, número ou lista não vazia deles em []string
+func stringsFromPayload(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("texto vazio")
+		}
+		return []string{strings.TrimSpace(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("lista vazia")
+		}
+		var result []string
+		for _, item := range v {
+			if _, nested := item.([]interface{}); nested {
+				return nil, fmt.Errorf("lista aninhada")
+			}
+			values, err := stringsFromPayload(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, values...)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("valor %v não é texto nem lista de textos", value)
+}
+
 // productNetworkMain executa o job principal de integração de produtos e rede
 // Baseado na função TypeScript productNetworkMain
-func (l *Listener) productNetworkMain(dataCorte time.Time) error {
+func (l *Listener) productNetworkMain(ctx context.Context, dataCorte time.Time) error {
 	log.Printf("Job Integração - Início")
 
 	// Executar integração principal
-	if err := l.IntegrationUc.IntegrationJob(); err != nil {
+	if err := l.IntegrationUc.IntegrationJob(ctx); err != nil {
 		log.Printf("Erro ao executar integração: %v", err)
 		return fmt.Errorf("erro ao executar integração: %w", err)
 	}
@@ -407,13 +930,13 @@ func (l *Listener) productNetworkMain(dataCorte time.Time) error {
 	}
 
 	// Mover dados usando o dataCorte fornecido
-	if err := l.IntegrationUc.MoveDataJob(dataCorte); err != nil {
+	if err := l.IntegrationUc.MoveDataJob(ctx, dataCorte); err != nil {
 		log.Printf("Erro ao mover dados: %v", err)
 		return fmt.Errorf("erro ao mover dados: %w", err)
 	}
 
 	// Atualizar solicitações SLA expiradas
-	if err := l.IntegrationUc.UpdateExpirationSlaRequestsJob(); err != nil {
+	if err := l.IntegrationUc.UpdateExpirationSlaRequestsJob(ctx); err != nil {
 		log.Printf("Erro ao atualizar solicitações SLA expiradas: %v", err)
 		return fmt.Errorf("erro ao atualizar solicitações SLA expiradas: %w", err)
 	}
@@ -0,0 +1,268 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
+)
+
+const testQueue = "integracaoCron"
+
+// testRetryDelay keeps the retries of the tests short
+const testRetryDelay = 20 * time.Millisecond
+
+// startListener runs a single-worker listener on mem with process as the message handler
+// and returns a function that stops it and waits for it to return
+func startListener(t *testing.T, mem *broker.MemoryBroker, maxRequeues int, process func(context.Context, broker.Delivery) (error, string)) func() {
+	t.Helper()
+	return runListener(t, &Listener{
+		Workers:     1,
+		MaxRequeues: maxRequeues,
+		RetryDelay:  testRetryDelay,
+		Consumer:    mem,
+		Publisher:   mem,
+		process:     process,
+	})
+}
+
+// runListener runs listener and returns a function that stops it and waits for it to return
+func runListener(t *testing.T, listener *Listener) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.ListenToQueueContext(ctx, "")
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("listener did not stop")
+		}
+	}
+}
+
+// publish sends body to the listener queue
+func publish(t *testing.T, mem *broker.MemoryBroker, body string) {
+	t.Helper()
+	publishMessage(t, mem, broker.Message{Body: []byte(body)})
+}
+
+// publishMessage sends msg to the listener queue
+func publishMessage(t *testing.T, mem *broker.MemoryBroker, msg broker.Message) {
+	t.Helper()
+	if err := mem.DeclareQueue(testQueue); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.Publish(context.Background(), "", testQueue, msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestListenerTransientErrorIsRetriedUpToTheLimit(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	publish(t, mem, `"promocao"`)
+
+	var calls atomic.Int32
+	transient := &database.OracleError{Code: 60, Kind: database.ErrorKindDeadlock, Err: errors.New("ORA-00060: deadlock detected")}
+	stop := startListener(t, mem, 2, func(ctx context.Context, msg broker.Delivery) (error, string) {
+		calls.Add(1)
+		return transient, ""
+	})
+
+	waitFor(t, "the message to be dead-lettered", func() bool { return len(mem.DeadLetters(testQueue)) == 1 })
+	stop()
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("processed %d times, want 3 (the delivery and 2 retries)", got)
+	}
+	if got := mem.Len(testQueue); got != 0 {
+		t.Errorf("%d messages left in the queue, want 0", got)
+	}
+	deadLetter := mem.DeadLetters(testQueue)[0]
+	if got := deadLetter.Headers[retryCountHeader]; got != int64(2) {
+		t.Errorf("dead letter %s = %v, want 2", retryCountHeader, got)
+	}
+	if string(deadLetter.Body) != `"promocao"` {
+		t.Errorf("dead letter body = %s, want the original", deadLetter.Body)
+	}
+}
+
+func TestListenerAcksProcessedMessages(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	if err := mem.DeclareQueue("respostas"); err != nil {
+		t.Fatal(err)
+	}
+	publish(t, mem, `"produto_duplicados"`)
+	if err := mem.Publish(context.Background(), "", testQueue, broker.Message{Body: []byte(`"produto_exportacao"`), ReplyTo: "respostas"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	stop := startListener(t, mem, 2, func(ctx context.Context, msg broker.Delivery) (error, string) {
+		calls.Add(1)
+		return nil, `{"success":true}`
+	})
+
+	waitFor(t, "both messages to be settled", func() bool { return calls.Load() == 2 && mem.Unacked(testQueue) == 0 })
+	stop()
+
+	if got := mem.Len("respostas"); got != 1 {
+		t.Errorf("%d replies, want 1", got)
+	}
+	if got := mem.Len(testQueue); got != 0 {
+		t.Errorf("%d messages left in the queue, want 0", got)
+	}
+	if got := len(mem.DeadLetters(testQueue)); got != 0 {
+		t.Errorf("%d dead letters, want 0", got)
+	}
+}
+
+func TestListenerAcksMessageThatSucceedsOnRetry(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	publish(t, mem, `"mover"`)
+
+	var calls atomic.Int32
+	var failedAt time.Time
+	stop := startListener(t, mem, 2, func(ctx context.Context, msg broker.Delivery) (error, string) {
+		if calls.Add(1) == 1 {
+			failedAt = time.Now()
+			return context.DeadlineExceeded, ""
+		}
+		if got := retryCount(msg); got != 1 {
+			t.Errorf("retry delivered with %s = %d, want 1", retryCountHeader, got)
+		}
+		if waited := time.Since(failedAt); waited < testRetryDelay {
+			t.Errorf("retry delivered after %s, want at least %s", waited, testRetryDelay)
+		}
+		return nil, ""
+	})
+
+	waitFor(t, "the retry to be settled", func() bool { return calls.Load() == 2 && mem.Unacked(testQueue) == 0 })
+	stop()
+
+	if got := mem.Len(testQueue); got != 0 {
+		t.Errorf("%d messages left in the queue, want 0", got)
+	}
+	if got := len(mem.DeadLetters(testQueue)); got != 0 {
+		t.Errorf("%d dead letters, want 0", got)
+	}
+}
+
+func TestListenerDeadLettersPermanentErrors(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	publish(t, mem, `"tipo_inexistente"`)
+
+	// No handler: the real processMessage rejects the unknown type without touching Oracle
+	stop := startListener(t, mem, 2, nil)
+
+	waitFor(t, "the message to be dead-lettered", func() bool { return len(mem.DeadLetters(testQueue)) == 1 })
+	stop()
+
+	deadLetter := mem.DeadLetters(testQueue)[0]
+	if _, retried := deadLetter.Headers[retryCountHeader]; retried {
+		t.Errorf("permanent error was retried: %v", deadLetter.Headers)
+	}
+	if got := mem.Len(testQueue); got != 0 {
+		t.Errorf("%d messages left in the queue, want 0", got)
+	}
+}
+
+func TestListenerDeadLettersMessagePastItsDeadline(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	publishMessage(t, mem, broker.Message{
+		Body:    []byte(`"promocao"`),
+		Headers: map[string]interface{}{"x-deadline": time.Now().Add(-time.Minute).Format(time.RFC3339)},
+	})
+
+	var calls atomic.Int32
+	stop := startListener(t, mem, 2, func(ctx context.Context, msg broker.Delivery) (error, string) {
+		calls.Add(1)
+		return nil, ""
+	})
+
+	waitFor(t, "the message to be dead-lettered", func() bool { return len(mem.DeadLetters(testQueue)) == 1 })
+	stop()
+
+	if got := calls.Load(); got != 0 {
+		t.Errorf("processed %d times, want 0", got)
+	}
+}
+
+func TestListenerDoesNotRetryPastTheDeadline(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	// Later than now but sooner than the first retry delay
+	publishMessage(t, mem, broker.Message{
+		Body:    []byte(`"promocao"`),
+		Headers: map[string]interface{}{"x-deadline": time.Now().Add(2 * time.Second).Format(time.RFC3339)},
+	})
+
+	var calls atomic.Int32
+	stop := runListener(t, &Listener{
+		Workers:    1,
+		RetryDelay: time.Minute,
+		Consumer:   mem,
+		Publisher:  mem,
+		process: func(ctx context.Context, msg broker.Delivery) (error, string) {
+			calls.Add(1)
+			return context.DeadlineExceeded, ""
+		},
+	})
+
+	waitFor(t, "the message to be dead-lettered", func() bool { return len(mem.DeadLetters(testQueue)) == 1 })
+	stop()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("processed %d times, want 1", got)
+	}
+}
+
+func TestListenerDefersMessagesWhileCircuitIsOpen(t *testing.T) {
+	mem := broker.NewMemoryBroker()
+	publish(t, mem, `"promocao"`)
+
+	retryAt := time.Now().Add(100 * time.Millisecond)
+	var calls atomic.Int32
+	stop := startListener(t, mem, 1, func(ctx context.Context, msg broker.Delivery) (error, string) {
+		// Rejected more times than MaxRequeues allows: deferrals are not retries
+		if calls.Add(1) <= 2 {
+			return fmt.Errorf("procedure rejeitada: %w", &database.CircuitOpenError{Name: "sp_teste", RetryAt: retryAt}), ""
+		}
+		if got := retryCount(msg); got != 0 {
+			t.Errorf("deferred message delivered with %s = %d, want 0", retryCountHeader, got)
+		}
+		if now := time.Now(); now.Before(retryAt) {
+			t.Errorf("deferred message delivered %s before the circuit trial", retryAt.Sub(now))
+		}
+		return nil, ""
+	})
+
+	waitFor(t, "the deferred message to be settled", func() bool { return calls.Load() == 3 && mem.Unacked(testQueue) == 0 })
+	stop()
+
+	if got := len(mem.DeadLetters(testQueue)); got != 0 {
+		t.Errorf("%d dead letters, want 0", got)
+	}
+}
@@ -0,0 +1,194 @@
+package rabbitmq
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/thiagohmm/integracaocron/domain/entities"
+	"github.com/thiagohmm/integracaocron/domain/usecases"
+	"github.com/thiagohmm/integracaocron/infraestructure/broker"
+	"github.com/thiagohmm/integracaocron/infraestructure/database"
+	infraestructure "github.com/thiagohmm/integracaocron/infraestructure/rabbitmq"
+)
+
+// LogConsumer reads the LogIntegrRMS queue and writes the messages to LOG_INTEGR_RMS in
+// batches. Messages are acknowledged only after the batch is committed. A transient failure
+// requeues the whole batch; any other failure retries the rows one by one so only the rows
+// Oracle refuses are rejected. Malformed messages are rejected without requeue.
+type LogConsumer struct {
+	UC            *usecases.LogConsumerUseCase
+	Queue         string
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// Consumer reads the queue; without it Run uses the RabbitMQ at its URL
+	Consumer broker.Consumer
+
+	stopOnce sync.Once
+	initOnce sync.Once
+	stop     chan struct{}
+}
+
+// pendingLog is a valid message waiting for its batch to be committed
+type pendingLog struct {
+	delivery broker.Delivery
+	row      entities.LogIntegrRMS
+}
+
+// Run consumes the queue until Stop is called, reconnecting when the connection drops
+func (c *LogConsumer) Run(rabbitmqURL string) {
+	if c.Queue == "" {
+		c.Queue = entities.DEFAULT_LOG_QUEUE
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = entities.DEFAULT_LOG_CONSUMER_BATCH_SIZE
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = entities.DEFAULT_LOG_CONSUMER_FLUSH_INTERVAL_SECONDS * time.Second
+	}
+	if c.Consumer == nil {
+		c.Consumer = infraestructure.NewAMQPBroker(rabbitmqURL)
+	}
+	log.Printf("Consumidor de logs iniciado na fila '%s' (lote %d, intervalo %s)", c.Queue, c.BatchSize, c.FlushInterval)
+
+	for {
+		if err := c.consume(); err != nil {
+			log.Printf("Erro no consumidor de logs: %v. Reconectando em 5 segundos...", err)
+		}
+
+		select {
+		case <-c.stopped():
+			log.Printf("Consumidor de logs finalizado")
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// Stop makes Run flush the current batch and return
+func (c *LogConsumer) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopped())
+	})
+}
+
+// stopped returns the channel closed by Stop
+func (c *LogConsumer) stopped() chan struct{} {
+	c.initOnce.Do(func() {
+		c.stop = make(chan struct{})
+	})
+	return c.stop
+}
+
+// consume processes deliveries until the consumer channel closes or Stop is called
+func (c *LogConsumer) consume() error {
+	// Cancelled only on return, so the batch flushed on Stop can still be acknowledged
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The prefetch covers a full batch, otherwise the broker stops delivering before it fills
+	msgs, err := c.Consumer.Consume(ctx, c.Queue, c.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(c.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]pendingLog, 0, c.BatchSize)
+	for {
+		select {
+		case <-c.stopped():
+			c.flush(batch)
+			return nil
+
+		case <-ticker.C:
+			batch = c.flush(batch)
+
+		case msg, ok := <-msgs:
+			if !ok {
+				// The channel is gone, so the pending deliveries are redelivered and must not be inserted
+				log.Printf("Canal da fila '%s' fechado, %d logs pendentes serão reentregues", c.Queue, len(batch))
+				return nil
+			}
+
+			row, err := c.UC.ParseLogMessage(msg.Body)
+			if err != nil {
+				log.Printf("Mensagem de log inválida descartada: %v", err)
+				if err := msg.Nack(false); err != nil {
+					log.Printf("Erro ao rejeitar mensagem de log: %v", err)
+				}
+				continue
+			}
+
+			batch = append(batch, pendingLog{delivery: msg, row: row})
+			if len(batch) >= c.BatchSize {
+				batch = c.flush(batch)
+			}
+		}
+	}
+}
+
+// flush writes the batch and settles its deliveries, returning the emptied batch
+func (c *LogConsumer) flush(batch []pendingLog) []pendingLog {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	rows := make([]entities.LogIntegrRMS, len(batch))
+	for i, pending := range batch {
+		rows[i] = pending.row
+	}
+
+	// Not tied to Stop: the batch read before stopping is still written and acknowledged
+	if err := c.UC.SaveBatch(context.Background(), rows); err != nil {
+		if database.IsTransient(err) {
+			log.Printf("Erro ao gravar lote de %d logs, mensagens devolvidas à fila: %v", len(batch), err)
+			for _, pending := range batch {
+				if err := pending.delivery.Nack(true); err != nil {
+					log.Printf("Erro ao devolver mensagem de log: %v", err)
+				}
+			}
+			return batch[:0]
+		}
+
+		// A permanent error (ORA-12899, ORA-01400...) comes from some row: requeueing the
+		// batch would fail forever, so each row is written on its own
+		log.Printf("Erro ao gravar lote de %d logs, gravando linha a linha: %v", len(batch), err)
+		c.flushEach(batch)
+		return batch[:0]
+	}
+
+	for _, pending := range batch {
+		if err := pending.delivery.Ack(); err != nil {
+			log.Printf("Erro ao confirmar mensagem de log: %v", err)
+		}
+	}
+	return batch[:0]
+}
+
+// flushEach writes every row in its own transaction. Rows refused by Oracle are rejected
+// without requeue; rows that hit a transient error go back to the queue.
+func (c *LogConsumer) flushEach(batch []pendingLog) {
+	for _, pending := range batch {
+		err := c.UC.SaveBatch(context.Background(), []entities.LogIntegrRMS{pending.row})
+		switch {
+		case err == nil:
+			if err := pending.delivery.Ack(); err != nil {
+				log.Printf("Erro ao confirmar mensagem de log: %v", err)
+			}
+		case database.IsTransient(err):
+			log.Printf("Erro ao gravar log, mensagem devolvida à fila: %v", err)
+			if err := pending.delivery.Nack(true); err != nil {
+				log.Printf("Erro ao devolver mensagem de log: %v", err)
+			}
+		default:
+			log.Printf("Log recusado pelo banco, mensagem rejeitada: %v", err)
+			if err := pending.delivery.Nack(false); err != nil {
+				log.Printf("Erro ao rejeitar mensagem de log: %v", err)
+			}
+		}
+	}
+}